@@ -0,0 +1,400 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/wakatime/semver-action/cmd/generate"
+	"github.com/wakatime/semver-action/pkg/actions"
+	"github.com/wakatime/semver-action/pkg/ci"
+	"github.com/wakatime/semver-action/pkg/git"
+	"github.com/wakatime/semver-action/pkg/output"
+
+	"github.com/apex/log"
+)
+
+const (
+	commandNext     = "next"
+	commandExplain  = "explain"
+	commandCurrent  = "current"
+	commandValidate = "validate"
+
+	outputHuman = "human"
+	outputJSON  = "json"
+)
+
+// Exit codes returned by Run, see the Exit Codes section of the README.
+const (
+	exitOK             = 0
+	exitError          = 1
+	exitUsage          = 2
+	exitNotRepository  = 3
+	exitShallowClone   = 4
+	exitNoSourceBranch = 5
+	exitInvalidTag     = 6
+	exitTagExists      = 7
+)
+
+// flagInput maps a command line flag to an action input.
+type flagInput struct {
+	Input string
+	Usage string
+	Bool  bool
+}
+
+// nolint: gochecknoglobals
+var flagInputs = []flagInput{
+	{Input: "commit_sha", Usage: "commit sha to calculate the version for (default $GITHUB_SHA)"},
+	{Input: "repo_dir", Usage: "the repository path (default \".\")"},
+	{Input: "bump", Usage: "bump strategy, can be auto, major, minor, patch (default \"auto\")"},
+	{Input: "base_version", Usage: "version to use as base for the generation"},
+	{Input: "prefix", Usage: "prefix used to prepend the final version (default \"v\")"},
+	{Input: "prerelease_id", Usage: "text representing the prerelease identifier (default \"pre\")"},
+	{Input: "main_branch_name", Usage: "the main branch name (default \"master\")"},
+	{Input: "develop_branch_name", Usage: "the develop branch name (default \"develop\")"},
+	{Input: "source_branch", Usage: "source branch of the merge instead of reading it from the commit message"},
+	{Input: "dest_branch", Usage: "branch merged into instead of the current branch"},
+	{Input: "pull_request", Usage: "number of the pull request to calculate a preview version for"},
+	{Input: "changelog_file", Usage: "path to write the generated changelog to"},
+	{Input: "update_changelog", Usage: "path to a changelog in Keep a Changelog format to add the new version to"},
+	{Input: "create_tag", Usage: "creates the calculated tag on the commit", Bool: true},
+	{Input: "annotate_tag", Usage: "creates an annotated tag", Bool: true},
+	{Input: "tag_message", Usage: "go template for the annotated tag message"},
+	{Input: "sign_tag", Usage: "creates a signed tag", Bool: true},
+	{Input: "signing_key", Usage: "the key to sign the tag with"},
+	{Input: "signing_format", Usage: "the signature format, can be openpgp, ssh, x509"},
+	{Input: "push_tag", Usage: "pushes the created tag to the remote", Bool: true},
+	{Input: "remote", Usage: "the remote to push the tag to (default \"origin\")"},
+	{Input: "push_retries", Usage: "how many times to recalculate the tag when the push is rejected (default 3)"},
+	{Input: "tag_selection", Usage: "how the latest tag is selected, can be latest, highest, highest-reachable" +
+		" (default \"latest\")"},
+	{Input: "shallow_policy", Usage: "what to do in shallow clones, can be deepen, unshallow, fail, ignore" +
+		" (default \"deepen\")"},
+	{Input: "tag_exists", Usage: "what to do when the tag already exists, can be fail, bump, reuse (default \"fail\")"},
+	{Input: "check_remote_tags", Usage: "also checks the tags of the remote with git ls-remote", Bool: true},
+	{Input: "output_formats", Usage: "additional outputs, e.g. dotenv=.env,shell=semver.sh,github_env,json=semver.json"},
+	{Input: "git_backend", Usage: "how the repository is read, can be exec, native, snapshot (default \"exec\")"},
+	{Input: "git_timeout", Usage: "how long a single git command may run, 0 disables it (default \"5m\")"},
+	{Input: "timeout", Usage: "how long the whole run may take, 0 disables it (default \"15m\")"},
+	{Input: "global_safe_directory", Usage: "adds the repository to the safe.directory global git config", Bool: true},
+	{
+		Input: "branch_snapshots",
+		Usage: "calculates snapshot versions on pushes to branches other than main and develop",
+		Bool:  true,
+	},
+	{Input: "debug", Usage: "enables debug mode", Bool: true},
+}
+
+// errUsage is returned when the command line is invalid.
+var errUsage = errors.New("invalid usage") // nolint
+
+// errConfig is returned when an input has an invalid value.
+var errConfig = errors.New("invalid configuration") // nolint
+
+// Run runs the command line with the given arguments and returns the exit code.
+func Run(args []string, stdout, stderr io.Writer) int {
+	command, args := splitCommand(args)
+
+	// Interrupting the run kills running git commands.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, command, args, stdout, stderr)
+
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errUsage):
+		// the flag set already printed the error and usage
+		return exitUsage
+	}
+
+	log.Errorf("%s\n", err)
+
+	return exitCode(err)
+}
+
+// exitCode returns the exit code for the error, so wrapper scripts can tell a
+// misconfiguration or an expected outcome apart from a failing git command.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, errConfig):
+		return exitUsage
+	case errors.Is(err, git.ErrNotRepository):
+		return exitNotRepository
+	case errors.Is(err, generate.ErrShallowClone):
+		return exitShallowClone
+	case errors.Is(err, git.ErrNoSourceBranch):
+		return exitNoSourceBranch
+	case errors.Is(err, generate.ErrInvalidTag):
+		return exitInvalidTag
+	case errors.Is(err, generate.ErrTagExists):
+		return exitTagExists
+	default:
+		return exitError
+	}
+}
+
+// splitCommand returns the subcommand and its arguments. The next command is
+// used when no subcommand is given. Inside GitHub Actions unknown positional
+// arguments are ignored, since inputs are read from the environment.
+func splitCommand(args []string) (string, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return commandNext, args
+	}
+
+	switch args[0] {
+	case commandNext, commandExplain, commandCurrent, commandValidate:
+		return args[0], args[1:]
+	}
+
+	if isGithubActions() {
+		return commandNext, nil
+	}
+
+	return args[0], args[1:]
+}
+
+func run(ctx context.Context, command string, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("semver "+command, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: semver [next|explain|current|validate] [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	values := map[string]*string{}
+
+	for _, fi := range flagInputs {
+		name := strings.ReplaceAll(fi.Input, "_", "-")
+
+		if fi.Bool {
+			values[fi.Input] = new(string)
+			fs.Var(boolValue{values[fi.Input]}, name, fi.Usage)
+
+			continue
+		}
+
+		values[fi.Input] = fs.String(name, "", fi.Usage)
+	}
+
+	format := fs.String("output", outputHuman, "output format when not running in GitHub Actions, can be human, json")
+
+	switch command {
+	case commandNext, commandExplain, commandCurrent, commandValidate:
+	default:
+		fs.Usage()
+
+		return fmt.Errorf("unknown command %q: %w", command, errUsage)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", err, errUsage)
+	}
+
+	if *format != outputHuman && *format != outputJSON {
+		return fmt.Errorf("invalid output format %q: %w", *format, errUsage)
+	}
+
+	set := map[string]bool{}
+
+	fs.Visit(func(f *flag.Flag) {
+		set[strings.ReplaceAll(f.Name, "-", "_")] = true
+	})
+
+	params, err := generate.LoadParamsFrom(func(name string) string {
+		if set[name] {
+			return strings.TrimSpace(*values[name])
+		}
+
+		return actions.GetInput(name)
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %s", errConfig, err)
+	}
+
+	switch command {
+	case commandExplain:
+		return runExplain(ctx, params, *format, stdout)
+	case commandCurrent:
+		return runCurrent(ctx, params, *format, stdout)
+	case commandValidate:
+		if fs.NArg() != 1 {
+			return fmt.Errorf("validate requires exactly one version argument: %w", errUsage)
+		}
+
+		return runValidate(fs.Arg(0), params.Prefix, *format, stdout)
+	default:
+		return runNext(ctx, params, *format, stdout)
+	}
+}
+
+func runNext(ctx context.Context, params generate.Params, format string, stdout io.Writer) error {
+	result, err := generate.Run(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to generate semver version: %w", err)
+	}
+
+	provider := ci.Detect().Provider
+
+	outputs, err := result.Outputs()
+	if err != nil {
+		return err
+	}
+
+	if provider == ci.GitHub {
+		actions.Group(stdout, "Explanation")
+	}
+
+	// Print how the version was calculated.
+	log.Infof("EXPLANATION:\n%s", result.Decision.Explain())
+
+	for _, o := range outputs {
+		log.Infof("%s: %s", strings.ToUpper(o.Name), o.Value)
+	}
+
+	if provider == ci.GitHub {
+		actions.EndGroup(stdout)
+		actions.Notice(stdout, fmt.Sprintf("Calculated %s from %s (%s)", result.SemverTag, result.PreviousTag, result.BumpReason))
+
+		if err := actions.AppendSummary(result.Summary()); err != nil {
+			return err
+		}
+	}
+
+	formats := append(output.DefaultFormats(provider), params.OutputFormats...)
+
+	writers := make([]output.Writer, 0, len(formats))
+
+	for _, f := range formats {
+		w, err := output.New(f, stdout)
+		if err != nil {
+			return fmt.Errorf("failed to create %s output writer: %s", f.Name, err)
+		}
+
+		writers = append(writers, w)
+	}
+
+	if err := output.WriteAll(writers, output.Outputs{Values: outputs, Data: result}); err != nil {
+		return err
+	}
+
+	if provider != ci.Unknown {
+		return nil
+	}
+
+	if format == outputJSON {
+		data, err := result.JSON()
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(stdout, data)
+
+		return err
+	}
+
+	_, err = fmt.Fprintln(stdout, result.SemverTag)
+
+	return err
+}
+
+func runExplain(ctx context.Context, params generate.Params, format string, stdout io.Writer) error {
+	result, err := generate.Explain(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to explain semver version: %w", err)
+	}
+
+	if format == outputJSON {
+		data, err := result.Decision.JSON()
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(stdout, data)
+
+		return err
+	}
+
+	_, err = fmt.Fprintf(stdout, "%ssemver tag: %s\n", result.Decision.Explain(), result.SemverTag)
+
+	return err
+}
+
+func runCurrent(ctx context.Context, params generate.Params, format string, stdout io.Writer) error {
+	tag, err := generate.Current(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to get current version: %w", err)
+	}
+
+	if format == outputJSON {
+		return json.NewEncoder(stdout).Encode(map[string]string{"tag": tag})
+	}
+
+	_, err = fmt.Fprintln(stdout, tag)
+
+	return err
+}
+
+func runValidate(version, prefix, format string, stdout io.Writer) error {
+	parsed, err := generate.Validate(version, prefix)
+
+	if format == outputJSON {
+		data := map[string]interface{}{"valid": err == nil, "tag": version}
+		if err != nil {
+			data["error"] = err.Error()
+		} else {
+			data["version"] = parsed.String()
+		}
+
+		if encErr := json.NewEncoder(stdout).Encode(data); encErr != nil {
+			return encErr
+		}
+	} else if err == nil {
+		if _, err := fmt.Fprintf(stdout, "%s is valid\n", version); err != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+func isGithubActions() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// boolValue is a flag value that can be used without argument and stores the
+// parsed boolean as string so it can be read like an action input.
+type boolValue struct {
+	value *string
+}
+
+func (b boolValue) String() string {
+	if b.value == nil {
+		return ""
+	}
+
+	return *b.value
+}
+
+func (b boolValue) Set(s string) error {
+	*b.value = s
+
+	return nil
+}
+
+func (boolValue) IsBoolFlag() bool {
+	return true
+}
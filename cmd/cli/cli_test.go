@@ -0,0 +1,589 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wakatime/semver-action/cmd/cli"
+
+	"github.com/alecthomas/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_Next(t *testing.T) {
+	repo, sha := setupRepo(t)
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"next", "--repo-dir", repo, "--commit-sha", sha}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	assert.Equal(t, "v1.1.0-pre.1\n", stdout.String())
+}
+
+func TestRun_NextDefaultCommand(t *testing.T) {
+	repo, sha := setupRepo(t)
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"--repo-dir", repo, "--commit-sha", sha, "--prefix", "v", "--bump", "major"}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	assert.Equal(t, "v2.0.0\n", stdout.String())
+}
+
+func TestRun_NextJSON(t *testing.T) {
+	repo, sha := setupRepo(t)
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"next", "--repo-dir", repo, "--commit-sha", sha, "--output", "json"}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	var result map[string]interface{}
+
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+
+	assert.Equal(t, "v1.1.0-pre.1", result["semver_tag"])
+	assert.Equal(t, "feature/login", result["source_branch"])
+	assert.Equal(t, "develop", result["dest_branch"])
+}
+
+func TestRun_NextSourceAndDestBranch(t *testing.T) {
+	repo, sha := setupRepo(t)
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{
+		"next", "--repo-dir", repo, "--commit-sha", sha,
+		"--source-branch", "hotfix/crash", "--dest-branch", "master",
+	}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	assert.Equal(t, "v1.0.1\n", stdout.String())
+}
+
+func TestRun_NextAlreadyTagged(t *testing.T) {
+	repo, sha := setupRepo(t)
+
+	runGit(t, repo, "tag", "v1.1.0-pre.1")
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{
+		"next", "--repo-dir", repo, "--commit-sha", sha, "--create-tag", "--output", "json",
+	}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	var result map[string]interface{}
+
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+
+	assert.Equal(t, "v1.1.0-pre.1", result["semver_tag"])
+	assert.Equal(t, "v1.0.0", result["previous_tag"])
+	assert.Equal(t, true, result["already_tagged"])
+}
+
+func TestRun_NextNativeBackend(t *testing.T) {
+	repo, sha := setupRepo(t)
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{
+		"next", "--repo-dir", repo, "--commit-sha", sha, "--git-backend", "native", "--create-tag",
+	}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	assert.Equal(t, "v1.1.0-pre.1\n", stdout.String())
+	assert.Equal(t, sha, runGit(t, repo, "rev-parse", "v1.1.0-pre.1^{commit}"))
+}
+
+func TestRun_NextTimeout(t *testing.T) {
+	repo, sha := setupRepo(t)
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{
+		"next", "--repo-dir", repo, "--commit-sha", sha, "--git-timeout", "1ns",
+	}, &stdout, &stderr)
+	require.Equal(t, 1, code)
+
+	assert.Empty(t, stdout.String())
+}
+
+func TestRun_NextDetachedHead(t *testing.T) {
+	repo, sha := setupRepo(t)
+
+	clone := filepath.Join(t.TempDir(), "clone")
+	runGit(t, "", "clone", "--quiet", "--branch=develop", "file://"+repo, clone)
+	runGit(t, clone, "checkout", "--quiet", "--detach", sha)
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"next", "--repo-dir", clone, "--commit-sha", sha}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	assert.Equal(t, "v1.1.0-pre.1\n", stdout.String())
+}
+
+func TestRun_NextPullRequestPreview(t *testing.T) {
+	repo, _ := setupRepo(t)
+
+	clone := filepath.Join(t.TempDir(), "clone")
+	runGit(t, "", "clone", "--quiet", "--branch=develop", "file://"+repo, clone)
+	runGit(t, clone, "checkout", "--quiet", "-b", "feature/signup")
+	runGit(t, clone, "commit", "--allow-empty", "-m", "add signup")
+	runGit(t, clone, "commit", "--allow-empty", "-m", "validate email")
+
+	// like the merge ref checked out on pull request builds
+	runGit(t, clone, "checkout", "--quiet", "--detach", "origin/develop")
+	runGit(t, clone, "merge", "--quiet", "--no-ff", "-m", "Merge feature/signup into develop", "feature/signup")
+
+	// the default pull request checkout has neither the base branch nor its remote ref
+	runGit(t, clone, "branch", "--quiet", "-D", "develop")
+	runGit(t, clone, "update-ref", "-d", "refs/remotes/origin/develop")
+
+	sha := runGit(t, clone, "rev-parse", "HEAD")
+
+	for _, backend := range []string{"exec", "native", "snapshot"} {
+		t.Run(backend, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run([]string{
+				"next", "--repo-dir", clone, "--commit-sha", sha, "--git-backend", backend,
+				"--source-branch", "feature/signup", "--dest-branch", "develop", "--pull-request", "482",
+			}, &stdout, &stderr)
+			require.Equal(t, 0, code, stderr.String())
+
+			assert.Equal(t, "v1.1.0-pr.482.2\n", stdout.String())
+		})
+	}
+}
+
+func TestRun_NextPullRequestPreviewChangelogUntouched(t *testing.T) {
+	repo, _ := setupRepo(t)
+
+	runGit(t, repo, "checkout", "--quiet", "-b", "feature/signup")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "add signup")
+
+	dir := t.TempDir()
+	changelogFile := filepath.Join(dir, "release-notes.md")
+	keepAChangelog := filepath.Join(dir, "CHANGELOG.md")
+
+	content := "# Changelog\n\n## [Unreleased]\n\n### Added\n\n- Login\n"
+	require.NoError(t, os.WriteFile(keepAChangelog, []byte(content), 0600))
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{
+		"next", "--repo-dir", repo, "--commit-sha", runGit(t, repo, "rev-parse", "HEAD"), "--pull-request", "482",
+		"--source-branch", "feature/signup", "--dest-branch", "develop",
+		"--changelog-file", changelogFile, "--update-changelog", keepAChangelog,
+	}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	assert.Equal(t, "v1.1.0-pr.482.1\n", stdout.String())
+
+	_, err := os.Stat(changelogFile)
+	assert.True(t, os.IsNotExist(err))
+
+	data, err := os.ReadFile(keepAChangelog)
+	require.NoError(t, err)
+
+	assert.Equal(t, content, string(data))
+}
+
+func TestRun_NextBranchSnapshot(t *testing.T) {
+	repo, developSha := setupRepo(t)
+
+	runGit(t, repo, "checkout", "--quiet", "-b", "feature/Login_OAuth")
+
+	for _, expected := range []string{"v1.1.0-feature-login-oauth.1\n", "v1.1.0-feature-login-oauth.2\n"} {
+		runGit(t, repo, "commit", "--allow-empty", "-m", "add oauth")
+
+		var stdout, stderr bytes.Buffer
+
+		code := cli.Run([]string{
+			"next", "--repo-dir", repo, "--commit-sha", runGit(t, repo, "rev-parse", "HEAD"),
+			"--branch-snapshots", "--create-tag",
+		}, &stdout, &stderr)
+		require.Equal(t, 0, code, stderr.String())
+
+		assert.Equal(t, expected, stdout.String())
+	}
+
+	// the snapshot tags don't change the version of develop
+	runGit(t, repo, "checkout", "--quiet", "develop")
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"next", "--repo-dir", repo, "--commit-sha", developSha, "--branch-snapshots"}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	assert.Equal(t, "v1.1.0-pre.1\n", stdout.String())
+}
+
+func TestRun_NextBranchSnapshotChangelogUntouched(t *testing.T) {
+	repo, _ := setupRepo(t)
+
+	runGit(t, repo, "checkout", "--quiet", "-b", "feature/signup")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "add signup")
+
+	dir := t.TempDir()
+	changelogFile := filepath.Join(dir, "release-notes.md")
+	keepAChangelog := filepath.Join(dir, "CHANGELOG.md")
+
+	content := "# Changelog\n\n## [Unreleased]\n\n### Added\n\n- Signup\n"
+	require.NoError(t, os.WriteFile(keepAChangelog, []byte(content), 0600))
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{
+		"next", "--repo-dir", repo, "--commit-sha", runGit(t, repo, "rev-parse", "HEAD"), "--branch-snapshots",
+		"--changelog-file", changelogFile, "--update-changelog", keepAChangelog,
+	}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	assert.Equal(t, "v1.1.0-feature-signup.1\n", stdout.String())
+
+	_, err := os.Stat(changelogFile)
+	assert.True(t, os.IsNotExist(err))
+
+	data, err := os.ReadFile(keepAChangelog)
+	require.NoError(t, err)
+
+	assert.Equal(t, content, string(data))
+}
+
+func TestRun_NextPrereleaseChangelogUntouched(t *testing.T) {
+	repo, sha := setupRepo(t)
+
+	keepAChangelog := filepath.Join(t.TempDir(), "CHANGELOG.md")
+
+	content := "# Changelog\n\n## [Unreleased]\n\n### Added\n\n- Login\n"
+	require.NoError(t, os.WriteFile(keepAChangelog, []byte(content), 0600))
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{
+		"next", "--repo-dir", repo, "--commit-sha", sha, "--update-changelog", keepAChangelog,
+	}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	assert.Equal(t, "v1.1.0-pre.1\n", stdout.String())
+
+	data, err := os.ReadFile(keepAChangelog)
+	require.NoError(t, err)
+
+	assert.Equal(t, content, string(data))
+}
+
+func TestRun_NextFinalReleaseUpdatesChangelog(t *testing.T) {
+	repo, sha := setupRepo(t)
+
+	keepAChangelog := filepath.Join(t.TempDir(), "CHANGELOG.md")
+
+	content := "# Changelog\n\n## [Unreleased]\n\n### Fixed\n\n- Crash\n"
+	require.NoError(t, os.WriteFile(keepAChangelog, []byte(content), 0600))
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{
+		"next", "--repo-dir", repo, "--commit-sha", sha, "--update-changelog", keepAChangelog,
+		"--source-branch", "hotfix/crash", "--dest-branch", "master",
+	}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	assert.Equal(t, "v1.0.1\n", stdout.String())
+
+	data, err := os.ReadFile(keepAChangelog)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(data), "## [Unreleased]\n\n## [1.0.1] - ")
+	assert.Contains(t, string(data), "### Fixed\n\n- Crash\n")
+}
+
+func TestRun_NextBranchSnapshotWithoutLocalDevelop(t *testing.T) {
+	repo, developSha := setupRepo(t)
+
+	runGit(t, repo, "tag", "v1.1.0-pre.1", developSha)
+
+	// like a CI checkout of a branch, develop only exists as a remote branch
+	clone := filepath.Join(t.TempDir(), "clone")
+	runGit(t, "", "clone", "--quiet", "--branch=master", "file://"+repo, clone)
+
+	for _, branch := range []string{"doc/readme", "feature/signup"} {
+		t.Run(branch, func(t *testing.T) {
+			runGit(t, clone, "checkout", "--quiet", "-b", branch, "origin/develop")
+			runGit(t, clone, "commit", "--allow-empty", "-m", "update "+branch)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run([]string{
+				"next", "--repo-dir", clone, "--commit-sha", runGit(t, clone, "rev-parse", "HEAD"),
+				"--branch-snapshots", "--output", "json",
+			}, &stdout, &stderr)
+			require.Equal(t, 0, code, stderr.String())
+
+			var result map[string]interface{}
+
+			require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+
+			assert.Equal(t, "v1.1.0-pre.1", result["ancestor_tag"])
+			assert.Equal(t, branch, result["source_branch"])
+			assert.NotContains(t, result["changelog"], "add login")
+		})
+	}
+}
+
+func TestRun_NextShallowClone(t *testing.T) {
+	repo, sha := setupRepo(t)
+
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, "", "clone", "--quiet", "--bare", repo, remote)
+
+	tests := map[string]struct {
+		ShallowPolicy string
+		GitBackend    string
+		Expected      string
+	}{
+		"deepen": {
+			ShallowPolicy: "deepen",
+			GitBackend:    "exec",
+			Expected:      "v1.1.0-pre.1\n",
+		},
+		"deepen native": {
+			ShallowPolicy: "deepen",
+			GitBackend:    "native",
+			Expected:      "v1.1.0-pre.1\n",
+		},
+		"deepen snapshot": {
+			ShallowPolicy: "deepen",
+			GitBackend:    "snapshot",
+			Expected:      "v1.1.0-pre.1\n",
+		},
+		"ignore": {
+			ShallowPolicy: "ignore",
+			GitBackend:    "exec",
+			Expected:      "v0.1.0-pre.1\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			shallow := filepath.Join(t.TempDir(), "shallow")
+			runGit(t, "", "clone", "--quiet", "--depth=1", "--branch=develop", "file://"+remote, shallow)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run([]string{
+				"next", "--repo-dir", shallow, "--commit-sha", sha,
+				"--shallow-policy", test.ShallowPolicy, "--git-backend", test.GitBackend,
+			}, &stdout, &stderr)
+			require.Equal(t, 0, code, stderr.String())
+
+			assert.Equal(t, test.Expected, stdout.String())
+		})
+	}
+}
+
+func TestRun_NextGithubActions(t *testing.T) {
+	repo, sha := setupRepo(t)
+
+	dir := t.TempDir()
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_OUTPUT", filepath.Join(dir, "output"))
+	t.Setenv("GITHUB_STEP_SUMMARY", filepath.Join(dir, "summary"))
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"next", "--repo-dir", repo, "--commit-sha", sha}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	assert.Equal(t, "::group::Explanation\n::endgroup::\n"+
+		"::notice::Calculated v1.1.0-pre.1 from v1.0.0 (feature/login into develop: build minor)\n", stdout.String())
+
+	summary, err := os.ReadFile(filepath.Join(dir, "summary"))
+	require.NoError(t, err)
+
+	assert.Contains(t, string(summary), "| `v1.0.0` | `v1.1.0-pre.1` | minor |")
+	assert.Contains(t, string(summary), "| Features | feature/login | #1 | John Doe |")
+}
+
+func TestRun_Explain(t *testing.T) {
+	repo, sha := setupRepo(t)
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"explain", "--repo-dir", repo, "--commit-sha", sha}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	assert.True(t, strings.Contains(stdout.String(), "matched rule: feature-into-develop\n"))
+	assert.True(t, strings.HasSuffix(stdout.String(), "semver tag: v1.1.0-pre.1\n"))
+}
+
+func TestRun_Current(t *testing.T) {
+	repo, _ := setupRepo(t)
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"current", "--repo-dir", repo}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	assert.Equal(t, "v1.0.0\n", stdout.String())
+}
+
+func TestRun_Validate(t *testing.T) {
+	tests := map[string]struct {
+		Args     []string
+		Code     int
+		Expected string
+	}{
+		"valid": {
+			Args:     []string{"validate", "v1.2.3-pre.1"},
+			Code:     0,
+			Expected: "v1.2.3-pre.1 is valid\n",
+		},
+		"valid json": {
+			Args:     []string{"validate", "--output", "json", "v1.2.3"},
+			Code:     0,
+			Expected: `{"tag":"v1.2.3","valid":true,"version":"1.2.3"}` + "\n",
+		},
+		"invalid json": {
+			Args: []string{"validate", "--output", "json", "--prefix", "release-", "v1.2"},
+			Code: 6,
+			Expected: `{"error":"version \"v1.2\" does not start with prefix \"release-\"",` +
+				`"tag":"v1.2","valid":false}` + "\n",
+		},
+		"missing version": {
+			Args: []string{"validate"},
+			Code: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(test.Args, &stdout, &stderr)
+
+			assert.Equal(t, test.Code, code)
+			assert.Equal(t, test.Expected, stdout.String())
+		})
+	}
+}
+
+func TestRun_UnknownCommand(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "")
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"unknown"}, &stdout, &stderr)
+
+	assert.Equal(t, 2, code)
+	assert.True(t, strings.Contains(stderr.String(), "Usage: semver"))
+}
+
+func TestRun_InvalidFlag(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"next", "--bump", "invalid"}, &stdout, &stderr)
+
+	assert.Equal(t, 2, code)
+	assert.Empty(t, stdout.String())
+}
+
+func TestRun_ExitCodes(t *testing.T) {
+	tests := map[string]struct {
+		Setup func(t *testing.T, repo string) []string
+		Code  int
+	}{
+		"not a repository": {
+			Setup: func(t *testing.T, _ string) []string {
+				return []string{"next", "--repo-dir", t.TempDir()}
+			},
+			Code: 3,
+		},
+		"shallow clone": {
+			Setup: func(t *testing.T, repo string) []string {
+				shallow := filepath.Join(t.TempDir(), "shallow")
+				runGit(t, "", "clone", "--quiet", "--depth=1", "--branch=develop", "file://"+repo, shallow)
+
+				return []string{"next", "--repo-dir", shallow, "--shallow-policy", "fail"}
+			},
+			Code: 4,
+		},
+		"no source branch": {
+			Setup: func(t *testing.T, repo string) []string {
+				runGit(t, repo, "commit", "--allow-empty", "-m", "fix typo")
+
+				return []string{"next", "--repo-dir", repo, "--commit-sha", runGit(t, repo, "rev-parse", "HEAD")}
+			},
+			Code: 5,
+		},
+		"tag exists": {
+			Setup: func(t *testing.T, repo string) []string {
+				runGit(t, repo, "tag", "v1.1.0-pre.1", "feature/login")
+
+				return []string{"next", "--repo-dir", repo, "--commit-sha", runGit(t, repo, "rev-parse", "HEAD")}
+			},
+			Code: 7,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			repo, _ := setupRepo(t)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(test.Setup(t, repo), &stdout, &stderr)
+
+			assert.Equal(t, test.Code, code)
+			assert.Empty(t, stdout.String())
+		})
+	}
+}
+
+// setupRepo creates a repository with a v1.0.0 tag on master and a feature
+// branch merged into develop. It returns the repository path and the merge commit sha.
+func setupRepo(t *testing.T) (string, string) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("GITHUB_OUTPUT", "")
+	t.Setenv("GITHUB_SHA", "")
+	t.Setenv("GIT_AUTHOR_NAME", "John Doe")
+	t.Setenv("GIT_AUTHOR_EMAIL", "john@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "John Doe")
+	t.Setenv("GIT_COMMITTER_EMAIL", "john@example.com")
+
+	repo := filepath.Join(t.TempDir(), "repo")
+
+	runGit(t, "", "init", "--quiet", "--initial-branch=master", repo)
+	runGit(t, repo, "commit", "--allow-empty", "-m", "initial commit")
+	runGit(t, repo, "tag", "v1.0.0")
+	runGit(t, repo, "checkout", "--quiet", "-b", "develop")
+	runGit(t, repo, "checkout", "--quiet", "-b", "feature/login")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "add login")
+	runGit(t, repo, "checkout", "--quiet", "develop")
+	runGit(t, repo, "merge", "--no-ff", "-m", "Merge pull request #1 from wakatime/feature/login", "feature/login")
+
+	return repo, runGit(t, repo, "rev-parse", "HEAD")
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+
+	out, err := exec.Command("git", args...).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	return strings.TrimSpace(string(out))
+}
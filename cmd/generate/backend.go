@@ -0,0 +1,51 @@
+package generate
+
+import (
+	"context"
+	"time"
+
+	"github.com/wakatime/semver-action/pkg/git"
+)
+
+// Backends to read the repository with.
+const (
+	// gitBackendExec runs the git binary for every query.
+	gitBackendExec = "exec"
+	// gitBackendNative reads refs and objects from the repository files and only
+	// runs git to create, push and fetch tags.
+	gitBackendNative = "native"
+	// gitBackendSnapshot runs git like exec, but loads all tags and the commit
+	// graph once and answers tag queries in memory.
+	gitBackendSnapshot = "snapshot"
+)
+
+// newGitClient returns the git client for the configured backend.
+func newGitClient(params Params) gitClient {
+	switch params.GitBackend {
+	case gitBackendNative:
+		gc := git.NewNative(params.RepoDir)
+		gc.Timeout = params.GitTimeout
+
+		return gc
+	case gitBackendSnapshot:
+		gc := git.NewSnapshot(params.RepoDir)
+		gc.Timeout = params.GitTimeout
+
+		return gc
+	default:
+		gc := git.NewGit(params.RepoDir)
+		gc.Timeout = params.GitTimeout
+
+		return gc
+	}
+}
+
+// withTimeout returns a context that is canceled after the timeout. Zero
+// means no timeout.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,192 @@
+package generate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/wakatime/semver-action/pkg/git"
+	calc "github.com/wakatime/semver-action/pkg/semver"
+)
+
+// nolint: gochecknoglobals
+var (
+	conventionalCommitRegex = regexp.MustCompile(`^(?P<type>[a-zA-Z]+)(\([^)]*\))?(?P<breaking>!)?:\s*(?P<description>.+)$`)
+	mergePullRequestRegex   = regexp.MustCompile(`^Merge pull request #(?P<number>[0-9]+) from (?P<source>\S+)`)
+	squashPullRequestRegex  = regexp.MustCompile(`\s*\(#(?P<number>[0-9]+)\)$`)
+	// changelogCategories keeps the order categories are rendered in.
+	changelogCategories = []string{
+		categoryBreaking,
+		categoryFeatures,
+		categoryBugFixes,
+		categoryHotfixes,
+		categoryDocumentation,
+		categoryMiscellaneous,
+		categoryOther,
+	}
+)
+
+const (
+	categoryBreaking      = "Breaking Changes"
+	categoryFeatures      = "Features"
+	categoryBugFixes      = "Bug Fixes"
+	categoryHotfixes      = "Hotfixes"
+	categoryDocumentation = "Documentation"
+	categoryMiscellaneous = "Miscellaneous"
+	categoryOther         = "Other"
+)
+
+// ChangelogEntry contains a single changelog line.
+type ChangelogEntry struct {
+	Category  string
+	Title     string
+	PRNumber  string
+	Author    string
+	ShortHash string
+}
+
+// Changelog contains the entries between two revisions.
+type Changelog struct {
+	Entries []ChangelogEntry
+}
+
+// NewChangelog creates a changelog from the given commits.
+func NewChangelog(commits []git.Commit) Changelog {
+	var changelog Changelog
+
+	for _, commit := range commits {
+		changelog.Entries = append(changelog.Entries, parseChangelogEntry(commit))
+	}
+
+	return changelog
+}
+
+// parseChangelogEntry categorizes a commit either from the source branch of a
+// merged pull request or from the conventional commit type of its subject.
+func parseChangelogEntry(commit git.Commit) ChangelogEntry {
+	entry := ChangelogEntry{
+		Category:  categoryOther,
+		Title:     commit.Subject,
+		Author:    commit.Author,
+		ShortHash: commit.ShortHash,
+	}
+
+	if match := mergePullRequestRegex.FindStringSubmatch(commit.Subject); match != nil {
+		entry.PRNumber = match[1]
+
+		source := match[2]
+		if splitted := strings.SplitN(source, "/", 2); len(splitted) == 2 {
+			source = splitted[1]
+		}
+
+		entry.Category = categoryFromBranch(source)
+		entry.Title = source
+
+		if commit.Body != "" {
+			entry.Title = strings.SplitN(commit.Body, "\n", 2)[0]
+		}
+	} else if match := squashPullRequestRegex.FindStringSubmatch(commit.Subject); match != nil {
+		entry.PRNumber = match[1]
+		entry.Title = squashPullRequestRegex.ReplaceAllString(commit.Subject, "")
+	}
+
+	if match := conventionalCommitRegex.FindStringSubmatch(entry.Title); match != nil {
+		if category := categoryFromCommitType(match[1], match[3] == "!"); category != "" {
+			if entry.Category == categoryOther || category == categoryBreaking {
+				entry.Category = category
+			}
+
+			entry.Title = match[4]
+		}
+	}
+
+	return entry
+}
+
+// categoryFromBranch returns the changelog category for a source branch.
+func categoryFromBranch(branch string) string {
+	switch {
+	case calc.BranchMajor.Match(branch):
+		return categoryBreaking
+	case calc.BranchFeature.Match(branch):
+		return categoryFeatures
+	case calc.BranchBugfix.Match(branch):
+		return categoryBugFixes
+	case calc.BranchHotfix.Match(branch):
+		return categoryHotfixes
+	case calc.BranchDoc.Match(branch):
+		return categoryDocumentation
+	case calc.BranchMisc.Match(branch):
+		return categoryMiscellaneous
+	default:
+		return categoryOther
+	}
+}
+
+// categoryFromCommitType returns the changelog category for a conventional commit type.
+// An empty string is returned for unknown types.
+func categoryFromCommitType(commitType string, breaking bool) string {
+	if breaking {
+		return categoryBreaking
+	}
+
+	switch strings.ToLower(commitType) {
+	case "feat", "feature":
+		return categoryFeatures
+	case "fix", "bugfix":
+		return categoryBugFixes
+	case "hotfix":
+		return categoryHotfixes
+	case "doc", "docs":
+		return categoryDocumentation
+	case "build", "chore", "ci", "misc", "perf", "refactor", "revert", "style", "test":
+		return categoryMiscellaneous
+	default:
+		return ""
+	}
+}
+
+// String formats a changelog entry as a markdown list item.
+func (e ChangelogEntry) String() string {
+	var b strings.Builder
+
+	b.WriteString("- ")
+	b.WriteString(e.Title)
+
+	if e.PRNumber != "" {
+		b.WriteString(fmt.Sprintf(" (#%s)", e.PRNumber))
+	}
+
+	if e.Author != "" {
+		b.WriteString(fmt.Sprintf(" by %s", e.Author))
+	}
+
+	if e.ShortHash != "" {
+		b.WriteString(fmt.Sprintf(" (%s)", e.ShortHash))
+	}
+
+	return b.String()
+}
+
+// Markdown renders the changelog grouped by category.
+func (c Changelog) Markdown() string {
+	var sections []string
+
+	for _, category := range changelogCategories {
+		var lines []string
+
+		for _, entry := range c.Entries {
+			if entry.Category == category {
+				lines = append(lines, entry.String())
+			}
+		}
+
+		if len(lines) == 0 {
+			continue
+		}
+
+		sections = append(sections, fmt.Sprintf("### %s\n\n%s\n", category, strings.Join(lines, "\n")))
+	}
+
+	return strings.Join(sections, "\n")
+}
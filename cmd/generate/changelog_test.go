@@ -0,0 +1,147 @@
+package generate_test
+
+import (
+	"testing"
+
+	"github.com/wakatime/semver-action/cmd/generate"
+	"github.com/wakatime/semver-action/pkg/git"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestNewChangelog(t *testing.T) {
+	tests := map[string]struct {
+		Commit   git.Commit
+		Expected generate.ChangelogEntry
+	}{
+		"feature branch merge": {
+			Commit: git.Commit{
+				ShortHash: "e63c125",
+				Author:    "John Doe",
+				Subject:   "Merge pull request #123 from wakatime/feature/login",
+				Body:      "Add login page\n\nLonger description.",
+			},
+			Expected: generate.ChangelogEntry{
+				Category:  "Features",
+				Title:     "Add login page",
+				PRNumber:  "123",
+				Author:    "John Doe",
+				ShortHash: "e63c125",
+			},
+		},
+		"bugfix branch merge without body": {
+			Commit: git.Commit{
+				ShortHash: "e63c125",
+				Author:    "John Doe",
+				Subject:   "Merge pull request #7 from some-user/bugfix/crash",
+			},
+			Expected: generate.ChangelogEntry{
+				Category:  "Bug Fixes",
+				Title:     "bugfix/crash",
+				PRNumber:  "7",
+				Author:    "John Doe",
+				ShortHash: "e63c125",
+			},
+		},
+		"misc branch merge with conventional title": {
+			Commit: git.Commit{
+				ShortHash: "e63c125",
+				Author:    "John Doe",
+				Subject:   "Merge pull request #8 from wakatime/misc/deps",
+				Body:      "chore: bump dependencies",
+			},
+			Expected: generate.ChangelogEntry{
+				Category:  "Miscellaneous",
+				Title:     "bump dependencies",
+				PRNumber:  "8",
+				Author:    "John Doe",
+				ShortHash: "e63c125",
+			},
+		},
+		"squashed conventional commit": {
+			Commit: git.Commit{
+				ShortHash: "e63c125",
+				Author:    "John Doe",
+				Subject:   "fix(parser): handle empty tags (#42)",
+			},
+			Expected: generate.ChangelogEntry{
+				Category:  "Bug Fixes",
+				Title:     "handle empty tags",
+				PRNumber:  "42",
+				Author:    "John Doe",
+				ShortHash: "e63c125",
+			},
+		},
+		"breaking conventional commit": {
+			Commit: git.Commit{
+				ShortHash: "e63c125",
+				Author:    "John Doe",
+				Subject:   "feat!: drop legacy inputs",
+			},
+			Expected: generate.ChangelogEntry{
+				Category:  "Breaking Changes",
+				Title:     "drop legacy inputs",
+				Author:    "John Doe",
+				ShortHash: "e63c125",
+			},
+		},
+		"plain commit": {
+			Commit: git.Commit{
+				ShortHash: "e63c125",
+				Author:    "John Doe",
+				Subject:   "Update README",
+			},
+			Expected: generate.ChangelogEntry{
+				Category:  "Other",
+				Title:     "Update README",
+				Author:    "John Doe",
+				ShortHash: "e63c125",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			changelog := generate.NewChangelog([]git.Commit{test.Commit})
+
+			assert.Equal(t, []generate.ChangelogEntry{test.Expected}, changelog.Entries)
+		})
+	}
+}
+
+func TestChangelog_Markdown(t *testing.T) {
+	changelog := generate.NewChangelog([]git.Commit{
+		{
+			ShortHash: "aaaaaaa",
+			Author:    "John Doe",
+			Subject:   "Merge pull request #2 from wakatime/bugfix/crash",
+			Body:      "Fix crash",
+		},
+		{
+			ShortHash: "bbbbbbb",
+			Author:    "Jane Doe",
+			Subject:   "Merge pull request #1 from wakatime/feature/login",
+			Body:      "Add login",
+		},
+		{
+			ShortHash: "ccccccc",
+			Author:    "Jane Doe",
+			Subject:   "Update README",
+		},
+	})
+
+	expected := "### Features\n\n" +
+		"- Add login (#1) by Jane Doe (bbbbbbb)\n" +
+		"\n" +
+		"### Bug Fixes\n\n" +
+		"- Fix crash (#2) by John Doe (aaaaaaa)\n" +
+		"\n" +
+		"### Other\n\n" +
+		"- Update README by Jane Doe (ccccccc)\n"
+
+	assert.Equal(t, expected, changelog.Markdown())
+}
+
+func TestChangelog_MarkdownEmpty(t *testing.T) {
+	assert.Empty(t, generate.NewChangelog(nil).Markdown())
+}
@@ -0,0 +1,125 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Decision records how the semantic version was calculated.
+type Decision struct {
+	SourceBranch      string            `json:"source_branch"`
+	DestBranch        string            `json:"dest_branch"`
+	Bump              string            `json:"bump"`
+	Rule              string            `json:"rule"`
+	Method            string            `json:"method"`
+	Version           string            `json:"version"`
+	PreviousTag       string            `json:"previous_tag"`
+	PreviousTagSource string            `json:"previous_tag_source"`
+	TagSelection      string            `json:"tag_selection,omitempty"`
+	BaseVersion       string            `json:"base_version,omitempty"`
+	AncestorPatterns  []AncestorPattern `json:"ancestor_patterns"`
+	Substitution      Substitution      `json:"substitution"`
+	TagExists         string            `json:"tag_exists,omitempty"`
+	PullRequest       uint64            `json:"pull_request,omitempty"`
+	SnapshotBranch    string            `json:"snapshot_branch,omitempty"`
+}
+
+// AncestorPattern records an ancestor tag lookup.
+type AncestorPattern struct {
+	Purpose string `json:"purpose"`
+	Include string `json:"include"`
+	Exclude string `json:"exclude"`
+	Branch  string `json:"branch"`
+	Result  string `json:"result"`
+}
+
+// Substitution records whether the ancestor develop tag replaced the latest tag
+// for doc and misc branches.
+type Substitution struct {
+	Applied bool   `json:"applied"`
+	Reason  string `json:"reason"`
+}
+
+// Reason returns a one line summary of why the version was bumped.
+func (d Decision) Reason() string {
+	if d.Rule == ruleAlreadyTagged {
+		return d.TagExists
+	}
+
+	var reason string
+
+	if d.Bump != "" && d.Bump != "auto" {
+		reason = fmt.Sprintf("bump forced to %s", d.Bump)
+	} else {
+		reason = fmt.Sprintf("%s into %s: %s", d.SourceBranch, d.DestBranch, d.Method)
+		if d.Version != "" {
+			reason += " " + d.Version
+		}
+	}
+
+	if d.TagExists != "" {
+		reason += ", " + d.TagExists
+	}
+
+	return reason
+}
+
+// JSON returns the decision encoded as json.
+func (d Decision) JSON() (string, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode decision: %s", err)
+	}
+
+	return string(data), nil
+}
+
+// Explain returns a human-readable explanation of the decision.
+func (d Decision) Explain() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "source branch: %s\n", valueOrNone(d.SourceBranch))
+	fmt.Fprintf(&b, "dest branch: %s\n", valueOrNone(d.DestBranch))
+	fmt.Fprintf(&b, "bump: %s\n", valueOrNone(d.Bump))
+	fmt.Fprintf(&b, "matched rule: %s\n", d.Rule)
+	fmt.Fprintf(&b, "method: %s, version component: %s\n", d.Method, valueOrNone(d.Version))
+	fmt.Fprintf(&b, "previous tag: %s (from %s)\n", d.PreviousTag, d.PreviousTagSource)
+
+	if d.TagSelection != "" {
+		fmt.Fprintf(&b, "tag selection: %s\n", d.TagSelection)
+	}
+
+	if d.BaseVersion != "" {
+		fmt.Fprintf(&b, "base version: %s (from parameter)\n", d.BaseVersion)
+	}
+
+	for _, p := range d.AncestorPatterns {
+		fmt.Fprintf(&b, "%s: include %q, exclude %q on %s found %s\n",
+			p.Purpose, p.Include, p.Exclude, p.Branch, valueOrNone(p.Result))
+	}
+
+	fmt.Fprintf(&b, "ancestor substitution applied: %t (%s)\n", d.Substitution.Applied, d.Substitution.Reason)
+
+	if d.TagExists != "" {
+		fmt.Fprintf(&b, "tag exists: %s\n", d.TagExists)
+	}
+
+	if d.PullRequest > 0 {
+		fmt.Fprintf(&b, "preview of pull request: #%d\n", d.PullRequest)
+	}
+
+	if d.SnapshotBranch != "" {
+		fmt.Fprintf(&b, "snapshot of branch: %s\n", d.SnapshotBranch)
+	}
+
+	return b.String()
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+
+	return value
+}
@@ -0,0 +1,145 @@
+package generate_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wakatime/semver-action/cmd/generate"
+
+	"github.com/alecthomas/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecision_Reason(t *testing.T) {
+	tests := map[string]struct {
+		Decision generate.Decision
+		Expected string
+	}{
+		"auto bump": {
+			Decision: generate.Decision{
+				SourceBranch: "feature/some",
+				DestBranch:   "develop",
+				Bump:         "auto",
+				Method:       "build",
+				Version:      "minor",
+			},
+			Expected: "feature/some into develop: build minor",
+		},
+		"auto bump without version": {
+			Decision: generate.Decision{
+				SourceBranch: "develop",
+				DestBranch:   "master",
+				Bump:         "auto",
+				Method:       "final",
+			},
+			Expected: "develop into master: final",
+		},
+		"forced bump": {
+			Decision: generate.Decision{
+				Bump:   "major",
+				Method: "major",
+			},
+			Expected: "bump forced to major",
+		},
+		"existing tag bumped": {
+			Decision: generate.Decision{
+				SourceBranch: "feature/some",
+				DestBranch:   "develop",
+				Bump:         "auto",
+				Method:       "build",
+				Version:      "minor",
+				TagExists:    "v1.6.0-pre.1 already exists on 81918ffc, bumped to v1.6.0-pre.2",
+			},
+			Expected: "feature/some into develop: build minor, v1.6.0-pre.1 already exists on 81918ffc, bumped to v1.6.0-pre.2",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.Expected, test.Decision.Reason())
+		})
+	}
+}
+
+func TestDecision_JSON(t *testing.T) {
+	decision := generate.Decision{
+		SourceBranch:      "develop",
+		DestBranch:        "master",
+		Bump:              "auto",
+		Rule:              "develop-into-main",
+		Method:            "final",
+		PreviousTag:       "v1.4.17-alpha.1",
+		PreviousTagSource: "git",
+		AncestorPatterns: []generate.AncestorPattern{
+			{
+				Purpose: "ancestor tag",
+				Include: "v[0-9]*",
+				Exclude: "v[0-9]*-alpha*",
+				Branch:  "master",
+				Result:  "v1.4.16",
+			},
+		},
+		Substitution: generate.Substitution{
+			Reason: "source branch is not prefixed with doc or misc",
+		},
+	}
+
+	data, err := decision.JSON()
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"source_branch":"develop","dest_branch":"master","bump":"auto",`+
+		`"rule":"develop-into-main","method":"final","version":"","previous_tag":"v1.4.17-alpha.1",`+
+		`"previous_tag_source":"git","ancestor_patterns":[{"purpose":"ancestor tag","include":"v[0-9]*",`+
+		`"exclude":"v[0-9]*-alpha*","branch":"master","result":"v1.4.16"}],`+
+		`"substitution":{"applied":false,"reason":"source branch is not prefixed with doc or misc"}}`, data)
+}
+
+func TestDecision_Explain(t *testing.T) {
+	decision := generate.Decision{
+		SourceBranch:      "feature/some",
+		DestBranch:        "develop",
+		Bump:              "auto",
+		Rule:              "feature-into-develop",
+		Method:            "build",
+		Version:           "minor",
+		PreviousTag:       "v0.0.0",
+		PreviousTagSource: "default",
+		AncestorPatterns: []generate.AncestorPattern{
+			{
+				Purpose: "ancestor tag",
+				Include: "v[0-9]*-pre*",
+				Branch:  "develop",
+			},
+		},
+		Substitution: generate.Substitution{
+			Reason: "source branch is not prefixed with doc or misc",
+		},
+	}
+
+	expected := "source branch: feature/some\n" +
+		"dest branch: develop\n" +
+		"bump: auto\n" +
+		"matched rule: feature-into-develop\n" +
+		"method: build, version component: minor\n" +
+		"previous tag: v0.0.0 (from default)\n" +
+		"ancestor tag: include \"v[0-9]*-pre*\", exclude \"\" on develop found none\n" +
+		"ancestor substitution applied: false (source branch is not prefixed with doc or misc)\n"
+
+	assert.Equal(t, expected, decision.Explain())
+}
+
+func TestDecision_ExplainPreview(t *testing.T) {
+	decision := generate.Decision{
+		SourceBranch:      "feature/some",
+		DestBranch:        "develop",
+		Bump:              "auto",
+		Rule:              "feature-into-develop",
+		Method:            "build",
+		Version:           "minor",
+		PreviousTag:       "v1.5.3",
+		PreviousTagSource: "git",
+		PullRequest:       482,
+	}
+
+	assert.True(t, strings.HasSuffix(decision.Explain(), "preview of pull request: #482\n"))
+}
@@ -0,0 +1,100 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wakatime/semver-action/pkg/git"
+
+	"github.com/apex/log"
+)
+
+// resolveDestBranch returns the branch the commit is merged into. It is taken
+// from the dest_branch input or the CI environment, the current branch or, when
+// HEAD is detached, the remote branches containing the commit. HEAD is returned
+// if no branch can be determined.
+func resolveDestBranch(ctx context.Context, params Params, gc queryClient, rev string) (string, error) {
+	if params.DestBranch != "" {
+		source := params.DestBranchSource
+		if source == "" {
+			source = "parameter"
+		}
+
+		log.Debugf("dest branch %q from %s\n", params.DestBranch, source)
+
+		return params.DestBranch, nil
+	}
+
+	current, err := gc.CurrentBranch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if current != "HEAD" {
+		log.Debugf("dest branch %q from current branch\n", current)
+
+		return current, nil
+	}
+
+	commit, err := gc.ResolveCommit(ctx, rev)
+	if err != nil {
+		return "", err
+	}
+
+	branches, err := gc.RemoteBranches(ctx, params.Remote, commit)
+	if err != nil {
+		return "", err
+	}
+
+	if branch, source, ok := pickBranch(params, branches, commit); ok {
+		log.Debugf("dest branch %q from %s, HEAD is detached\n", branch, source)
+
+		return branch, nil
+	}
+
+	log.Warnf("HEAD is detached and no branch of %s identifies the commit, set dest_branch to apply the branch rules\n",
+		params.Remote)
+
+	return current, nil
+}
+
+// isSnapshotBranch returns true if pushes to the branch create snapshots, which
+// is every branch except main and develop.
+func isSnapshotBranch(params Params, branch string) bool {
+	return branch != "HEAD" && branch != params.MainBranchName && branch != params.DevelopBranchName
+}
+
+// pickBranch selects the dest branch from the remote branches containing the
+// commit. Branches pointing at the commit are preferred. If more than one
+// branch is left, the main and then the develop branch are picked.
+func pickBranch(params Params, branches []git.Branch, commit string) (string, string, bool) {
+	var (
+		candidates []string
+		source     = fmt.Sprintf("%s branch pointing at the commit", params.Remote)
+	)
+
+	for _, b := range branches {
+		if b.Commit == commit {
+			candidates = append(candidates, b.Name)
+		}
+	}
+
+	if len(candidates) == 0 {
+		source = fmt.Sprintf("%s branch containing the commit", params.Remote)
+
+		for _, b := range branches {
+			candidates = append(candidates, b.Name)
+		}
+	}
+
+	switch {
+	case len(candidates) == 1:
+		return candidates[0], source, true
+	case stringInSlice(params.MainBranchName, candidates):
+		return params.MainBranchName, source, true
+	case stringInSlice(params.DevelopBranchName, candidates):
+		return params.DevelopBranchName, source, true
+	default:
+		return "", "", false
+	}
+}
@@ -0,0 +1,100 @@
+package generate_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wakatime/semver-action/cmd/generate"
+	"github.com/wakatime/semver-action/pkg/git"
+
+	"github.com/alecthomas/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTag_DetachedHead(t *testing.T) {
+	tests := map[string]struct {
+		Branches []git.Branch
+		Expected string
+	}{
+		"single branch": {
+			Branches: []git.Branch{{Name: "develop", Commit: "a1b2c3d4"}},
+			Expected: "develop",
+		},
+		"branch pointing at the commit": {
+			Branches: []git.Branch{
+				{Name: "develop", Commit: "a1b2c3d4"},
+				{Name: "feature/some", Commit: "81918ffc"},
+			},
+			Expected: "feature/some",
+		},
+		"main branch preferred": {
+			Branches: []git.Branch{
+				{Name: "develop", Commit: "81918ffc"},
+				{Name: "master", Commit: "81918ffc"},
+			},
+			Expected: "master",
+		},
+		"develop branch preferred": {
+			Branches: []git.Branch{
+				{Name: "develop", Commit: "a1b2c3d4"},
+				{Name: "feature/other", Commit: "e5f6a7b8"},
+			},
+			Expected: "develop",
+		},
+		"ambiguous": {
+			Branches: []git.Branch{
+				{Name: "feature/other", Commit: "a1b2c3d4"},
+				{Name: "feature/some", Commit: "e5f6a7b8"},
+			},
+			Expected: "HEAD",
+		},
+		"no branch": {
+			Expected: "HEAD",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gc := initGitClientMock(t, "v1.2.3", "v1.2.3", "HEAD", "feature/some", "81918ffc")
+			gc.RemoteBranchesFn = func(remote, rev string) ([]git.Branch, error) {
+				assert.Equal(t, "origin", remote)
+				assert.Equal(t, "81918ffc", rev)
+
+				return test.Branches, nil
+			}
+
+			result, err := generate.Tag(context.Background(), generate.Params{
+				CommitSha:         "81918ffc",
+				Bump:              "auto",
+				Prefix:            "v",
+				PrereleaseID:      "pre",
+				MainBranchName:    "master",
+				DevelopBranchName: "develop",
+				Remote:            "origin",
+			}, gc)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Expected, result.DestBranch)
+		})
+	}
+}
+
+func TestTag_DestBranchParam(t *testing.T) {
+	gc := initGitClientMock(t, "v1.2.3", "v1.2.3", "HEAD", "feature/some", "81918ffc")
+
+	result, err := generate.Tag(context.Background(), generate.Params{
+		CommitSha:         "81918ffc",
+		Bump:              "auto",
+		Prefix:            "v",
+		PrereleaseID:      "pre",
+		MainBranchName:    "master",
+		DevelopBranchName: "develop",
+		DestBranch:        "develop",
+		DestBranchSource:  "github environment",
+	}, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "develop", result.DestBranch)
+	assert.Equal(t, 0, gc.CurrentBranchFnInvoked)
+	assert.Equal(t, 0, gc.RemoteBranchesFnInvoked)
+}
@@ -0,0 +1,22 @@
+package generate
+
+import (
+	"errors"
+
+	calc "github.com/wakatime/semver-action/pkg/semver"
+)
+
+// ErrShallowClone is returned when the repository is a shallow clone and the
+// shallow policy is fail.
+var ErrShallowClone = errors.New("repository is a shallow clone") // nolint
+
+// ErrTagExists is returned when the calculated tag already exists and the tag
+// exists policy can't resolve it.
+var ErrTagExists = errors.New("tag already exists") // nolint
+
+// ErrInvalidTag is returned when a tag or version is not a valid semantic
+// version. The error is an InvalidTagError holding the tag.
+var ErrInvalidTag = calc.ErrInvalidTag // nolint
+
+// InvalidTagError is returned when a tag or version is not a valid semantic version.
+type InvalidTagError = calc.InvalidTagError
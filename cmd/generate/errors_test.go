@@ -0,0 +1,42 @@
+package generate_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wakatime/semver-action/cmd/generate"
+
+	"github.com/alecthomas/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate_InvalidTagError(t *testing.T) {
+	tests := map[string]struct {
+		Version  string
+		Expected string
+	}{
+		"wrong prefix": {
+			Version:  "release-1.2.3",
+			Expected: `version "release-1.2.3" does not start with prefix "v"`,
+		},
+		"not semver": {
+			Version:  "v1.2",
+			Expected: `version "v1.2" is not a valid semantic version: No Major.Minor.Patch elements found`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := generate.Validate(test.Version, "v")
+			require.Error(t, err)
+
+			var invalidTag *generate.InvalidTagError
+
+			require.True(t, errors.As(err, &invalidTag))
+
+			assert.True(t, errors.Is(err, generate.ErrInvalidTag))
+			assert.Equal(t, test.Version, invalidTag.Tag)
+			assert.EqualError(t, err, test.Expected)
+		})
+	}
+}
@@ -1,53 +1,121 @@
 package generate
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"regexp"
-	"strconv"
+	"strings"
+	"time"
 
 	"github.com/wakatime/semver-action/pkg/git"
+	calc "github.com/wakatime/semver-action/pkg/semver"
 
 	"github.com/apex/log"
-	"github.com/blang/semver/v4"
 )
 
 // nolint: gochecknoglobals
 var (
-	branchBugfixPrefixRegex  = regexp.MustCompile(`(?i)^(.+:)?(bugfix/.+)`)
-	branchDocPrefixRegex     = regexp.MustCompile(`(?i)^(.+:)?(docs?/.+)`)
-	branchFeaturePrefixRegex = regexp.MustCompile(`(?i)^(.+:)?(feature/.+)`)
-	branchHotfixPrefixRegex  = regexp.MustCompile(`(?i)^(.+:)?(hotfix/.+)`)
-	branchMajorPrefixRegex   = regexp.MustCompile(`(?i)^(.+:)?(major/.+)`)
-	branchMiscPrefixRegex    = regexp.MustCompile(`(?i)^(.+:)?(misc/.+)`)
-	branchResyncPrefixRegex  = regexp.MustCompile(`(?i)^(.+:)?(resync/.+)`)
+	// rootCommitRegex matches the commit sha returned by AncestorTag when no tag matched.
+	rootCommitRegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
 )
 
-const tagDefault = "0.0.0"
+const (
+	tagMessageTemplateDefault = "Release {{ .Tag }}"
+	gitTimeoutDefault         = 5 * time.Minute
+	timeoutDefault            = 15 * time.Minute
+)
+
+// repoClient checks and trusts the repository.
+type repoClient interface {
+	IsRepo(ctx context.Context) (bool, error)
+	MakeSafe(ctx context.Context) error
+	AddSafeDirectory(ctx context.Context) error
+}
+
+// tagLister lists the tags of the repository.
+type tagLister interface {
+	Tags(ctx context.Context, reachableFrom string) ([]string, error)
+}
+
+// historyClient fetches the missing history of shallow clones.
+type historyClient interface {
+	tagLister
+	IsShallow(ctx context.Context) (bool, error)
+	Deepen(ctx context.Context, remote string, depth int) error
+	Unshallow(ctx context.Context, remote string) error
+}
+
+// queryClient reads branches, tags and commits.
+type queryClient interface {
+	tagLister
+	CurrentBranch(ctx context.Context) (string, error)
+	LatestTag(ctx context.Context) (string, error)
+	AncestorTag(ctx context.Context, include, exclude, branch string) (string, error)
+	SourceBranch(ctx context.Context, commitHash string) (string, error)
+	Commits(ctx context.Context, from, to string) ([]git.Commit, error)
+	TagCommit(ctx context.Context, name string) (string, error)
+	RemoteTagCommit(ctx context.Context, remote, name string) (string, error)
+	ResolveCommit(ctx context.Context, rev string) (string, error)
+	RemoteBranches(ctx context.Context, remote, rev string) ([]git.Branch, error)
+	CountCommits(ctx context.Context, from, to string) (int, error)
+	TagsAt(ctx context.Context, rev string) ([]string, error)
+}
+
+// tagClient creates, pushes and fetches tags.
+type tagClient interface {
+	CreateTag(ctx context.Context, opts git.TagOptions) error
+	DeleteTag(ctx context.Context, name string) error
+	PushTag(ctx context.Context, remote, name string) error
+	FetchTags(ctx context.Context, remote string) error
+}
+
+// inspectClient reads the repository as it is checked out.
+type inspectClient interface {
+	repoClient
+	queryClient
+}
 
+// readClient calculates versions without creating tags.
+type readClient interface {
+	inspectClient
+	historyClient
+}
+
+// gitClient calculates versions and creates and pushes tags.
 type gitClient interface {
-	CurrentBranch() (string, error)
-	IsRepo() bool
-	MakeSafe() error
-	LatestTag() string
-	AncestorTag(include, exclude, branch string) string
-	SourceBranch(commitHash string) (string, error)
+	readClient
+	tagClient
 }
 
 // Result contains the result of Run().
 type Result struct {
-	PreviousTag  string
-	AncestorTag  string
-	SemverTag    string
-	IsPrerelease bool
+	PreviousTag      string   `json:"previous_tag"`
+	AncestorTag      string   `json:"ancestor_tag"`
+	SemverTag        string   `json:"semver_tag"`
+	IsPrerelease     bool     `json:"is_prerelease"`
+	IsPreview        bool     `json:"is_preview"`
+	Version          string   `json:"version"`
+	Major            uint64   `json:"major"`
+	Minor            uint64   `json:"minor"`
+	Patch            uint64   `json:"patch"`
+	Prerelease       string   `json:"prerelease"`
+	PrereleaseNumber string   `json:"prerelease_number"`
+	BuildMetadata    string   `json:"build_metadata"`
+	BumpType         string   `json:"bump_type"`
+	SourceBranch     string   `json:"source_branch"`
+	DestBranch       string   `json:"dest_branch"`
+	IsNewMajor       bool     `json:"is_new_major"`
+	BumpReason       string   `json:"bump_reason"`
+	Changelog        string   `json:"changelog"`
+	Decision         Decision `json:"reason"`
+	// ChangelogEntries are rendered as a table in the job summary.
+	ChangelogEntries []ChangelogEntry `json:"-"`
+	AlreadyTagged    bool             `json:"already_tagged"`
 }
 
 // Run generates a semantic version using the commit sha.
-func Run() (Result, error) {
-	params, err := LoadParams()
-	if err != nil {
-		return Result{}, fmt.Errorf("failed to load parameters: %s", err)
-	}
-
+func Run(ctx context.Context, params Params) (Result, error) {
 	if params.Debug {
 		log.SetLevel(log.DebugLevel)
 		log.Debug("debug logs enabled\n")
@@ -55,218 +123,332 @@ func Run() (Result, error) {
 
 	log.Debug(params.String())
 
-	gc := git.NewGit(params.RepoDir)
+	ctx, cancel := withTimeout(ctx, params.Timeout)
+	defer cancel()
+
+	gc := newGitClient(params)
+
+	result, err := Release(ctx, params, gc)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// previews and snapshots are not released, so the changelogs are left untouched
+	if result.IsPreview || result.Decision.SnapshotBranch != "" {
+		log.Infof("skipping changelog files for %s\n", result.SemverTag)
+
+		return result, nil
+	}
+
+	if params.ChangelogFile != "" {
+		// nolint:gosec
+		if err := os.WriteFile(params.ChangelogFile, []byte(result.Changelog), 0644); err != nil {
+			return Result{}, fmt.Errorf("failed to write changelog file: %s", err)
+		}
+	}
+
+	// prereleases leave the [Unreleased] items for the section of the final release
+	if params.UpdateChangelogFile != "" && result.IsPrerelease {
+		log.Infof("skipping %q for prerelease %s\n", params.UpdateChangelogFile, result.SemverTag)
+	}
+
+	if params.UpdateChangelogFile != "" && !result.IsPrerelease {
+		previousTag := result.PreviousTag
+		if previousTag == params.Prefix+calc.InitialVersion {
+			previousTag = ""
+		}
 
-	return Tag(params, gc)
+		updated, err := UpdateChangelogFile(params.UpdateChangelogFile, ReleaseSection{
+			Version:     strings.TrimPrefix(result.SemverTag, params.Prefix),
+			Tag:         result.SemverTag,
+			PreviousTag: previousTag,
+			Date:        time.Now().UTC(),
+			Fallback:    result.Changelog,
+			RepoURL:     repoURL(),
+		})
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to update changelog: %s", err)
+		}
+
+		log.Debugf("changelog %q updated: %t\n", params.UpdateChangelogFile, updated)
+	}
+
+	return result, nil
+}
+
+// repoURL returns the repository url from github environment variables if available.
+func repoURL() string {
+	server, repository := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY")
+	if server == "" || repository == "" {
+		return ""
+	}
+
+	return strings.TrimSuffix(server, "/") + "/" + repository
 }
 
 // Tag returns the calculated semantica version.
 // nolint:gocyclo
-func Tag(params Params, gc gitClient) (Result, error) {
-	err := gc.MakeSafe()
-	if err != nil {
-		return Result{}, fmt.Errorf("failed to make safe: %s", err)
+func Tag(ctx context.Context, params Params, gc readClient) (Result, error) {
+	if err := checkRepo(ctx, gc); err != nil {
+		return Result{}, err
 	}
 
-	if !gc.IsRepo() {
-		return Result{}, fmt.Errorf("current folder is not a git repository")
+	if params.GlobalSafeDirectory {
+		if err := gc.AddSafeDirectory(ctx); err != nil {
+			return Result{}, err
+		}
 	}
 
-	tagSource := "git"
+	commitSha := params.CommitSha
+	if commitSha == "" {
+		commitSha = "HEAD"
+	}
 
-	if params.BaseVersion != nil {
-		tagSource = "parameter"
+	if err := ensureHistory(ctx, params, gc, commitSha); err != nil {
+		return Result{}, fmt.Errorf("failed to fetch history of shallow clone: %w", err)
 	}
 
-	dest, err := gc.CurrentBranch()
+	dest, err := resolveDestBranch(ctx, params, gc, commitSha)
 	if err != nil {
 		return Result{}, fmt.Errorf("failed to extract dest branch from commit: %s", err)
 	}
 
-	log.Debugf("dest branch: %q\n", dest)
+	source := params.SourceBranch
 
-	source, err := gc.SourceBranch(params.CommitSha)
-	if err != nil {
-		return Result{}, fmt.Errorf("failed to extract source branch from commit: %s", err)
+	// Snapshots of a branch are the version of merging it into develop. The
+	// ancestor tags are looked up from the commit, as develop is not merged
+	// yet and often not checked out.
+	var snapshotBranch string
+
+	ancestorRev := dest
+
+	if params.BranchSnapshots && params.PullRequest == 0 && isSnapshotBranch(params, dest) {
+		snapshotBranch = dest
+		source, dest = dest, params.DevelopBranchName
+		ancestorRev = commitSha
+
+		log.Debugf("snapshot of branch %q, calculating the merge into %q\n", snapshotBranch, dest)
+	}
+
+	if source == "" {
+		source, err = gc.SourceBranch(ctx, params.CommitSha)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to extract source branch from commit: %w", err)
+		}
 	}
 
 	log.Debugf("source branch: %q\n", source)
 
-	method, version := determineBumpStrategy(params.Bump, source, dest, params.MainBranchName, params.DevelopBranchName)
+	calculator := calc.NewCalculator(calc.Config{
+		Bump:            params.Bump,
+		Prefix:          params.Prefix,
+		PrereleaseID:    params.PrereleaseID,
+		MainBranch:      params.MainBranchName,
+		DevelopBranch:   params.DevelopBranchName,
+		BranchSnapshots: params.BranchSnapshots,
+	})
+
+	strategy := calculator.Strategy(source, dest)
 
-	log.Debugf("method: %q, version: %q", method, version)
+	log.Debugf("rule: %q, method: %q, version: %q", strategy.Rule, strategy.Method, strategy.Component)
 
-	var tag *semver.Version
+	var existingID string
 
-	latestTag := gc.LatestTag()
-	if latestTag == "" {
-		tag, _ = semver.New(tagDefault)
-	} else {
-		parsed, err := semver.ParseTolerant(latestTag)
+	switch {
+	case snapshotBranch != "":
+		existingID = calculator.SnapshotID(snapshotBranch)
+	case strategy.Method == calc.MethodBuild:
+		existingID = params.PrereleaseID
+	}
+
+	// Re-runs on an already tagged commit return the existing tag. Previews
+	// are never tagged.
+	if params.PullRequest == 0 {
+		existingTag, err := highestTagAt(ctx, params, gc, commitSha, existingID)
 		if err != nil {
-			return Result{}, fmt.Errorf("failed to parse tag %q or not valid semantic version: %s", latestTag, err)
+			return Result{}, fmt.Errorf("failed to get tags at commit: %s", err)
 		}
-		tag = &parsed
-	}
 
-	previousTag := params.Prefix + tag.String()
+		if existingTag != "" {
+			log.Infof("commit is already tagged with %s\n", existingTag)
 
-	if tagSource != "git" {
-		tag = params.BaseVersion
-	}
+			result, err := alreadyTagged(ctx, params, gc, existingTag, source, dest, commitSha)
+			if err != nil {
+				return Result{}, err
+			}
 
-	if (version == "major" && method == "build") || method == "major" {
-		log.Debug("incrementing major")
+			result.Decision.SnapshotBranch = snapshotBranch
 
-		if err := tag.IncrementMajor(); err != nil {
-			return Result{}, fmt.Errorf("failed to increment major version: %s", err)
+			return result, nil
 		}
 	}
 
-	if (version == "minor" && method == "build") || method == "minor" {
-		log.Debug("incrementing minor")
+	decision := Decision{
+		SourceBranch:      source,
+		DestBranch:        dest,
+		Bump:              params.Bump,
+		Rule:              strategy.Rule,
+		Method:            string(strategy.Method),
+		Version:           string(strategy.Component),
+		PreviousTagSource: "git",
+		TagSelection:      params.TagSelection,
+	}
 
-		if err := tag.IncrementMinor(); err != nil {
-			return Result{}, fmt.Errorf("failed to increment minor version: %s", err)
-		}
+	input := calc.Input{
+		SourceBranch: source,
+		DestBranch:   dest,
+		BaseVersion:  params.BaseVersion,
+		Snapshot:     snapshotBranch != "",
 	}
 
-	if (version == "patch" && method == "build") || method == "patch" || method == "hotfix" {
-		log.Debug("incrementing patch")
+	input.LatestTag, err = selectLatestTag(ctx, params, gc, commitSha)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to select latest tag: %s", err)
+	}
 
-		if err := tag.IncrementPatch(); err != nil {
-			return Result{}, fmt.Errorf("failed to increment patch version: %s", err)
-		}
+	if input.LatestTag == "" {
+		log.Warnf("no previous tag found, starting from %s%s\n", params.Prefix, calc.InitialVersion)
+
+		decision.PreviousTagSource = "default"
+	}
+
+	if params.BaseVersion != nil {
+		decision.BaseVersion = params.BaseVersion.String()
 	}
 
-	// If branch is prefixed with doc or misc and the latest tag is equal to the
-	// ancestor develop tag excluding prerelease part, then it will use ancestor one instead.
-	if (branchDocPrefixRegex.MatchString(source) || branchMiscPrefixRegex.MatchString(source)) &&
-		dest == params.DevelopBranchName {
-		ancestorDevelopTag := gc.AncestorTag(
-			fmt.Sprintf("%s[0-9]*-%s*", params.Prefix, params.PrereleaseID),
-			"",
-			dest)
+	if calculator.NeedsAncestorDevelopTag(input) {
+		includePattern := calculator.DevelopTagPattern()
 
-		parsed, err := semver.ParseTolerant(ancestorDevelopTag)
+		input.AncestorDevelopTag, err = gc.AncestorTag(ctx, includePattern, "", ancestorRev)
 		if err != nil {
-			return Result{}, fmt.Errorf("failed to parse tag %q or not valid semantic version: %s", latestTag, err)
+			return Result{}, fmt.Errorf("failed to get ancestor develop tag: %s", err)
 		}
 
-		if tag.String() == parsed.FinalizeVersion() {
-			tag = &parsed
-		}
+		decision.AncestorPatterns = append(decision.AncestorPatterns, AncestorPattern{
+			Purpose: "ancestor develop tag",
+			Include: includePattern,
+			Branch:  ancestorRev,
+			Result:  input.AncestorDevelopTag,
+		})
 	}
 
-	var (
-		finalTag       string
-		ancestorTag    string
-		includePattern string
-		excludePattern string
-		isPrerelease   bool
-	)
-
-	switch method {
-	case "build":
-		{
-			isPrerelease = true
-			includePattern = fmt.Sprintf("%s[0-9]*-%s*", params.Prefix, params.PrereleaseID)
+	out, err := calculator.Calculate(input)
+	if err != nil {
+		return Result{}, err
+	}
 
-			buildNumber, _ := semver.NewPRVersion("0")
+	if params.PullRequest > 0 {
+		counter, err := previewCounter(ctx, params, gc, dest, commitSha, input.LatestTag)
+		if err != nil {
+			return Result{}, err
+		}
 
-			if len(tag.Pre) > 1 && version == "" {
-				buildNumber = tag.Pre[1]
-			}
+		log.Debugf("preview of %s for pull request #%d\n", out.Tag, params.PullRequest)
 
-			tag.Pre = nil
+		out = calculator.Preview(out, params.PullRequest, counter)
+		decision.PullRequest = params.PullRequest
+	}
 
-			preVersion, err := semver.NewPRVersion(params.PrereleaseID)
-			if err != nil {
-				return Result{}, fmt.Errorf("failed to create new prerelease version: %s", err)
-			}
+	if snapshotBranch != "" {
+		tags, err := gc.Tags(ctx, "")
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to list tags of snapshots: %s", err)
+		}
 
-			tag.Pre = append(tag.Pre, preVersion)
+		log.Debugf("snapshot of %s for branch %q\n", out.Tag, snapshotBranch)
 
-			buildVersion, err := semver.NewPRVersion(strconv.Itoa(int(buildNumber.VersionNum + 1)))
-			if err != nil {
-				return Result{}, fmt.Errorf("failed to create new build version: %s", err)
-			}
+		out = calculator.Snapshot(out, snapshotBranch, tags)
+		decision.SnapshotBranch = snapshotBranch
+	}
 
-			tag.Pre = append(tag.Pre, buildVersion)
+	decision.PreviousTag = out.PreviousTag
+	decision.Substitution = Substitution{
+		Applied: out.Substitution.Applied,
+		Reason:  out.Substitution.Reason,
+	}
 
-			finalTag = params.Prefix + tag.String()
-		}
-	case "major", "minor", "patch":
-		if len(tag.Pre) > 0 {
-			isPrerelease = true
-			includePattern = fmt.Sprintf("%s[0-9]*-%s*", params.Prefix, params.PrereleaseID)
-		} else {
-			includePattern = fmt.Sprintf("%s[0-9]*", params.Prefix)
-			excludePattern = fmt.Sprintf("%s[0-9]*-%s*", params.Prefix, params.PrereleaseID)
-		}
+	log.Debugf("ancestor substitution applied: %t, %s", decision.Substitution.Applied, decision.Substitution.Reason)
 
-		finalTag = params.Prefix + tag.String()
-	default:
-		includePattern = fmt.Sprintf("%s[0-9]*", params.Prefix)
-		excludePattern = fmt.Sprintf("%s[0-9]*-%s*", params.Prefix, params.PrereleaseID)
-		finalTag = params.Prefix + tag.FinalizeVersion()
+	ancestorTag, err := gc.AncestorTag(ctx, out.AncestorInclude, out.AncestorExclude, ancestorRev)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get ancestor tag: %s", err)
 	}
 
-	ancestorTag = gc.AncestorTag(includePattern, excludePattern, dest)
+	if rootCommitRegex.MatchString(ancestorTag) {
+		log.Warnf("no ancestor tag matching %q found on %s, using root commit %s\n", out.AncestorInclude, ancestorRev, ancestorTag)
+	}
 
-	return Result{
-		PreviousTag:  previousTag,
-		AncestorTag:  ancestorTag,
-		SemverTag:    finalTag,
-		IsPrerelease: isPrerelease,
-	}, nil
-}
+	decision.AncestorPatterns = append(decision.AncestorPatterns, AncestorPattern{
+		Purpose: "ancestor tag",
+		Include: out.AncestorInclude,
+		Exclude: out.AncestorExclude,
+		Branch:  ancestorRev,
+		Result:  ancestorTag,
+	})
 
-// determineBumpStrategy determines the strategy for semver to bump product version.
-func determineBumpStrategy(bump, sourceBranch, destBranch, mainBranchName, developBranchName string) (string, string) {
-	if bump != "auto" {
-		return bump, ""
+	commits, err := gc.Commits(ctx, ancestorTag, commitSha)
+	if err != nil {
+		log.Warnf("failed to get commits for changelog, leaving it empty: %s\n", err)
 	}
 
-	// bugfix into develop branch
-	if branchBugfixPrefixRegex.MatchString(sourceBranch) && destBranch == developBranchName {
-		return "build", "patch"
+	changelog := NewChangelog(commits)
+
+	result := Result{
+		PreviousTag:  out.PreviousTag,
+		AncestorTag:  ancestorTag,
+		SemverTag:    out.Tag,
+		IsPrerelease: out.IsPrerelease,
+		IsPreview:    params.PullRequest > 0,
+		BumpType:     out.Strategy.BumpType(),
+		SourceBranch: source,
+		DestBranch:   dest,
+		BumpReason:   decision.Reason(),
+		Changelog:    changelog.Markdown(),
+		Decision:     decision,
+
+		ChangelogEntries: changelog.Entries,
 	}
 
-	// doc into develop branch
-	if branchDocPrefixRegex.MatchString(sourceBranch) && destBranch == developBranchName {
-		return "build", ""
+	if !result.IsPreview {
+		if err := resolveExistingTag(ctx, params, gc, &result); err != nil {
+			return Result{}, err
+		}
 	}
 
-	// feature into develop
-	if branchFeaturePrefixRegex.MatchString(sourceBranch) && destBranch == developBranchName {
-		return "build", "minor"
+	if err := result.setVersionComponents(params.Prefix); err != nil {
+		return Result{}, err
 	}
 
-	// major into develop
-	if branchMajorPrefixRegex.MatchString(sourceBranch) && destBranch == developBranchName {
-		return "build", "major"
+	return result, nil
+}
+
+// checkRepo returns an error if the repository directory is not a git
+// repository. Git refuses repositories owned by another user, e.g. a checkout
+// mounted into a container, so those are retried as a safe.directory.
+func checkRepo(ctx context.Context, gc repoClient) error {
+	isRepo, err := gc.IsRepo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check repository: %s", err)
 	}
 
-	// misc into develop branch
-	if branchMiscPrefixRegex.MatchString(sourceBranch) && destBranch == developBranchName {
-		return "build", ""
+	if isRepo {
+		return nil
 	}
 
-	// hotfix into main branch
-	if branchHotfixPrefixRegex.MatchString(sourceBranch) && destBranch == mainBranchName {
-		return "hotfix", ""
+	if err := gc.MakeSafe(ctx); err != nil {
+		return fmt.Errorf("failed to make safe: %s", err)
 	}
 
-	// resync into develop
-	if branchResyncPrefixRegex.MatchString(sourceBranch) && destBranch == developBranchName {
-		return "build", "patch"
+	isRepo, err = gc.IsRepo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check repository: %s", err)
 	}
 
-	// develop branch into main branch
-	if sourceBranch == developBranchName && destBranch == mainBranchName {
-		return "final", ""
+	if !isRepo {
+		return fmt.Errorf("current folder is %w", git.ErrNotRepository)
 	}
 
-	return "build", ""
+	log.Debugf("repository trusted with safe.directory\n")
+
+	return nil
 }
@@ -4,95 +4,31 @@ import (
 	"testing"
 
 	"github.com/alecthomas/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestDetermineBumpStrategy(t *testing.T) {
-	tests := map[string]struct {
-		SourceBranch    string
-		DestBranch      string
-		Bump            string
-		ExpectedMethod  string
-		ExpectedVersion string
-	}{
-		"source branch bugfix, dest branch develop and auto bump": {
-			SourceBranch:    "bugfix/some",
-			DestBranch:      "develop",
-			Bump:            "auto",
-			ExpectedMethod:  "build",
-			ExpectedVersion: "patch",
-		},
-		"source branch doc, dest branch develop and auto bump": {
-			SourceBranch:    "doc/some",
-			DestBranch:      "develop",
-			Bump:            "auto",
-			ExpectedMethod:  "build",
-			ExpectedVersion: "",
-		},
-		"source branch feature, dest branch develop and auto bump": {
-			SourceBranch:    "feature/some",
-			DestBranch:      "develop",
-			Bump:            "auto",
-			ExpectedMethod:  "build",
-			ExpectedVersion: "minor",
-		},
-		"source branch major, dest branch develop and auto bump": {
-			SourceBranch:    "major/some",
-			DestBranch:      "develop",
-			Bump:            "auto",
-			ExpectedMethod:  "build",
-			ExpectedVersion: "major",
-		},
-		"source branch misc, dest branch develop and auto bump": {
-			SourceBranch:    "misc/some",
-			DestBranch:      "develop",
-			Bump:            "auto",
-			ExpectedMethod:  "build",
-			ExpectedVersion: "",
-		},
-		"source branch hotfix, dest branch master and auto bump": {
-			SourceBranch:   "hotfix/some",
-			DestBranch:     "master",
-			Bump:           "auto",
-			ExpectedMethod: "hotfix",
-		},
-		"source branch resync, dest branch develop and auto bump": {
-			SourceBranch:    "resync/some",
-			DestBranch:      "develop",
-			Bump:            "auto",
-			ExpectedMethod:  "build",
-			ExpectedVersion: "patch",
-		},
-		"source branch develop, dest branch master and auto bump": {
-			SourceBranch:   "develop",
-			DestBranch:     "master",
-			Bump:           "auto",
-			ExpectedMethod: "final",
-		},
-		"not a valid source branch prefix and auto bump": {
-			SourceBranch:   "some-branch",
-			Bump:           "auto",
-			ExpectedMethod: "build",
-		},
-		"patch bump": {
-			Bump:           "patch",
-			ExpectedMethod: "patch",
-		},
-		"minor bump": {
-			Bump:           "minor",
-			ExpectedMethod: "minor",
-		},
-		"major bump": {
-			Bump:           "major",
-			ExpectedMethod: "major",
-		},
+func TestResult_SetVersionComponents(t *testing.T) {
+	result := Result{
+		PreviousTag: "v1.9.3",
+		SemverTag:   "v2.0.0-rc.1.4+build.5",
 	}
 
-	for name, test := range tests {
-		t.Run(name, func(t *testing.T) {
-			method, version := determineBumpStrategy(test.Bump, test.SourceBranch, test.DestBranch, "master", "develop")
+	err := result.setVersionComponents("v")
+	require.NoError(t, err)
 
-			assert.Equal(t, test.ExpectedMethod, method)
-			assert.Equal(t, test.ExpectedVersion, version)
-		})
-	}
+	assert.Equal(t, "2.0.0-rc.1.4+build.5", result.Version)
+	assert.Equal(t, uint64(2), result.Major)
+	assert.Equal(t, uint64(0), result.Minor)
+	assert.Equal(t, uint64(0), result.Patch)
+	assert.Equal(t, "rc.1.4", result.Prerelease)
+	assert.Equal(t, "4", result.PrereleaseNumber)
+	assert.Equal(t, "build.5", result.BuildMetadata)
+	assert.True(t, result.IsNewMajor)
+}
+
+func TestResult_SetVersionComponentsErr(t *testing.T) {
+	result := Result{SemverTag: "invalid"}
+
+	err := result.setVersionComponents("v")
+	require.Error(t, err)
 }
@@ -1,10 +1,12 @@
 package generate_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/wakatime/semver-action/cmd/generate"
+	"github.com/wakatime/semver-action/pkg/git"
 
 	"github.com/alecthomas/assert"
 	"github.com/blang/semver/v4"
@@ -32,10 +34,21 @@ func TestTag(t *testing.T) {
 				DevelopBranchName: "develop",
 			},
 			Result: generate.Result{
-				PreviousTag:  "v0.0.0",
-				AncestorTag:  "",
-				SemverTag:    "v1.0.0-alpha.1",
-				IsPrerelease: true,
+				PreviousTag:      "v0.0.0",
+				AncestorTag:      "",
+				SemverTag:        "v1.0.0-alpha.1",
+				IsPrerelease:     true,
+				Version:          "1.0.0-alpha.1",
+				Major:            1,
+				Minor:            0,
+				Patch:            0,
+				Prerelease:       "alpha.1",
+				PrereleaseNumber: "1",
+				BumpType:         "major",
+				SourceBranch:     "major/some",
+				DestBranch:       "develop",
+				IsNewMajor:       true,
+				BumpReason:       "major/some into develop: build major",
 			},
 		},
 		"first non-development tag": {
@@ -56,6 +69,14 @@ func TestTag(t *testing.T) {
 				AncestorTag:  "e63c125b",
 				SemverTag:    "v1.0.0",
 				IsPrerelease: false,
+				Version:      "1.0.0",
+				Major:        1,
+				Minor:        0,
+				Patch:        0,
+				BumpType:     "final",
+				SourceBranch: "develop",
+				DestBranch:   "master",
+				BumpReason:   "develop into master: final",
 			},
 		},
 		"doc branch into develop": {
@@ -72,10 +93,20 @@ func TestTag(t *testing.T) {
 				DevelopBranchName: "develop",
 			},
 			Result: generate.Result{
-				PreviousTag:  "v0.2.1-alpha.1",
-				AncestorTag:  "v0.2.0-alpha.1",
-				SemverTag:    "v0.2.1-alpha.2",
-				IsPrerelease: true,
+				PreviousTag:      "v0.2.1-alpha.1",
+				AncestorTag:      "v0.2.0-alpha.1",
+				SemverTag:        "v0.2.1-alpha.2",
+				IsPrerelease:     true,
+				Version:          "0.2.1-alpha.2",
+				Major:            0,
+				Minor:            2,
+				Patch:            1,
+				Prerelease:       "alpha.2",
+				PrereleaseNumber: "2",
+				BumpType:         "prerelease",
+				SourceBranch:     "doc/some",
+				DestBranch:       "develop",
+				BumpReason:       "doc/some into develop: build",
 			},
 		},
 		"doc branch into develop when latest tag is equal to ancestor develop tag excluding prerelease part": {
@@ -92,10 +123,20 @@ func TestTag(t *testing.T) {
 				DevelopBranchName: "develop",
 			},
 			Result: generate.Result{
-				PreviousTag:  "v0.2.1",
-				AncestorTag:  "v0.2.1-alpha.2",
-				SemverTag:    "v0.2.1-alpha.3",
-				IsPrerelease: true,
+				PreviousTag:      "v0.2.1",
+				AncestorTag:      "v0.2.1-alpha.2",
+				SemverTag:        "v0.2.1-alpha.3",
+				IsPrerelease:     true,
+				Version:          "0.2.1-alpha.3",
+				Major:            0,
+				Minor:            2,
+				Patch:            1,
+				Prerelease:       "alpha.3",
+				PrereleaseNumber: "3",
+				BumpType:         "prerelease",
+				SourceBranch:     "doc/some",
+				DestBranch:       "develop",
+				BumpReason:       "doc/some into develop: build",
 			},
 		},
 		"feature branch into develop": {
@@ -111,9 +152,19 @@ func TestTag(t *testing.T) {
 				DevelopBranchName: "develop",
 			},
 			Result: generate.Result{
-				PreviousTag:  "v0.2.1",
-				SemverTag:    "v0.3.0-alpha.1",
-				IsPrerelease: true,
+				PreviousTag:      "v0.2.1",
+				SemverTag:        "v0.3.0-alpha.1",
+				IsPrerelease:     true,
+				Version:          "0.3.0-alpha.1",
+				Major:            0,
+				Minor:            3,
+				Patch:            0,
+				Prerelease:       "alpha.1",
+				PrereleaseNumber: "1",
+				BumpType:         "minor",
+				SourceBranch:     "feature/some",
+				DestBranch:       "develop",
+				BumpReason:       "feature/some into develop: build minor",
 			},
 		},
 		"upstream feature branch into develop": {
@@ -129,9 +180,19 @@ func TestTag(t *testing.T) {
 				DevelopBranchName: "develop",
 			},
 			Result: generate.Result{
-				PreviousTag:  "v0.2.1",
-				SemverTag:    "v0.3.0-alpha.1",
-				IsPrerelease: true,
+				PreviousTag:      "v0.2.1",
+				SemverTag:        "v0.3.0-alpha.1",
+				IsPrerelease:     true,
+				Version:          "0.3.0-alpha.1",
+				Major:            0,
+				Minor:            3,
+				Patch:            0,
+				Prerelease:       "alpha.1",
+				PrereleaseNumber: "1",
+				BumpType:         "minor",
+				SourceBranch:     "some-user:feature/some",
+				DestBranch:       "develop",
+				BumpReason:       "some-user:feature/some into develop: build minor",
 			},
 		},
 		"bugfix branch into develop": {
@@ -147,9 +208,19 @@ func TestTag(t *testing.T) {
 				DevelopBranchName: "develop",
 			},
 			Result: generate.Result{
-				PreviousTag:  "v0.2.1",
-				SemverTag:    "v0.2.2-alpha.1",
-				IsPrerelease: true,
+				PreviousTag:      "v0.2.1",
+				SemverTag:        "v0.2.2-alpha.1",
+				IsPrerelease:     true,
+				Version:          "0.2.2-alpha.1",
+				Major:            0,
+				Minor:            2,
+				Patch:            2,
+				Prerelease:       "alpha.1",
+				PrereleaseNumber: "1",
+				BumpType:         "patch",
+				SourceBranch:     "bugfix/some",
+				DestBranch:       "develop",
+				BumpReason:       "bugfix/some into develop: build patch",
 			},
 		},
 		"upstream bugfix branch into develop": {
@@ -165,9 +236,19 @@ func TestTag(t *testing.T) {
 				DevelopBranchName: "develop",
 			},
 			Result: generate.Result{
-				PreviousTag:  "v0.2.1",
-				SemverTag:    "v0.2.2-alpha.1",
-				IsPrerelease: true,
+				PreviousTag:      "v0.2.1",
+				SemverTag:        "v0.2.2-alpha.1",
+				IsPrerelease:     true,
+				Version:          "0.2.2-alpha.1",
+				Major:            0,
+				Minor:            2,
+				Patch:            2,
+				Prerelease:       "alpha.1",
+				PrereleaseNumber: "1",
+				BumpType:         "patch",
+				SourceBranch:     "some-user:bugfix/some",
+				DestBranch:       "develop",
+				BumpReason:       "some-user:bugfix/some into develop: build patch",
 			},
 		},
 		"misc branch into develop": {
@@ -184,10 +265,20 @@ func TestTag(t *testing.T) {
 				DevelopBranchName: "develop",
 			},
 			Result: generate.Result{
-				PreviousTag:  "v0.2.1-alpha.1",
-				AncestorTag:  "v0.2.0-alpha.1",
-				SemverTag:    "v0.2.1-alpha.2",
-				IsPrerelease: true,
+				PreviousTag:      "v0.2.1-alpha.1",
+				AncestorTag:      "v0.2.0-alpha.1",
+				SemverTag:        "v0.2.1-alpha.2",
+				IsPrerelease:     true,
+				Version:          "0.2.1-alpha.2",
+				Major:            0,
+				Minor:            2,
+				Patch:            1,
+				Prerelease:       "alpha.2",
+				PrereleaseNumber: "2",
+				BumpType:         "prerelease",
+				SourceBranch:     "misc/some",
+				DestBranch:       "develop",
+				BumpReason:       "misc/some into develop: build",
 			},
 		},
 		"upstream misc branch into develop": {
@@ -204,10 +295,20 @@ func TestTag(t *testing.T) {
 				DevelopBranchName: "develop",
 			},
 			Result: generate.Result{
-				PreviousTag:  "v0.2.1-alpha.1",
-				AncestorTag:  "v0.2.0-alpha.1",
-				SemverTag:    "v0.2.1-alpha.2",
-				IsPrerelease: true,
+				PreviousTag:      "v0.2.1-alpha.1",
+				AncestorTag:      "v0.2.0-alpha.1",
+				SemverTag:        "v0.2.1-alpha.2",
+				IsPrerelease:     true,
+				Version:          "0.2.1-alpha.2",
+				Major:            0,
+				Minor:            2,
+				Patch:            1,
+				Prerelease:       "alpha.2",
+				PrereleaseNumber: "2",
+				BumpType:         "prerelease",
+				SourceBranch:     "some-user:misc/some",
+				DestBranch:       "develop",
+				BumpReason:       "some-user:misc/some into develop: build",
 			},
 		},
 		"hotfix branch into master": {
@@ -226,6 +327,14 @@ func TestTag(t *testing.T) {
 				PreviousTag:  "v0.2.1",
 				SemverTag:    "v0.2.2",
 				IsPrerelease: false,
+				Version:      "0.2.2",
+				Major:        0,
+				Minor:        2,
+				Patch:        2,
+				BumpType:     "patch",
+				SourceBranch: "hotfix/some",
+				DestBranch:   "master",
+				BumpReason:   "hotfix/some into master: hotfix",
 			},
 		},
 		"merge develop into master": {
@@ -244,6 +353,14 @@ func TestTag(t *testing.T) {
 				PreviousTag:  "v1.4.17-alpha.1",
 				SemverTag:    "v1.4.17",
 				IsPrerelease: false,
+				Version:      "1.4.17",
+				Major:        1,
+				Minor:        4,
+				Patch:        17,
+				BumpType:     "final",
+				SourceBranch: "develop",
+				DestBranch:   "master",
+				BumpReason:   "develop into master: final",
 			},
 		},
 		"merge develop into master with previous matching tag": {
@@ -264,6 +381,14 @@ func TestTag(t *testing.T) {
 				AncestorTag:  "v1.4.16",
 				SemverTag:    "v1.4.17",
 				IsPrerelease: false,
+				Version:      "1.4.17",
+				Major:        1,
+				Minor:        4,
+				Patch:        17,
+				BumpType:     "final",
+				SourceBranch: "develop",
+				DestBranch:   "master",
+				BumpReason:   "develop into master: final",
 			},
 		},
 		"resync branch into develop": {
@@ -280,10 +405,20 @@ func TestTag(t *testing.T) {
 				DevelopBranchName: "develop",
 			},
 			Result: generate.Result{
-				PreviousTag:  "v1.3.0",
-				AncestorTag:  "v1.3.0-alpha.2",
-				SemverTag:    "v1.3.1-alpha.1",
-				IsPrerelease: true,
+				PreviousTag:      "v1.3.0",
+				AncestorTag:      "v1.3.0-alpha.2",
+				SemverTag:        "v1.3.1-alpha.1",
+				IsPrerelease:     true,
+				Version:          "1.3.1-alpha.1",
+				Major:            1,
+				Minor:            3,
+				Patch:            1,
+				Prerelease:       "alpha.1",
+				PrereleaseNumber: "1",
+				BumpType:         "patch",
+				SourceBranch:     "resync/master",
+				DestBranch:       "develop",
+				BumpReason:       "resync/master into develop: build patch",
 			},
 		},
 		"base version set": {
@@ -300,9 +435,20 @@ func TestTag(t *testing.T) {
 				DevelopBranchName: "develop",
 			},
 			Result: generate.Result{
-				PreviousTag:  "v2.6.19",
-				SemverTag:    "v4.3.0-alpha.1",
-				IsPrerelease: true,
+				PreviousTag:      "v2.6.19",
+				SemverTag:        "v4.3.0-alpha.1",
+				IsPrerelease:     true,
+				Version:          "4.3.0-alpha.1",
+				Major:            4,
+				Minor:            3,
+				Patch:            0,
+				Prerelease:       "alpha.1",
+				PrereleaseNumber: "1",
+				BumpType:         "minor",
+				SourceBranch:     "feature/semver-initial",
+				DestBranch:       "develop",
+				IsNewMajor:       true,
+				BumpReason:       "feature/semver-initial into develop: build minor",
 			},
 		},
 		"invalid branch name": {
@@ -318,9 +464,19 @@ func TestTag(t *testing.T) {
 				DevelopBranchName: "develop",
 			},
 			Result: generate.Result{
-				PreviousTag:  "v2.6.19-alpha.1",
-				SemverTag:    "v2.6.19-alpha.2",
-				IsPrerelease: true,
+				PreviousTag:      "v2.6.19-alpha.1",
+				SemverTag:        "v2.6.19-alpha.2",
+				IsPrerelease:     true,
+				Version:          "2.6.19-alpha.2",
+				Major:            2,
+				Minor:            6,
+				Patch:            19,
+				Prerelease:       "alpha.2",
+				PrereleaseNumber: "2",
+				BumpType:         "prerelease",
+				SourceBranch:     "semver-initial",
+				DestBranch:       "develop",
+				BumpReason:       "semver-initial into develop: build",
 			},
 		},
 		"force bump major": {
@@ -336,9 +492,20 @@ func TestTag(t *testing.T) {
 				DevelopBranchName: "develop",
 			},
 			Result: generate.Result{
-				PreviousTag:  "v2.6.19-alpha.1",
-				SemverTag:    "v3.0.0-alpha.1",
-				IsPrerelease: true,
+				PreviousTag:      "v2.6.19-alpha.1",
+				SemverTag:        "v3.0.0-alpha.1",
+				IsPrerelease:     true,
+				Version:          "3.0.0-alpha.1",
+				Major:            3,
+				Minor:            0,
+				Patch:            0,
+				Prerelease:       "alpha.1",
+				PrereleaseNumber: "1",
+				BumpType:         "major",
+				SourceBranch:     "semver-initial",
+				DestBranch:       "develop",
+				IsNewMajor:       true,
+				BumpReason:       "bump forced to major",
 			},
 		},
 		"force bump minor": {
@@ -354,9 +521,19 @@ func TestTag(t *testing.T) {
 				DevelopBranchName: "develop",
 			},
 			Result: generate.Result{
-				PreviousTag:  "v2.6.19-alpha.1",
-				SemverTag:    "v2.7.0-alpha.1",
-				IsPrerelease: true,
+				PreviousTag:      "v2.6.19-alpha.1",
+				SemverTag:        "v2.7.0-alpha.1",
+				IsPrerelease:     true,
+				Version:          "2.7.0-alpha.1",
+				Major:            2,
+				Minor:            7,
+				Patch:            0,
+				Prerelease:       "alpha.1",
+				PrereleaseNumber: "1",
+				BumpType:         "minor",
+				SourceBranch:     "semver-initial",
+				DestBranch:       "develop",
+				BumpReason:       "bump forced to minor",
 			},
 		},
 		"force bump patch": {
@@ -372,9 +549,19 @@ func TestTag(t *testing.T) {
 				DevelopBranchName: "develop",
 			},
 			Result: generate.Result{
-				PreviousTag:  "v2.6.19-alpha.1",
-				SemverTag:    "v2.6.20-alpha.1",
-				IsPrerelease: true,
+				PreviousTag:      "v2.6.19-alpha.1",
+				SemverTag:        "v2.6.20-alpha.1",
+				IsPrerelease:     true,
+				Version:          "2.6.20-alpha.1",
+				Major:            2,
+				Minor:            6,
+				Patch:            20,
+				Prerelease:       "alpha.1",
+				PrereleaseNumber: "1",
+				BumpType:         "patch",
+				SourceBranch:     "semver-initial",
+				DestBranch:       "develop",
+				BumpReason:       "bump forced to patch",
 			},
 		},
 	}
@@ -390,14 +577,132 @@ func TestTag(t *testing.T) {
 				test.Params.CommitSha,
 			)
 
-			result, err := generate.Tag(test.Params, gc)
+			result, err := generate.Tag(context.Background(), test.Params, gc)
 			require.NoError(t, err)
 
+			// decision is covered by TestTag_Decision
+			result.Decision = generate.Decision{}
+
 			assert.Equal(t, test.Result, result)
 		})
 	}
 }
 
+func TestTag_Decision(t *testing.T) {
+	gc := initGitClientMock(t, "v0.2.1", "v0.2.1-alpha.2", "develop", "doc/some", "81918ffc")
+
+	result, err := generate.Tag(context.Background(), generate.Params{
+		CommitSha:         "81918ffc",
+		Bump:              "auto",
+		Prefix:            "v",
+		PrereleaseID:      "alpha",
+		MainBranchName:    "master",
+		DevelopBranchName: "develop",
+	}, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, generate.Decision{
+		SourceBranch:      "doc/some",
+		DestBranch:        "develop",
+		Bump:              "auto",
+		Rule:              "doc-into-develop",
+		Method:            "build",
+		PreviousTag:       "v0.2.1",
+		PreviousTagSource: "git",
+		AncestorPatterns: []generate.AncestorPattern{
+			{
+				Purpose: "ancestor develop tag",
+				Include: "v[0-9]*-alpha*",
+				Branch:  "develop",
+				Result:  "v0.2.1-alpha.2",
+			},
+			{
+				Purpose: "ancestor tag",
+				Include: "v[0-9]*-alpha*",
+				Branch:  "develop",
+				Result:  "v0.2.1-alpha.2",
+			},
+		},
+		Substitution: generate.Substitution{
+			Applied: true,
+			Reason:  "latest tag 0.2.1 equals ancestor develop tag v0.2.1-alpha.2 excluding prerelease part",
+		},
+	}, result.Decision)
+}
+
+func TestTag_DecisionDefaultTag(t *testing.T) {
+	gc := initGitClientMock(t, "", "", "master", "hotfix/some", "81918ffc")
+
+	result, err := generate.Tag(context.Background(), generate.Params{
+		CommitSha:         "81918ffc",
+		Bump:              "auto",
+		BaseVersion:       newSemVerPtr(t, "4.2.0"),
+		Prefix:            "v",
+		PrereleaseID:      "alpha",
+		MainBranchName:    "master",
+		DevelopBranchName: "develop",
+	}, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v4.2.1", result.SemverTag)
+	assert.Equal(t, "hotfix-into-main", result.Decision.Rule)
+	assert.Equal(t, "default", result.Decision.PreviousTagSource)
+	assert.Equal(t, "4.2.0", result.Decision.BaseVersion)
+	assert.False(t, result.Decision.Substitution.Applied)
+	assert.Equal(t, "source branch is not prefixed with doc or misc", result.Decision.Substitution.Reason)
+}
+
+func TestTag_Changelog(t *testing.T) {
+	gc := initGitClientMock(t, "v1.4.17-alpha.1", "v1.4.16", "master", "develop", "81918ffc")
+	gc.CommitsFn = func(from, to string) ([]git.Commit, error) {
+		assert.Equal(t, "v1.4.16", from)
+		assert.Equal(t, "81918ffc", to)
+
+		return []git.Commit{
+			{
+				ShortHash: "e63c125",
+				Author:    "John Doe",
+				Subject:   "Merge pull request #12 from wakatime/feature/login",
+				Body:      "Add login",
+			},
+		}, nil
+	}
+
+	result, err := generate.Tag(context.Background(), generate.Params{
+		CommitSha:         "81918ffc",
+		Bump:              "auto",
+		Prefix:            "v",
+		PrereleaseID:      "alpha",
+		MainBranchName:    "master",
+		DevelopBranchName: "develop",
+	}, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "### Features\n\n- Add login (#12) by John Doe (e63c125)\n", result.Changelog)
+	assert.Equal(t, 1, gc.CommitsFnInvoked)
+}
+
+func TestTag_ChangelogErr(t *testing.T) {
+	gc := initGitClientMock(t, "v1.4.17-alpha.1", "v1.4.16", "master", "develop", "81918ffc")
+	gc.CommitsFn = func(from, to string) ([]git.Commit, error) {
+		return nil, errors.New("error")
+	}
+
+	result, err := generate.Tag(context.Background(), generate.Params{
+		CommitSha:         "81918ffc",
+		Bump:              "auto",
+		Prefix:            "v",
+		PrereleaseID:      "alpha",
+		MainBranchName:    "master",
+		DevelopBranchName: "develop",
+	}, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.4.17", result.SemverTag)
+	assert.Empty(t, result.Changelog)
+	assert.Equal(t, 1, gc.CommitsFnInvoked)
+}
+
 func TestTag_IsNotRepo(t *testing.T) {
 	gc := &gitClientMock{
 		MakeSafeFn: func() error {
@@ -408,38 +713,157 @@ func TestTag_IsNotRepo(t *testing.T) {
 		},
 	}
 
-	_, err := generate.Tag(generate.Params{}, gc)
+	_, err := generate.Tag(context.Background(), generate.Params{}, gc)
 	require.Error(t, err)
 
+	assert.True(t, errors.Is(err, git.ErrNotRepository))
 	assert.EqualError(t, err, "current folder is not a git repository")
 }
 
+func TestTag_InvalidLatestTag(t *testing.T) {
+	gc := initGitClientMock(t, "nightly", "v1.2.3", "develop", "feature/some", "81918ffc")
+
+	_, err := generate.Tag(context.Background(), generate.Params{
+		CommitSha:         "81918ffc",
+		Bump:              "auto",
+		Prefix:            "v",
+		PrereleaseID:      "pre",
+		MainBranchName:    "master",
+		DevelopBranchName: "develop",
+	}, gc)
+	require.Error(t, err)
+
+	var invalidTag *generate.InvalidTagError
+
+	require.True(t, errors.As(err, &invalidTag))
+
+	assert.True(t, errors.Is(err, generate.ErrInvalidTag))
+	assert.Equal(t, "nightly", invalidTag.Tag)
+}
+
+func TestTag_NoSourceBranch(t *testing.T) {
+	gc := initGitClientMock(t, "v1.2.3", "v1.2.3", "develop", "", "81918ffc")
+	gc.SourceBranchFn = func(commitHash string) (string, error) {
+		return "", git.ErrNoSourceBranch
+	}
+
+	_, err := generate.Tag(context.Background(), generate.Params{CommitSha: "81918ffc"}, gc)
+	require.Error(t, err)
+
+	assert.True(t, errors.Is(err, git.ErrNoSourceBranch))
+	assert.EqualError(t, err, "failed to extract source branch from commit: no source branch found")
+}
+
 func TestTag_MakeSafeErr(t *testing.T) {
 	gc := &gitClientMock{
+		IsRepoFn: func() bool {
+			return false
+		},
 		MakeSafeFn: func() error {
 			return errors.New("error")
 		},
 	}
 
-	_, err := generate.Tag(generate.Params{}, gc)
+	_, err := generate.Tag(context.Background(), generate.Params{}, gc)
 	require.Error(t, err)
 
 	assert.EqualError(t, err, "failed to make safe: error")
 }
 
+func TestTag_SafeDirectory(t *testing.T) {
+	tests := map[string]struct {
+		Trusted             bool
+		GlobalSafeDirectory bool
+		ExpectedMakeSafe    int
+		ExpectedIsRepo      int
+		ExpectedAddSafe     int
+	}{
+		"trusted": {
+			Trusted:        true,
+			ExpectedIsRepo: 1,
+		},
+		"owned by another user": {
+			ExpectedMakeSafe: 1,
+			ExpectedIsRepo:   2,
+		},
+		"global": {
+			Trusted:             true,
+			GlobalSafeDirectory: true,
+			ExpectedIsRepo:      1,
+			ExpectedAddSafe:     1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gc := initGitClientMock(t, "v1.2.3", "v1.2.3", "develop", "feature/some", "81918ffc")
+			gc.IsRepoFn = func() bool {
+				return test.Trusted || gc.MakeSafeFnInvoked > 0
+			}
+
+			_, err := generate.Tag(context.Background(), generate.Params{
+				CommitSha:           "81918ffc",
+				Bump:                "auto",
+				Prefix:              "v",
+				PrereleaseID:        "pre",
+				MainBranchName:      "master",
+				DevelopBranchName:   "develop",
+				GlobalSafeDirectory: test.GlobalSafeDirectory,
+			}, gc)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.ExpectedMakeSafe, gc.MakeSafeFnInvoked)
+			assert.Equal(t, test.ExpectedIsRepo, gc.IsRepoFnInvoked)
+			assert.Equal(t, test.ExpectedAddSafe, gc.AddSafeDirectoryFnInvoked)
+		})
+	}
+}
+
 type gitClientMock struct {
-	CurrentBranchFn        func() (string, error)
-	CurrentBranchFnInvoked int
-	IsRepoFn               func() bool
-	IsRepoFnInvoked        int
-	MakeSafeFn             func() error
-	MakeSafeFnInvoked      int
-	LatestTagFn            func() string
-	LatestTagFnInvoked     int
-	AncestorTagFn          func(include, exclude, branch string) string
-	AncestorTagFnInvoked   int
-	SourceBranchFn         func(commitHash string) (string, error)
-	SourceBranchFnInvoked  int
+	CurrentBranchFn           func() (string, error)
+	CurrentBranchFnInvoked    int
+	IsRepoFn                  func() bool
+	IsRepoFnInvoked           int
+	MakeSafeFn                func() error
+	MakeSafeFnInvoked         int
+	AddSafeDirectoryFn        func() error
+	AddSafeDirectoryFnInvoked int
+	RemoteBranchesFn          func(remote, rev string) ([]git.Branch, error)
+	RemoteBranchesFnInvoked   int
+	CountCommitsFn            func(from, to string) (int, error)
+	CountCommitsFnInvoked     int
+	LatestTagFn               func() string
+	LatestTagFnInvoked        int
+	AncestorTagFn             func(include, exclude, branch string) string
+	AncestorTagFnInvoked      int
+	SourceBranchFn            func(commitHash string) (string, error)
+	SourceBranchFnInvoked     int
+	CommitsFn                 func(from, to string) ([]git.Commit, error)
+	CommitsFnInvoked          int
+	CreateTagFn               func(opts git.TagOptions) error
+	CreateTagFnInvoked        int
+	DeleteTagFn               func(name string) error
+	DeleteTagFnInvoked        int
+	PushTagFn                 func(remote, name string) error
+	PushTagFnInvoked          int
+	FetchTagsFn               func(remote string) error
+	FetchTagsFnInvoked        int
+	TagCommitFn               func(name string) string
+	TagCommitFnInvoked        int
+	RemoteTagCommitFn         func(remote, name string) (string, error)
+	RemoteTagCommitFnInvoked  int
+	ResolveCommitFn           func(rev string) (string, error)
+	ResolveCommitFnInvoked    int
+	TagsAtFn                  func(rev string) ([]string, error)
+	TagsAtFnInvoked           int
+	TagsFn                    func(reachableFrom string) ([]string, error)
+	TagsFnInvoked             int
+	IsShallowFn               func() (bool, error)
+	IsShallowFnInvoked        int
+	DeepenFn                  func(remote string, depth int) error
+	DeepenFnInvoked           int
+	UnshallowFn               func(remote string) error
+	UnshallowFnInvoked        int
 }
 
 func initGitClientMock(
@@ -459,6 +883,9 @@ func initGitClientMock(
 		MakeSafeFn: func() error {
 			return nil
 		},
+		AddSafeDirectoryFn: func() error {
+			return nil
+		},
 		LatestTagFn: func() string {
 			return latestTag
 		},
@@ -469,39 +896,134 @@ func initGitClientMock(
 			assert.Equal(t, expectedCommitHash, commitHash)
 			return sourceBranch, nil
 		},
+		CommitsFn: func(from, to string) ([]git.Commit, error) {
+			return nil, nil
+		},
+		TagCommitFn: func(name string) string {
+			return ""
+		},
+		ResolveCommitFn: func(rev string) (string, error) {
+			return rev, nil
+		},
+		TagsAtFn: func(rev string) ([]string, error) {
+			return nil, nil
+		},
+		IsShallowFn: func() (bool, error) {
+			return false, nil
+		},
 	}
 }
 
-func (m *gitClientMock) CurrentBranch() (string, error) {
+func (m *gitClientMock) CurrentBranch(_ context.Context) (string, error) {
 	m.CurrentBranchFnInvoked++
 	return m.CurrentBranchFn()
 }
 
-func (m *gitClientMock) MakeSafe() error {
+func (m *gitClientMock) MakeSafe(_ context.Context) error {
 	m.MakeSafeFnInvoked++
 	return m.MakeSafeFn()
 }
 
-func (m *gitClientMock) IsRepo() bool {
+func (m *gitClientMock) AddSafeDirectory(_ context.Context) error {
+	m.AddSafeDirectoryFnInvoked++
+	return m.AddSafeDirectoryFn()
+}
+
+func (m *gitClientMock) RemoteBranches(_ context.Context, remote, rev string) ([]git.Branch, error) {
+	m.RemoteBranchesFnInvoked++
+	return m.RemoteBranchesFn(remote, rev)
+}
+
+func (m *gitClientMock) CountCommits(_ context.Context, from, to string) (int, error) {
+	m.CountCommitsFnInvoked++
+	return m.CountCommitsFn(from, to)
+}
+
+func (m *gitClientMock) IsRepo(_ context.Context) (bool, error) {
 	m.IsRepoFnInvoked++
-	return m.IsRepoFn()
+	return m.IsRepoFn(), nil
 }
 
-func (m *gitClientMock) LatestTag() string {
+func (m *gitClientMock) LatestTag(_ context.Context) (string, error) {
 	m.LatestTagFnInvoked++
-	return m.LatestTagFn()
+	return m.LatestTagFn(), nil
 }
 
-func (m *gitClientMock) AncestorTag(include, exclude, branch string) string {
+func (m *gitClientMock) AncestorTag(_ context.Context, include, exclude, branch string) (string, error) {
 	m.AncestorTagFnInvoked++
-	return m.AncestorTagFn(include, exclude, branch)
+	return m.AncestorTagFn(include, exclude, branch), nil
 }
 
-func (m *gitClientMock) SourceBranch(commitHash string) (string, error) {
+func (m *gitClientMock) SourceBranch(_ context.Context, commitHash string) (string, error) {
 	m.SourceBranchFnInvoked++
 	return m.SourceBranchFn(commitHash)
 }
 
+func (m *gitClientMock) Commits(_ context.Context, from, to string) ([]git.Commit, error) {
+	m.CommitsFnInvoked++
+	return m.CommitsFn(from, to)
+}
+
+func (m *gitClientMock) CreateTag(_ context.Context, opts git.TagOptions) error {
+	m.CreateTagFnInvoked++
+	return m.CreateTagFn(opts)
+}
+
+func (m *gitClientMock) DeleteTag(_ context.Context, name string) error {
+	m.DeleteTagFnInvoked++
+	return m.DeleteTagFn(name)
+}
+
+func (m *gitClientMock) PushTag(_ context.Context, remote, name string) error {
+	m.PushTagFnInvoked++
+	return m.PushTagFn(remote, name)
+}
+
+func (m *gitClientMock) FetchTags(_ context.Context, remote string) error {
+	m.FetchTagsFnInvoked++
+	return m.FetchTagsFn(remote)
+}
+
+func (m *gitClientMock) TagCommit(_ context.Context, name string) (string, error) {
+	m.TagCommitFnInvoked++
+	return m.TagCommitFn(name), nil
+}
+
+func (m *gitClientMock) RemoteTagCommit(_ context.Context, remote, name string) (string, error) {
+	m.RemoteTagCommitFnInvoked++
+	return m.RemoteTagCommitFn(remote, name)
+}
+
+func (m *gitClientMock) ResolveCommit(_ context.Context, rev string) (string, error) {
+	m.ResolveCommitFnInvoked++
+	return m.ResolveCommitFn(rev)
+}
+
+func (m *gitClientMock) TagsAt(_ context.Context, rev string) ([]string, error) {
+	m.TagsAtFnInvoked++
+	return m.TagsAtFn(rev)
+}
+
+func (m *gitClientMock) Tags(_ context.Context, reachableFrom string) ([]string, error) {
+	m.TagsFnInvoked++
+	return m.TagsFn(reachableFrom)
+}
+
+func (m *gitClientMock) IsShallow(_ context.Context) (bool, error) {
+	m.IsShallowFnInvoked++
+	return m.IsShallowFn()
+}
+
+func (m *gitClientMock) Deepen(_ context.Context, remote string, depth int) error {
+	m.DeepenFnInvoked++
+	return m.DeepenFn(remote, depth)
+}
+
+func (m *gitClientMock) Unshallow(_ context.Context, remote string) error {
+	m.UnshallowFnInvoked++
+	return m.UnshallowFn(remote)
+}
+
 func newSemVerPtr(t *testing.T, s string) *semver.Version {
 	version, err := semver.New(s)
 	require.NoError(t, err)
@@ -0,0 +1,105 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apex/log"
+)
+
+// Policies applied when the repository is a shallow clone.
+const (
+	shallowPolicyDeepen    = "deepen"
+	shallowPolicyUnshallow = "unshallow"
+	shallowPolicyFail      = "fail"
+	shallowPolicyIgnore    = "ignore"
+)
+
+const (
+	// deepenStep is the number of commits fetched by the first deepen call. It
+	// doubles on every further call.
+	deepenStep = 100
+	// deepenMaxAttempts is the number of deepen calls before the complete
+	// history is fetched.
+	deepenMaxAttempts = 6
+)
+
+// ensureHistory makes sure the tags needed to calculate the version are
+// reachable in shallow clones. Depending on the shallow policy it fails or
+// fetches more history and tags from the remote until a tag with the prefix
+// is reachable from the commit.
+func ensureHistory(ctx context.Context, params Params, gc historyClient, rev string) error {
+	if params.ShallowPolicy == shallowPolicyIgnore {
+		return nil
+	}
+
+	shallow, err := gc.IsShallow(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !shallow {
+		return nil
+	}
+
+	switch params.ShallowPolicy {
+	case shallowPolicyFail:
+		return fmt.Errorf(
+			"%w and tags outside of the fetched history cannot be found."+
+				" Set fetch-depth: 0 for actions/checkout or set shallow_policy to deepen", ErrShallowClone)
+	case shallowPolicyUnshallow:
+		log.Warnf("repository is a shallow clone, fetching the complete history from %s\n", params.Remote)
+
+		return gc.Unshallow(ctx, params.Remote)
+	}
+
+	depth := deepenStep
+
+	for attempt := 0; attempt < deepenMaxAttempts; attempt++ {
+		found, err := reachableTagFound(ctx, params, gc, rev)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			return nil
+		}
+
+		log.Warnf("repository is a shallow clone, fetching %d more commits from %s\n", depth, params.Remote)
+
+		if err := gc.Deepen(ctx, params.Remote, depth); err != nil {
+			return err
+		}
+
+		shallow, err := gc.IsShallow(ctx)
+		if err != nil {
+			return err
+		}
+
+		if !shallow {
+			return nil
+		}
+
+		depth *= 2
+	}
+
+	found, err := reachableTagFound(ctx, params, gc, rev)
+	if err != nil || found {
+		return err
+	}
+
+	log.Warnf("no tag found after deepening, fetching the complete history from %s\n", params.Remote)
+
+	return gc.Unshallow(ctx, params.Remote)
+}
+
+// reachableTagFound returns true if a semantic version tag with the prefix is
+// reachable from the revision.
+func reachableTagFound(ctx context.Context, params Params, gc tagLister, rev string) (bool, error) {
+	tags, err := gc.Tags(ctx, rev)
+	if err != nil {
+		return false, err
+	}
+
+	return highestTag(tags, params.Prefix) != "", nil
+}
@@ -0,0 +1,140 @@
+package generate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/wakatime/semver-action/cmd/generate"
+
+	"github.com/alecthomas/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTag_ShallowFail(t *testing.T) {
+	gc := initGitClientMock(t, "v1.2.3", "v1.2.3", "develop", "feature/some", "81918ffc")
+	gc.IsShallowFn = func() (bool, error) {
+		return true, nil
+	}
+
+	params := tagExistsParams("fail")
+	params.ShallowPolicy = "fail"
+
+	_, err := generate.Tag(context.Background(), params, gc)
+	require.Error(t, err)
+
+	assert.True(t, errors.Is(err, generate.ErrShallowClone))
+	assert.Contains(t, err.Error(), "repository is a shallow clone")
+	assert.Equal(t, 0, gc.LatestTagFnInvoked)
+}
+
+func TestTag_ShallowIgnore(t *testing.T) {
+	gc := initGitClientMock(t, "v1.2.3", "v1.2.3", "develop", "feature/some", "81918ffc")
+
+	params := tagExistsParams("fail")
+	params.ShallowPolicy = "ignore"
+
+	_, err := generate.Tag(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, gc.IsShallowFnInvoked)
+}
+
+func TestTag_ShallowUnshallow(t *testing.T) {
+	gc := initGitClientMock(t, "v1.2.3", "v1.2.3", "develop", "feature/some", "81918ffc")
+	gc.IsShallowFn = func() (bool, error) {
+		return true, nil
+	}
+	gc.UnshallowFn = func(remote string) error {
+		assert.Equal(t, "origin", remote)
+
+		return nil
+	}
+
+	params := tagExistsParams("fail")
+	params.ShallowPolicy = "unshallow"
+
+	result, err := generate.Tag(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.3.0-pre.1", result.SemverTag)
+	assert.Equal(t, 1, gc.UnshallowFnInvoked)
+}
+
+func TestTag_ShallowDeepen(t *testing.T) {
+	var depths []int
+
+	gc := initGitClientMock(t, "v1.2.3", "v1.2.3", "develop", "feature/some", "81918ffc")
+	gc.IsShallowFn = func() (bool, error) {
+		return true, nil
+	}
+	gc.TagsFn = func(reachableFrom string) ([]string, error) {
+		assert.Equal(t, "81918ffc", reachableFrom)
+
+		// the tag becomes reachable after the second deepen call
+		if len(depths) < 2 {
+			return []string{"latest"}, nil
+		}
+
+		return []string{"latest", "v1.2.3"}, nil
+	}
+	gc.DeepenFn = func(remote string, depth int) error {
+		assert.Equal(t, "origin", remote)
+
+		depths = append(depths, depth)
+
+		return nil
+	}
+
+	params := tagExistsParams("fail")
+	params.ShallowPolicy = "deepen"
+
+	result, err := generate.Tag(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.3.0-pre.1", result.SemverTag)
+	assert.Equal(t, []int{100, 200}, depths)
+	assert.Equal(t, 0, gc.UnshallowFnInvoked)
+}
+
+func TestTag_ShallowDeepenCompleteHistory(t *testing.T) {
+	gc := initGitClientMock(t, "", "", "develop", "feature/some", "81918ffc")
+	gc.IsShallowFn = func() (bool, error) {
+		return gc.DeepenFnInvoked == 0, nil
+	}
+	gc.TagsFn = func(reachableFrom string) ([]string, error) {
+		return nil, nil
+	}
+	gc.DeepenFn = func(remote string, depth int) error {
+		return nil
+	}
+
+	result, err := generate.Tag(context.Background(), tagExistsParams("fail"), gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v0.1.0-pre.1", result.SemverTag)
+	assert.Equal(t, 1, gc.DeepenFnInvoked)
+	assert.Equal(t, 0, gc.UnshallowFnInvoked)
+}
+
+func TestTag_ShallowDeepenExhausted(t *testing.T) {
+	gc := initGitClientMock(t, "", "", "develop", "feature/some", "81918ffc")
+	gc.IsShallowFn = func() (bool, error) {
+		return true, nil
+	}
+	gc.TagsFn = func(reachableFrom string) ([]string, error) {
+		return nil, nil
+	}
+	gc.DeepenFn = func(remote string, depth int) error {
+		return nil
+	}
+	gc.UnshallowFn = func(remote string) error {
+		return nil
+	}
+
+	_, err := generate.Tag(context.Background(), tagExistsParams("fail"), gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, 6, gc.DeepenFnInvoked)
+	assert.Equal(t, 1, gc.UnshallowFnInvoked)
+}
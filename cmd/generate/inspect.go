@@ -0,0 +1,76 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	calc "github.com/wakatime/semver-action/pkg/semver"
+
+	"github.com/apex/log"
+	"github.com/blang/semver/v4"
+)
+
+// Explain calculates the semantic version without creating tags or writing files.
+func Explain(ctx context.Context, params Params) (Result, error) {
+	if params.Debug {
+		log.SetLevel(log.DebugLevel)
+	}
+
+	log.Debug(params.String())
+
+	ctx, cancel := withTimeout(ctx, params.Timeout)
+	defer cancel()
+
+	return Tag(ctx, params, newGitClient(params))
+}
+
+// Current returns the latest tag of the repository.
+func Current(ctx context.Context, params Params) (string, error) {
+	if params.Debug {
+		log.SetLevel(log.DebugLevel)
+	}
+
+	ctx, cancel := withTimeout(ctx, params.Timeout)
+	defer cancel()
+
+	return CurrentTag(ctx, params, newGitClient(params))
+}
+
+// CurrentTag returns the latest tag or the default tag if none is found.
+func CurrentTag(ctx context.Context, params Params, gc inspectClient) (string, error) {
+	if err := checkRepo(ctx, gc); err != nil {
+		return "", err
+	}
+
+	latestTag, err := gc.LatestTag(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get latest tag: %s", err)
+	}
+
+	if latestTag == "" {
+		return params.Prefix + calc.InitialVersion, nil
+	}
+
+	return latestTag, nil
+}
+
+// Validate checks that the version is a valid semantic version with the given prefix.
+func Validate(version, prefix string) (semver.Version, error) {
+	if !strings.HasPrefix(version, prefix) {
+		return semver.Version{}, &InvalidTagError{
+			Tag: version,
+			Err: fmt.Errorf("version %q does not start with prefix %q", version, prefix),
+		}
+	}
+
+	parsed, err := semver.Parse(strings.TrimPrefix(version, prefix))
+	if err != nil {
+		return semver.Version{}, &InvalidTagError{
+			Tag: version,
+			Err: fmt.Errorf("version %q is not a valid semantic version: %s", version, err),
+		}
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,195 @@
+package generate
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// nolint: gochecknoglobals
+var (
+	unreleasedHeadingRegex = regexp.MustCompile(`(?i)^##\s+\[?unreleased\]?\s*$`)
+	unreleasedLinkRegex    = regexp.MustCompile(`(?i)^\[unreleased\]:\s*(\S+)/compare/\S+$`)
+	versionHeadingRegex    = regexp.MustCompile(`^##\s+`)
+	linkReferenceRegex     = regexp.MustCompile(`^\[[^\]]+\]:\s*\S+`)
+)
+
+// ReleaseSection contains the data needed to add a new version to a changelog
+// in Keep a Changelog format.
+type ReleaseSection struct {
+	Version     string
+	Tag         string
+	PreviousTag string
+	Date        time.Time
+	// Fallback is used as the section content when the unreleased section is empty.
+	Fallback string
+	// RepoURL is used to build compare links when the changelog has none yet.
+	RepoURL string
+}
+
+// UpdateChangelogFile inserts a new version section into the changelog file. It
+// returns false when the file already contains the version.
+func UpdateChangelogFile(fp string, section ReleaseSection) (bool, error) {
+	// nolint:gosec
+	content, err := os.ReadFile(fp)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read changelog file: %s", err)
+	}
+
+	updated, changed := UpdateChangelog(string(content), section)
+	if !changed {
+		return false, nil
+	}
+
+	// nolint:gosec
+	if err := os.WriteFile(fp, []byte(updated), 0644); err != nil {
+		return false, fmt.Errorf("failed to write changelog file: %s", err)
+	}
+
+	return true, nil
+}
+
+// UpdateChangelog moves the items of the unreleased section into a new version
+// section and updates the compare links at the bottom. It returns false when the
+// changelog already contains the version.
+func UpdateChangelog(content string, section ReleaseSection) (string, bool) {
+	versionHeading := fmt.Sprintf("## [%s]", section.Version)
+
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if content == "" {
+		lines = []string{"# Changelog"}
+	}
+
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), versionHeading) {
+			return content, false
+		}
+	}
+
+	body, links := splitLinkReferences(lines)
+
+	unreleased := -1
+
+	for i, line := range body {
+		if unreleasedHeadingRegex.MatchString(strings.TrimSpace(line)) {
+			unreleased = i
+			break
+		}
+	}
+
+	var head, items, tail []string
+
+	if unreleased == -1 {
+		next := nextVersionHeading(body, 0)
+		head = append(append([]string{}, trimBlankLines(body[:next])...), "", "## [Unreleased]")
+		tail = body[next:]
+	} else {
+		next := nextVersionHeading(body, unreleased+1)
+		head = body[:unreleased+1]
+		items = trimBlankLines(body[unreleased+1 : next])
+		tail = body[next:]
+	}
+
+	if len(items) == 0 && strings.TrimSpace(section.Fallback) != "" {
+		items = strings.Split(strings.TrimSpace(section.Fallback), "\n")
+	}
+
+	result := append([]string{}, head...)
+	result = append(result, "", fmt.Sprintf("%s - %s", versionHeading, section.Date.Format("2006-01-02")))
+
+	if len(items) > 0 {
+		result = append(result, "")
+		result = append(result, items...)
+	}
+
+	if len(tail) > 0 {
+		result = append(result, "")
+		result = append(result, trimBlankLines(tail)...)
+	}
+
+	links = updateCompareLinks(links, section)
+	if len(links) > 0 {
+		result = append(result, "")
+		result = append(result, links...)
+	}
+
+	return strings.Join(result, "\n") + "\n", true
+}
+
+// splitLinkReferences splits the trailing link reference definitions from the rest of the changelog.
+func splitLinkReferences(lines []string) ([]string, []string) {
+	i := len(lines)
+
+	for i > 0 {
+		line := strings.TrimSpace(lines[i-1])
+		if line != "" && !linkReferenceRegex.MatchString(line) {
+			break
+		}
+
+		i--
+	}
+
+	return lines[:i], trimBlankLines(lines[i:])
+}
+
+// updateCompareLinks points the unreleased link at the new tag and adds a link for the new version.
+func updateCompareLinks(links []string, section ReleaseSection) []string {
+	repoURL := section.RepoURL
+	unreleased := -1
+
+	for i, link := range links {
+		if match := unreleasedLinkRegex.FindStringSubmatch(strings.TrimSpace(link)); match != nil {
+			repoURL = match[1]
+			unreleased = i
+
+			break
+		}
+	}
+
+	if repoURL == "" {
+		return links
+	}
+
+	versionLink := fmt.Sprintf("[%s]: %s/releases/tag/%s", section.Version, repoURL, section.Tag)
+	if section.PreviousTag != "" {
+		versionLink = fmt.Sprintf("[%s]: %s/compare/%s...%s", section.Version, repoURL, section.PreviousTag, section.Tag)
+	}
+
+	unreleasedLink := fmt.Sprintf("[Unreleased]: %s/compare/%s...HEAD", repoURL, section.Tag)
+
+	if unreleased == -1 {
+		return append([]string{unreleasedLink, versionLink}, links...)
+	}
+
+	result := append([]string{}, links[:unreleased]...)
+	result = append(result, unreleasedLink, versionLink)
+
+	return append(result, links[unreleased+1:]...)
+}
+
+// nextVersionHeading returns the index of the next level two heading starting at
+// the given index or the length of lines if there is none.
+func nextVersionHeading(lines []string, start int) int {
+	for i := start; i < len(lines); i++ {
+		if versionHeadingRegex.MatchString(lines[i]) {
+			return i
+		}
+	}
+
+	return len(lines)
+}
+
+// trimBlankLines removes leading and trailing blank lines.
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
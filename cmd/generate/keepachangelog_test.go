@@ -0,0 +1,177 @@
+package generate_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wakatime/semver-action/cmd/generate"
+
+	"github.com/alecthomas/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateChangelog(t *testing.T) {
+	content := `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+## [Unreleased]
+
+### Added
+
+- New login page.
+
+## [1.0.0] - 2026-01-01
+
+### Added
+
+- Initial release.
+
+[Unreleased]: https://github.com/wakatime/semver-action/compare/v1.0.0...HEAD
+[1.0.0]: https://github.com/wakatime/semver-action/releases/tag/v1.0.0
+`
+
+	expected := `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+## [Unreleased]
+
+## [1.1.0] - 2026-10-18
+
+### Added
+
+- New login page.
+
+## [1.0.0] - 2026-01-01
+
+### Added
+
+- Initial release.
+
+[Unreleased]: https://github.com/wakatime/semver-action/compare/v1.1.0...HEAD
+[1.1.0]: https://github.com/wakatime/semver-action/compare/v1.0.0...v1.1.0
+[1.0.0]: https://github.com/wakatime/semver-action/releases/tag/v1.0.0
+`
+
+	updated, changed := generate.UpdateChangelog(content, generate.ReleaseSection{
+		Version:     "1.1.0",
+		Tag:         "v1.1.0",
+		PreviousTag: "v1.0.0",
+		Date:        time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+	})
+
+	assert.True(t, changed)
+	assert.Equal(t, expected, updated)
+}
+
+func TestUpdateChangelog_AlreadyReleased(t *testing.T) {
+	content := `# Changelog
+
+## [Unreleased]
+
+## [1.1.0] - 2026-10-18
+
+- New login page.
+`
+
+	updated, changed := generate.UpdateChangelog(content, generate.ReleaseSection{
+		Version:     "1.1.0",
+		Tag:         "v1.1.0",
+		PreviousTag: "v1.0.0",
+		Date:        time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+	})
+
+	assert.False(t, changed)
+	assert.Equal(t, content, updated)
+}
+
+func TestUpdateChangelog_EmptyUnreleasedUsesFallback(t *testing.T) {
+	content := `# Changelog
+
+## [Unreleased]
+`
+
+	expected := `# Changelog
+
+## [Unreleased]
+
+## [0.1.0] - 2026-10-18
+
+### Features
+
+- Add login (#1) by John Doe (e63c125)
+
+[Unreleased]: https://github.com/wakatime/semver-action/compare/v0.1.0...HEAD
+[0.1.0]: https://github.com/wakatime/semver-action/releases/tag/v0.1.0
+`
+
+	updated, changed := generate.UpdateChangelog(content, generate.ReleaseSection{
+		Version:  "0.1.0",
+		Tag:      "v0.1.0",
+		Date:     time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+		Fallback: "### Features\n\n- Add login (#1) by John Doe (e63c125)\n",
+		RepoURL:  "https://github.com/wakatime/semver-action",
+	})
+
+	assert.True(t, changed)
+	assert.Equal(t, expected, updated)
+}
+
+func TestUpdateChangelog_NoUnreleasedSection(t *testing.T) {
+	content := `# Changelog
+
+## [1.0.0] - 2026-01-01
+
+- Initial release.
+`
+
+	expected := `# Changelog
+
+## [Unreleased]
+
+## [1.0.1] - 2026-10-18
+
+## [1.0.0] - 2026-01-01
+
+- Initial release.
+`
+
+	updated, changed := generate.UpdateChangelog(content, generate.ReleaseSection{
+		Version:     "1.0.1",
+		Tag:         "v1.0.1",
+		PreviousTag: "v1.0.0",
+		Date:        time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+	})
+
+	assert.True(t, changed)
+	assert.Equal(t, expected, updated)
+}
+
+func TestUpdateChangelogFile(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "CHANGELOG.md")
+
+	section := generate.ReleaseSection{
+		Version:  "1.0.0",
+		Tag:      "v1.0.0",
+		Date:     time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+		Fallback: "- Initial release.",
+	}
+
+	changed, err := generate.UpdateChangelogFile(fp, section)
+	require.NoError(t, err)
+
+	assert.True(t, changed)
+
+	changed, err = generate.UpdateChangelogFile(fp, section)
+	require.NoError(t, err)
+
+	assert.False(t, changed)
+
+	content, err := os.ReadFile(fp)
+	require.NoError(t, err)
+
+	assert.Equal(t, "# Changelog\n\n## [Unreleased]\n\n## [1.0.0] - 2026-10-18\n\n- Initial release.\n", string(content))
+}
@@ -5,8 +5,12 @@ import (
 	"os"
 	"regexp"
 	"strconv"
+	"text/template"
+	"time"
 
 	"github.com/wakatime/semver-action/pkg/actions"
+	"github.com/wakatime/semver-action/pkg/ci"
+	"github.com/wakatime/semver-action/pkg/output"
 
 	"github.com/blang/semver/v4"
 )
@@ -16,26 +20,77 @@ var (
 	commitShaRegex = regexp.MustCompile(`\b[0-9a-f]{5,40}\b`)
 	// nolint
 	validBumpStrategies = []string{"auto", "major", "minor", "patch"}
+	// nolint
+	validSigningFormats = []string{"openpgp", "ssh", "x509"}
+	// nolint
+	validTagExistsPolicies = []string{tagExistsFail, tagExistsBump, tagExistsReuse}
+	// nolint
+	validTagSelections = []string{tagSelectionLatest, tagSelectionHighest, tagSelectionHighestReachable}
+	// nolint
+	validShallowPolicies = []string{shallowPolicyDeepen, shallowPolicyUnshallow, shallowPolicyFail, shallowPolicyIgnore}
+	// nolint
+	validGitBackends = []string{gitBackendExec, gitBackendNative, gitBackendSnapshot}
 )
 
 // Params contains semver generate command parameters.
 type Params struct {
-	CommitSha         string
-	RepoDir           string
-	Bump              string
-	BaseVersion       *semver.Version
-	Prefix            string
-	PrereleaseID      string
-	MainBranchName    string
-	DevelopBranchName string
-	Debug             bool
+	CommitSha           string
+	RepoDir             string
+	Bump                string
+	BaseVersion         *semver.Version
+	Prefix              string
+	PrereleaseID        string
+	MainBranchName      string
+	DevelopBranchName   string
+	SourceBranch        string
+	DestBranch          string
+	DestBranchSource    string
+	PullRequest         uint64
+	BranchSnapshots     bool
+	ChangelogFile       string
+	UpdateChangelogFile string
+	CreateTag           bool
+	AnnotateTag         bool
+	TagMessageTemplate  string
+	SignTag             bool
+	SigningKey          string
+	SigningFormat       string
+	PushTag             bool
+	Remote              string
+	PushRetries         int
+	TagSelection        string
+	ShallowPolicy       string
+	TagExists           string
+	CheckRemoteTags     bool
+	OutputFormats       []output.Format
+	GitBackend          string
+	GitTimeout          time.Duration
+	Timeout             time.Duration
+	GlobalSafeDirectory bool
+	Debug               bool
 }
 
-// LoadParams loads semver generate config params.
+// LoadParams loads semver generate config params from action inputs.
 func LoadParams() (Params, error) {
+	return LoadParamsFrom(actions.GetInput)
+}
+
+// LoadParamsFrom loads semver generate config params using the given input getter.
+func LoadParamsFrom(getInput func(name string) string) (Params, error) {
+	env := ci.Detect()
+
 	var commitSha string
 
-	if commitShaStr := os.Getenv("GITHUB_SHA"); commitShaStr != "" {
+	commitShaStr := getInput("commit_sha")
+	if commitShaStr == "" {
+		commitShaStr = env.CommitSha
+	}
+
+	if commitShaStr == "" {
+		commitShaStr = os.Getenv("GITHUB_SHA")
+	}
+
+	if commitShaStr != "" {
 		if !commitShaRegex.MatchString(commitShaStr) {
 			return Params{}, fmt.Errorf("invalid commit-sha format: %s", commitShaStr)
 		}
@@ -45,13 +100,13 @@ func LoadParams() (Params, error) {
 
 	var repoDir = "."
 
-	if repoDirStr := actions.GetInput("repo_dir"); repoDirStr != "" {
+	if repoDirStr := getInput("repo_dir"); repoDirStr != "" {
 		repoDir = repoDirStr
 	}
 
 	var bump = "auto"
 
-	if bumpStr := actions.GetInput("bump"); bumpStr != "" {
+	if bumpStr := getInput("bump"); bumpStr != "" {
 		if !stringInSlice(bumpStr, validBumpStrategies) {
 			return Params{}, fmt.Errorf("invalid bump value: %s", bumpStr)
 		}
@@ -59,26 +114,20 @@ func LoadParams() (Params, error) {
 		bump = bumpStr
 	}
 
-	var debug bool
-
-	if debugStr := actions.GetInput("debug"); debugStr != "" {
-		parsed, err := strconv.ParseBool(debugStr)
-		if err != nil {
-			return Params{}, fmt.Errorf("invalid debug argument: %s", debugStr)
-		}
-
-		debug = parsed
+	debug, err := parseBoolInput(getInput, "debug")
+	if err != nil {
+		return Params{}, err
 	}
 
 	var prefix = "v"
 
-	if prefixStr := actions.GetInput("prefix"); prefixStr != "" {
+	if prefixStr := getInput("prefix"); prefixStr != "" {
 		prefix = prefixStr
 	}
 
 	var baseVersion *semver.Version
 
-	if baseVersionStr := actions.GetInput("base_version"); baseVersionStr != "" {
+	if baseVersionStr := getInput("base_version"); baseVersionStr != "" {
 		prefixRe := regexp.MustCompile(fmt.Sprintf("^%s", prefix))
 		baseVersionStr = prefixRe.ReplaceAllLiteralString(baseVersionStr, "")
 
@@ -92,35 +141,262 @@ func LoadParams() (Params, error) {
 
 	var mainBranchName = "master"
 
-	if mainBranchNameStr := actions.GetInput("main_branch_name"); mainBranchNameStr != "" {
+	if mainBranchNameStr := getInput("main_branch_name"); mainBranchNameStr != "" {
 		mainBranchName = mainBranchNameStr
 	}
 
 	var developBranchName = "develop"
 
-	if developBranchNameStr := actions.GetInput("develop_branch_name"); developBranchNameStr != "" {
+	if developBranchNameStr := getInput("develop_branch_name"); developBranchNameStr != "" {
 		developBranchName = developBranchNameStr
 	}
 
+	var sourceBranch = env.SourceBranch
+
+	if sourceBranchStr := getInput("source_branch"); sourceBranchStr != "" {
+		sourceBranch = sourceBranchStr
+	}
+
+	var destBranch, destBranchSource = env.DestBranch(), ""
+
+	if destBranch != "" {
+		destBranchSource = fmt.Sprintf("%s environment", env.Provider)
+	}
+
+	if destBranchStr := getInput("dest_branch"); destBranchStr != "" {
+		destBranch = destBranchStr
+		destBranchSource = "dest_branch input"
+	}
+
+	var pullRequest uint64
+
+	// an invalid number from the CI environment is ignored, only the input is validated
+	if parsed, err := strconv.ParseUint(env.PullRequest, 10, 64); err == nil {
+		pullRequest = parsed
+	}
+
+	if pullRequestStr := getInput("pull_request"); pullRequestStr != "" {
+		parsed, err := strconv.ParseUint(pullRequestStr, 10, 64)
+		if err != nil {
+			return Params{}, fmt.Errorf("invalid pull_request argument: %s", pullRequestStr)
+		}
+
+		pullRequest = parsed
+	}
+
+	// CircleCI doesn't expose the target branch of pull requests, so they are
+	// calculated as merges into develop like branch snapshots
+	if pullRequest > 0 && destBranch == "" && env.Provider == ci.CircleCI {
+		destBranch = developBranchName
+		destBranchSource = "develop_branch_name, CircleCI has no pull request target branch"
+	}
+
+	branchSnapshots, err := parseBoolInput(getInput, "branch_snapshots")
+	if err != nil {
+		return Params{}, err
+	}
+
 	var prereleaseID = "pre"
 
-	if prereleaseIDStr := actions.GetInput("prerelease_id"); prereleaseIDStr != "" {
+	if prereleaseIDStr := getInput("prerelease_id"); prereleaseIDStr != "" {
 		prereleaseID = prereleaseIDStr
 	}
 
+	changelogFile := getInput("changelog_file")
+	updateChangelogFile := getInput("update_changelog")
+
+	createTag, err := parseBoolInput(getInput, "create_tag")
+	if err != nil {
+		return Params{}, err
+	}
+
+	annotateTag, err := parseBoolInput(getInput, "annotate_tag")
+	if err != nil {
+		return Params{}, err
+	}
+
+	var tagMessageTemplate = tagMessageTemplateDefault
+
+	if tagMessageStr := getInput("tag_message"); tagMessageStr != "" {
+		if _, err := template.New("tag_message").Parse(tagMessageStr); err != nil {
+			return Params{}, fmt.Errorf("invalid tag_message template: %s", err)
+		}
+
+		tagMessageTemplate = tagMessageStr
+	}
+
+	signTag, err := parseBoolInput(getInput, "sign_tag")
+	if err != nil {
+		return Params{}, err
+	}
+
+	signingKey := getInput("signing_key")
+
+	signingFormat := getInput("signing_format")
+	if signingFormat != "" && !stringInSlice(signingFormat, validSigningFormats) {
+		return Params{}, fmt.Errorf("invalid signing_format value: %s", signingFormat)
+	}
+
+	pushTag, err := parseBoolInput(getInput, "push_tag")
+	if err != nil {
+		return Params{}, err
+	}
+
+	if pushTag && !createTag {
+		return Params{}, fmt.Errorf("push_tag requires create_tag to be enabled")
+	}
+
+	var remote = "origin"
+
+	if remoteStr := getInput("remote"); remoteStr != "" {
+		remote = remoteStr
+	}
+
+	var pushRetries = 3
+
+	if pushRetriesStr := getInput("push_retries"); pushRetriesStr != "" {
+		parsed, err := strconv.Atoi(pushRetriesStr)
+		if err != nil || parsed < 0 {
+			return Params{}, fmt.Errorf("invalid push_retries argument: %s", pushRetriesStr)
+		}
+
+		pushRetries = parsed
+	}
+
+	var tagSelection = tagSelectionLatest
+
+	if tagSelectionStr := getInput("tag_selection"); tagSelectionStr != "" {
+		if !stringInSlice(tagSelectionStr, validTagSelections) {
+			return Params{}, fmt.Errorf("invalid tag_selection value: %s", tagSelectionStr)
+		}
+
+		tagSelection = tagSelectionStr
+	}
+
+	var shallowPolicy = shallowPolicyDeepen
+
+	if shallowPolicyStr := getInput("shallow_policy"); shallowPolicyStr != "" {
+		if !stringInSlice(shallowPolicyStr, validShallowPolicies) {
+			return Params{}, fmt.Errorf("invalid shallow_policy value: %s", shallowPolicyStr)
+		}
+
+		shallowPolicy = shallowPolicyStr
+	}
+
+	var tagExists = tagExistsFail
+
+	if tagExistsStr := getInput("tag_exists"); tagExistsStr != "" {
+		if !stringInSlice(tagExistsStr, validTagExistsPolicies) {
+			return Params{}, fmt.Errorf("invalid tag_exists value: %s", tagExistsStr)
+		}
+
+		tagExists = tagExistsStr
+	}
+
+	checkRemoteTags, err := parseBoolInput(getInput, "check_remote_tags")
+	if err != nil {
+		return Params{}, err
+	}
+
+	outputFormats, err := output.ParseFormats(getInput("output_formats"))
+	if err != nil {
+		return Params{}, fmt.Errorf("invalid output_formats argument: %s", err)
+	}
+
+	var gitBackend = gitBackendExec
+
+	if gitBackendStr := getInput("git_backend"); gitBackendStr != "" {
+		if !stringInSlice(gitBackendStr, validGitBackends) {
+			return Params{}, fmt.Errorf("invalid git_backend value: %s", gitBackendStr)
+		}
+
+		gitBackend = gitBackendStr
+	}
+
+	gitTimeout, err := parseDurationInput(getInput, "git_timeout", gitTimeoutDefault)
+	if err != nil {
+		return Params{}, err
+	}
+
+	timeout, err := parseDurationInput(getInput, "timeout", timeoutDefault)
+	if err != nil {
+		return Params{}, err
+	}
+
+	globalSafeDirectory, err := parseBoolInput(getInput, "global_safe_directory")
+	if err != nil {
+		return Params{}, err
+	}
+
 	return Params{
-		CommitSha:         commitSha,
-		RepoDir:           repoDir,
-		Bump:              bump,
-		BaseVersion:       baseVersion,
-		Prefix:            prefix,
-		PrereleaseID:      prereleaseID,
-		MainBranchName:    mainBranchName,
-		DevelopBranchName: developBranchName,
-		Debug:             debug,
+		CommitSha:           commitSha,
+		RepoDir:             repoDir,
+		Bump:                bump,
+		BaseVersion:         baseVersion,
+		Prefix:              prefix,
+		PrereleaseID:        prereleaseID,
+		MainBranchName:      mainBranchName,
+		DevelopBranchName:   developBranchName,
+		SourceBranch:        sourceBranch,
+		DestBranch:          destBranch,
+		DestBranchSource:    destBranchSource,
+		PullRequest:         pullRequest,
+		BranchSnapshots:     branchSnapshots,
+		ChangelogFile:       changelogFile,
+		UpdateChangelogFile: updateChangelogFile,
+		CreateTag:           createTag,
+		AnnotateTag:         annotateTag,
+		TagMessageTemplate:  tagMessageTemplate,
+		SignTag:             signTag,
+		SigningKey:          signingKey,
+		SigningFormat:       signingFormat,
+		PushTag:             pushTag,
+		Remote:              remote,
+		PushRetries:         pushRetries,
+		TagSelection:        tagSelection,
+		ShallowPolicy:       shallowPolicy,
+		TagExists:           tagExists,
+		CheckRemoteTags:     checkRemoteTags,
+		OutputFormats:       outputFormats,
+		GitBackend:          gitBackend,
+		GitTimeout:          gitTimeout,
+		Timeout:             timeout,
+		GlobalSafeDirectory: globalSafeDirectory,
+		Debug:               debug,
 	}, nil
 }
 
+// parseBoolInput parses a boolean input. It defaults to false when empty.
+func parseBoolInput(getInput func(name string) string, name string) (bool, error) {
+	str := getInput(name)
+	if str == "" {
+		return false, nil
+	}
+
+	parsed, err := strconv.ParseBool(str)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s argument: %s", name, str)
+	}
+
+	return parsed, nil
+}
+
+// parseDurationInput parses a duration input like 30s or 5m. Zero disables
+// the timeout and an empty input returns the default.
+func parseDurationInput(getInput func(name string) string, name string, defaultValue time.Duration) (time.Duration, error) {
+	str := getInput(name)
+	if str == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := time.ParseDuration(str)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("invalid %s argument: %s", name, str)
+	}
+
+	return parsed, nil
+}
+
 func stringInSlice(a string, list []string) bool {
 	for _, b := range list {
 		if b == a {
@@ -140,7 +416,13 @@ func (p Params) String() string {
 	return fmt.Sprintf(
 		"commit sha: %q, bump: %q, base version: %q, prefix: %q,"+
 			" prerelease id: %q, main branch name: %q, develop branch name: %q,"+
-			" repo dir: %q, debug: %t\n",
+			" source branch: %q, dest branch: %q, pull request: %d, branch snapshots: %t,"+
+			" changelog file: %q, update changelog: %q, create tag: %t, annotate tag: %t,"+
+			" tag message: %q, sign tag: %t, signing key: %q, signing format: %q,"+
+			" push tag: %t, remote: %q, push retries: %d, tag selection: %q, shallow policy: %q,"+
+			" tag exists: %q, check remote tags: %t,"+
+			" output formats: %v, git backend: %q, git timeout: %s, timeout: %s,"+
+			" global safe directory: %t, repo dir: %q, debug: %t\n",
 		p.CommitSha,
 		p.Bump,
 		baseVersion,
@@ -148,6 +430,30 @@ func (p Params) String() string {
 		p.PrereleaseID,
 		p.MainBranchName,
 		p.DevelopBranchName,
+		p.SourceBranch,
+		p.DestBranch,
+		p.PullRequest,
+		p.BranchSnapshots,
+		p.ChangelogFile,
+		p.UpdateChangelogFile,
+		p.CreateTag,
+		p.AnnotateTag,
+		p.TagMessageTemplate,
+		p.SignTag,
+		p.SigningKey,
+		p.SigningFormat,
+		p.PushTag,
+		p.Remote,
+		p.PushRetries,
+		p.TagSelection,
+		p.ShallowPolicy,
+		p.TagExists,
+		p.CheckRemoteTags,
+		p.OutputFormats,
+		p.GitBackend,
+		p.GitTimeout,
+		p.Timeout,
+		p.GlobalSafeDirectory,
 		p.RepoDir,
 		p.Debug,
 	)
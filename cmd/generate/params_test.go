@@ -2,10 +2,13 @@ package generate_test
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/blang/semver/v4"
 	"github.com/wakatime/semver-action/cmd/generate"
+	"github.com/wakatime/semver-action/pkg/output"
 
 	"github.com/alecthomas/assert"
 	"github.com/stretchr/testify/require"
@@ -128,3 +131,350 @@ func TestLoadParams_InvalidBaseVersion(t *testing.T) {
 	_, err := generate.LoadParams()
 	require.Error(t, err)
 }
+
+func TestLoadParams_ChangelogFile(t *testing.T) {
+	os.Setenv("INPUT_CHANGELOG_FILE", "release-notes.md")
+	defer os.Unsetenv("INPUT_CHANGELOG_FILE")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "release-notes.md", params.ChangelogFile)
+}
+
+func TestLoadParams_UpdateChangelog(t *testing.T) {
+	os.Setenv("INPUT_UPDATE_CHANGELOG", "CHANGELOG.md")
+	defer os.Unsetenv("INPUT_UPDATE_CHANGELOG")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "CHANGELOG.md", params.UpdateChangelogFile)
+}
+
+func TestLoadParams_CreateTag(t *testing.T) {
+	os.Setenv("INPUT_CREATE_TAG", "true")
+	defer os.Unsetenv("INPUT_CREATE_TAG")
+
+	os.Setenv("INPUT_PUSH_TAG", "true")
+	defer os.Unsetenv("INPUT_PUSH_TAG")
+
+	os.Setenv("INPUT_REMOTE", "upstream")
+	defer os.Unsetenv("INPUT_REMOTE")
+
+	os.Setenv("INPUT_PUSH_RETRIES", "5")
+	defer os.Unsetenv("INPUT_PUSH_RETRIES")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.True(t, params.CreateTag)
+	assert.True(t, params.PushTag)
+	assert.Equal(t, "upstream", params.Remote)
+	assert.Equal(t, 5, params.PushRetries)
+}
+
+func TestLoadParams_PushTagWithoutCreateTag(t *testing.T) {
+	os.Setenv("INPUT_PUSH_TAG", "true")
+	defer os.Unsetenv("INPUT_PUSH_TAG")
+
+	_, err := generate.LoadParams()
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "push_tag requires create_tag to be enabled")
+}
+
+func TestLoadParams_InvalidPushRetries(t *testing.T) {
+	os.Setenv("INPUT_PUSH_RETRIES", "-1")
+	defer os.Unsetenv("INPUT_PUSH_RETRIES")
+
+	_, err := generate.LoadParams()
+	require.Error(t, err)
+}
+
+func TestLoadParams_SignTag(t *testing.T) {
+	os.Setenv("INPUT_SIGN_TAG", "true")
+	defer os.Unsetenv("INPUT_SIGN_TAG")
+
+	os.Setenv("INPUT_SIGNING_KEY", "~/.ssh/id_ed25519")
+	defer os.Unsetenv("INPUT_SIGNING_KEY")
+
+	os.Setenv("INPUT_SIGNING_FORMAT", "ssh")
+	defer os.Unsetenv("INPUT_SIGNING_FORMAT")
+
+	os.Setenv("INPUT_TAG_MESSAGE", "Release {{ .Version }}")
+	defer os.Unsetenv("INPUT_TAG_MESSAGE")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.True(t, params.SignTag)
+	assert.Equal(t, "~/.ssh/id_ed25519", params.SigningKey)
+	assert.Equal(t, "ssh", params.SigningFormat)
+	assert.Equal(t, "Release {{ .Version }}", params.TagMessageTemplate)
+}
+
+func TestLoadParams_InvalidSigningFormat(t *testing.T) {
+	os.Setenv("INPUT_SIGNING_FORMAT", "invalid")
+	defer os.Unsetenv("INPUT_SIGNING_FORMAT")
+
+	_, err := generate.LoadParams()
+	require.Error(t, err)
+}
+
+func TestLoadParams_InvalidTagMessage(t *testing.T) {
+	os.Setenv("INPUT_TAG_MESSAGE", "{{ .Version")
+	defer os.Unsetenv("INPUT_TAG_MESSAGE")
+
+	_, err := generate.LoadParams()
+	require.Error(t, err)
+}
+
+func TestLoadParams_GitLabMergeRequest(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("GITLAB_CI", "true")
+	t.Setenv("CI_COMMIT_SHA", "2f08f7b455ec64741d135216d19d7e0c4dd46458")
+	t.Setenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME", "feature/some")
+	t.Setenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME", "develop")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "2f08f7b455ec64741d135216d19d7e0c4dd46458", params.CommitSha)
+	assert.Equal(t, "feature/some", params.SourceBranch)
+	assert.Equal(t, "develop", params.DestBranch)
+	assert.Equal(t, "gitlab environment", params.DestBranchSource)
+}
+
+func TestLoadParams_GitHubPush(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_SHA", "2f08f7b455ec64741d135216d19d7e0c4dd46458")
+	t.Setenv("GITHUB_REF_TYPE", "branch")
+	t.Setenv("GITHUB_REF_NAME", "develop")
+	t.Setenv("GITHUB_BASE_REF", "")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "develop", params.DestBranch)
+	assert.Equal(t, "github environment", params.DestBranchSource)
+}
+
+func TestLoadParams_GitHubPullRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"number": 482, "pull_request": {"number": 482}}`), 0600))
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_SHA", "2f08f7b455ec64741d135216d19d7e0c4dd46458")
+	t.Setenv("GITHUB_EVENT_PATH", path)
+	t.Setenv("GITHUB_HEAD_REF", "feature/some")
+	t.Setenv("GITHUB_BASE_REF", "develop")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(482), params.PullRequest)
+	assert.Equal(t, "feature/some", params.SourceBranch)
+	assert.Equal(t, "develop", params.DestBranch)
+}
+
+func TestLoadParams_CircleCIPullRequest(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("CIRCLECI", "true")
+	t.Setenv("CIRCLE_SHA1", "2f08f7b455ec64741d135216d19d7e0c4dd46458")
+	t.Setenv("CIRCLE_BRANCH", "feature/some")
+	t.Setenv("CIRCLE_PULL_REQUEST", "https://github.com/wakatime/semver-action/pull/482")
+	t.Setenv("INPUT_DEVELOP_BRANCH_NAME", "dev")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(482), params.PullRequest)
+	assert.Equal(t, "feature/some", params.SourceBranch)
+	assert.Equal(t, "dev", params.DestBranch)
+}
+
+func TestLoadParams_PullRequest(t *testing.T) {
+	t.Setenv("INPUT_PULL_REQUEST", "12")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(12), params.PullRequest)
+}
+
+func TestLoadParams_InvalidPullRequest(t *testing.T) {
+	t.Setenv("INPUT_PULL_REQUEST", "abc")
+
+	_, err := generate.LoadParams()
+	require.EqualError(t, err, "invalid pull_request argument: abc")
+}
+
+func TestLoadParams_DestBranchOverridesCI(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("GITLAB_CI", "true")
+	t.Setenv("CI_COMMIT_BRANCH", "develop")
+	t.Setenv("INPUT_DEST_BRANCH", "master")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "master", params.DestBranch)
+	assert.Equal(t, "dest_branch input", params.DestBranchSource)
+}
+
+func TestLoadParams_OutputFormats(t *testing.T) {
+	t.Setenv("INPUT_OUTPUT_FORMATS", "dotenv=.env,github_env")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, []output.Format{
+		{Name: "dotenv", Path: ".env"},
+		{Name: "github_env"},
+	}, params.OutputFormats)
+}
+
+func TestLoadParams_InvalidOutputFormats(t *testing.T) {
+	t.Setenv("INPUT_OUTPUT_FORMATS", "xml")
+
+	_, err := generate.LoadParams()
+	require.Error(t, err)
+}
+
+func TestLoadParams_TagExists(t *testing.T) {
+	t.Setenv("INPUT_TAG_EXISTS", "bump")
+	t.Setenv("INPUT_CHECK_REMOTE_TAGS", "true")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "bump", params.TagExists)
+	assert.True(t, params.CheckRemoteTags)
+}
+
+func TestLoadParams_GlobalSafeDirectory(t *testing.T) {
+	t.Setenv("INPUT_GLOBAL_SAFE_DIRECTORY", "true")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.True(t, params.GlobalSafeDirectory)
+}
+
+func TestLoadParams_BranchSnapshots(t *testing.T) {
+	t.Setenv("INPUT_BRANCH_SNAPSHOTS", "true")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.True(t, params.BranchSnapshots)
+}
+
+func TestLoadParams_InvalidTagExists(t *testing.T) {
+	t.Setenv("INPUT_TAG_EXISTS", "overwrite")
+
+	_, err := generate.LoadParams()
+	require.Error(t, err)
+}
+
+func TestLoadParams_TagSelection(t *testing.T) {
+	t.Setenv("INPUT_TAG_SELECTION", "highest-reachable")
+
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "highest-reachable", params.TagSelection)
+}
+
+func TestLoadParams_InvalidTagSelection(t *testing.T) {
+	t.Setenv("INPUT_TAG_SELECTION", "newest")
+
+	_, err := generate.LoadParams()
+	require.Error(t, err)
+}
+
+func TestLoadParams_ShallowPolicy(t *testing.T) {
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "deepen", params.ShallowPolicy)
+
+	t.Setenv("INPUT_SHALLOW_POLICY", "fail")
+
+	params, err = generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "fail", params.ShallowPolicy)
+}
+
+func TestLoadParams_InvalidShallowPolicy(t *testing.T) {
+	t.Setenv("INPUT_SHALLOW_POLICY", "clone")
+
+	_, err := generate.LoadParams()
+	require.Error(t, err)
+}
+
+func TestLoadParams_GitBackend(t *testing.T) {
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "exec", params.GitBackend)
+
+	t.Setenv("INPUT_GIT_BACKEND", "native")
+
+	params, err = generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "native", params.GitBackend)
+
+	t.Setenv("INPUT_GIT_BACKEND", "snapshot")
+
+	params, err = generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, "snapshot", params.GitBackend)
+}
+
+func TestLoadParams_InvalidGitBackend(t *testing.T) {
+	t.Setenv("INPUT_GIT_BACKEND", "libgit2")
+
+	_, err := generate.LoadParams()
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "invalid git_backend value: libgit2")
+}
+
+func TestLoadParams_Timeouts(t *testing.T) {
+	params, err := generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, 5*time.Minute, params.GitTimeout)
+	assert.Equal(t, 15*time.Minute, params.Timeout)
+
+	t.Setenv("INPUT_GIT_TIMEOUT", "30s")
+	t.Setenv("INPUT_TIMEOUT", "0")
+
+	params, err = generate.LoadParams()
+	require.NoError(t, err)
+
+	assert.Equal(t, 30*time.Second, params.GitTimeout)
+	assert.Equal(t, time.Duration(0), params.Timeout)
+}
+
+func TestLoadParams_InvalidTimeout(t *testing.T) {
+	t.Setenv("INPUT_GIT_TIMEOUT", "5")
+
+	_, err := generate.LoadParams()
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "invalid git_timeout argument: 5")
+
+	t.Setenv("INPUT_GIT_TIMEOUT", "")
+	t.Setenv("INPUT_TIMEOUT", "-1m")
+
+	_, err = generate.LoadParams()
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "invalid timeout argument: -1m")
+}
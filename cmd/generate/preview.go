@@ -0,0 +1,46 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apex/log"
+)
+
+// previewCounter returns the counter of a pull request preview. It is the
+// number of commits of the pull request without merge commits, so it grows
+// with every push and every build of a commit gets the same preview.
+func previewCounter(ctx context.Context, params Params, gc queryClient, dest, rev, latestTag string) (uint64, error) {
+	base := previewBase(ctx, params, gc, dest, rev, latestTag)
+
+	count, err := gc.CountCommits(ctx, base, rev)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count commits of the pull request: %s", err)
+	}
+
+	log.Debugf("pull request has %d commits not on %q\n", count, base)
+
+	return uint64(count), nil
+}
+
+// previewBase returns the revision the commits of the pull request are counted
+// from. It is the dest branch when it is fetched, else the first parent of the
+// merge commit CI checks out for the pull request, which is the dest branch it
+// is merged into. Without either the commits since the latest tag are counted.
+func previewBase(ctx context.Context, params Params, gc queryClient, dest, rev, latestTag string) string {
+	for _, base := range []string{params.Remote + "/" + dest, dest} {
+		if _, err := gc.ResolveCommit(ctx, base); err == nil {
+			return base
+		}
+	}
+
+	if _, err := gc.ResolveCommit(ctx, rev+"^2"); err == nil {
+		log.Debugf("dest branch %q not found, counting commits from the first parent of merge commit %q\n", dest, rev)
+
+		return rev + "^1"
+	}
+
+	log.Warnf("dest branch %q of the pull request not found, counting commits since %q\n", dest, latestTag)
+
+	return latestTag
+}
@@ -0,0 +1,144 @@
+package generate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/wakatime/semver-action/cmd/generate"
+
+	"github.com/alecthomas/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTag_Preview(t *testing.T) {
+	gc := initGitClientMock(t, "v1.5.3", "v1.5.3", "HEAD", "feature/some", "81918ffc")
+	gc.CountCommitsFn = func(from, to string) (int, error) {
+		assert.Equal(t, "origin/develop", from)
+		assert.Equal(t, "81918ffc", to)
+
+		return 3, nil
+	}
+
+	result, err := generate.Tag(context.Background(), previewParams(), gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.6.0-pr.482.3", result.SemverTag)
+	assert.Equal(t, "1.6.0-pr.482.3", result.Version)
+	assert.Equal(t, "pr.482.3", result.Prerelease)
+	assert.Equal(t, "v1.5.3", result.PreviousTag)
+	assert.Equal(t, uint64(482), result.Decision.PullRequest)
+	assert.True(t, result.IsPrerelease)
+	assert.True(t, result.IsPreview)
+	assert.Equal(t, 0, gc.TagsAtFnInvoked)
+	assert.Equal(t, 0, gc.TagCommitFnInvoked)
+}
+
+func TestTag_PreviewLocalDestBranch(t *testing.T) {
+	gc := initGitClientMock(t, "v1.5.3", "v1.5.3", "HEAD", "feature/some", "81918ffc")
+	gc.ResolveCommitFn = func(rev string) (string, error) {
+		if rev == "origin/develop" {
+			return "", errors.New("unknown revision")
+		}
+
+		return rev, nil
+	}
+	gc.CountCommitsFn = func(from, to string) (int, error) {
+		assert.Equal(t, "develop", from)
+
+		return 1, nil
+	}
+
+	result, err := generate.Tag(context.Background(), previewParams(), gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.6.0-pr.482.1", result.SemverTag)
+}
+
+func TestTag_PreviewMergeCommit(t *testing.T) {
+	gc := initGitClientMock(t, "v1.5.3", "v1.5.3", "HEAD", "feature/some", "81918ffc")
+	gc.ResolveCommitFn = func(rev string) (string, error) {
+		if rev == "81918ffc" || rev == "81918ffc^2" {
+			return rev, nil
+		}
+
+		return "", errors.New("unknown revision")
+	}
+	gc.CountCommitsFn = func(from, to string) (int, error) {
+		assert.Equal(t, "81918ffc^1", from)
+		assert.Equal(t, "81918ffc", to)
+
+		return 4, nil
+	}
+
+	result, err := generate.Tag(context.Background(), previewParams(), gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.6.0-pr.482.4", result.SemverTag)
+}
+
+func TestTag_PreviewWithoutDestBranch(t *testing.T) {
+	gc := initGitClientMock(t, "v1.5.3", "v1.5.3", "HEAD", "feature/some", "81918ffc")
+	gc.ResolveCommitFn = func(rev string) (string, error) {
+		if rev == "81918ffc" {
+			return rev, nil
+		}
+
+		return "", errors.New("unknown revision")
+	}
+	gc.CountCommitsFn = func(from, to string) (int, error) {
+		assert.Equal(t, "v1.5.3", from)
+
+		return 7, nil
+	}
+
+	result, err := generate.Tag(context.Background(), previewParams(), gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.6.0-pr.482.7", result.SemverTag)
+}
+
+func TestTag_PreviewCountCommitsErr(t *testing.T) {
+	gc := initGitClientMock(t, "v1.5.3", "v1.5.3", "HEAD", "feature/some", "81918ffc")
+	gc.CountCommitsFn = func(from, to string) (int, error) {
+		return 0, errors.New("error")
+	}
+
+	_, err := generate.Tag(context.Background(), previewParams(), gc)
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "failed to count commits of the pull request: error")
+}
+
+func TestRelease_PreviewNotTagged(t *testing.T) {
+	gc := initGitClientMock(t, "v1.5.3", "v1.5.3", "HEAD", "feature/some", "81918ffc")
+	gc.CountCommitsFn = func(from, to string) (int, error) {
+		return 3, nil
+	}
+
+	params := previewParams()
+	params.CreateTag = true
+	params.PushTag = true
+
+	result, err := generate.Release(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.6.0-pr.482.3", result.SemverTag)
+	assert.Equal(t, 0, gc.CreateTagFnInvoked)
+	assert.Equal(t, 0, gc.PushTagFnInvoked)
+}
+
+func previewParams() generate.Params {
+	return generate.Params{
+		CommitSha:         "81918ffc",
+		Bump:              "auto",
+		Prefix:            "v",
+		PrereleaseID:      "pre",
+		MainBranchName:    "master",
+		DevelopBranchName: "develop",
+		SourceBranch:      "feature/some",
+		DestBranch:        "develop",
+		PullRequest:       482,
+		Remote:            "origin",
+	}
+}
@@ -0,0 +1,135 @@
+package generate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/wakatime/semver-action/pkg/git"
+
+	"github.com/apex/log"
+)
+
+// Release calculates the semantic version and, if enabled, creates the tag and
+// pushes it to the remote. When the push is rejected because a concurrent run
+// already took the version, the tag is recalculated with the remote tags.
+// Pull request previews are never tagged.
+func Release(ctx context.Context, params Params, gc gitClient) (Result, error) {
+	for attempt := 0; ; attempt++ {
+		result, err := Tag(ctx, params, gc)
+		if err != nil {
+			return Result{}, err
+		}
+
+		if !params.CreateTag {
+			return result, nil
+		}
+
+		if result.IsPreview {
+			log.Infof("%s is a pull request preview, skipping tag creation\n", result.SemverTag)
+
+			return result, nil
+		}
+
+		if result.AlreadyTagged {
+			log.Infof("tag %q already points at the commit, skipping tag creation\n", result.SemverTag)
+
+			return result, nil
+		}
+
+		opts := git.TagOptions{
+			Name:          result.SemverTag,
+			Ref:           params.CommitSha,
+			Sign:          params.SignTag,
+			SigningKey:    params.SigningKey,
+			SigningFormat: params.SigningFormat,
+		}
+
+		if params.AnnotateTag || params.SignTag {
+			opts.Message, err = RenderTagMessage(params.TagMessageTemplate, params, result)
+			if err != nil {
+				return Result{}, err
+			}
+		}
+
+		if err := gc.CreateTag(ctx, opts); err != nil {
+			return Result{}, fmt.Errorf("failed to create tag: %s", err)
+		}
+
+		log.Debugf("created tag %q\n", result.SemverTag)
+
+		if !params.PushTag {
+			return result, nil
+		}
+
+		err = gc.PushTag(ctx, params.Remote, result.SemverTag)
+		if err == nil {
+			log.Debugf("pushed tag %q to %q\n", result.SemverTag, params.Remote)
+
+			return result, nil
+		}
+
+		if !errors.Is(err, git.ErrPushRejected) || attempt >= params.PushRetries {
+			return Result{}, fmt.Errorf("failed to push tag: %s", err)
+		}
+
+		log.Warnf("tag %q already exists on %q, recalculating\n", result.SemverTag, params.Remote)
+
+		if err := gc.DeleteTag(ctx, result.SemverTag); err != nil {
+			return Result{}, fmt.Errorf("failed to delete rejected tag: %s", err)
+		}
+
+		if err := gc.FetchTags(ctx, params.Remote); err != nil {
+			return Result{}, fmt.Errorf("failed to fetch tags: %s", err)
+		}
+	}
+}
+
+// TagMessageData contains the fields available to the tag message template.
+type TagMessageData struct {
+	Tag         string
+	Version     string
+	PreviousTag string
+	AncestorTag string
+	BumpReason  string
+	Changelog   string
+	Range       string
+}
+
+// RenderTagMessage renders the tag message template with the calculated result.
+func RenderTagMessage(tmpl string, params Params, result Result) (string, error) {
+	if tmpl == "" {
+		tmpl = tagMessageTemplateDefault
+	}
+
+	t, err := template.New("tag_message").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse tag message template: %s", err)
+	}
+
+	to := params.CommitSha
+	if to == "" {
+		to = "HEAD"
+	}
+
+	data := TagMessageData{
+		Tag:         result.SemverTag,
+		Version:     strings.TrimPrefix(result.SemverTag, params.Prefix),
+		PreviousTag: result.PreviousTag,
+		AncestorTag: result.AncestorTag,
+		BumpReason:  result.BumpReason,
+		Changelog:   result.Changelog,
+		Range:       result.AncestorTag + ".." + to,
+	}
+
+	var buf bytes.Buffer
+
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render tag message template: %s", err)
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,222 @@
+package generate_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/wakatime/semver-action/cmd/generate"
+	"github.com/wakatime/semver-action/pkg/git"
+
+	"github.com/alecthomas/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelease_NoCreateTag(t *testing.T) {
+	gc := initGitClientMock(t, "v1.4.17-alpha.1", "", "master", "develop", "81918ffc")
+
+	result, err := generate.Release(context.Background(), releaseParams(), gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.4.17", result.SemverTag)
+	assert.Equal(t, 0, gc.CreateTagFnInvoked)
+}
+
+func TestRelease_CreateTag(t *testing.T) {
+	gc := initGitClientMock(t, "v1.4.17-alpha.1", "", "master", "develop", "81918ffc")
+	gc.CreateTagFn = func(opts git.TagOptions) error {
+		assert.Equal(t, git.TagOptions{
+			Name:    "v1.4.17",
+			Ref:     "81918ffc",
+			Message: "Release v1.4.17",
+		}, opts)
+
+		return nil
+	}
+
+	params := releaseParams()
+	params.CreateTag = true
+	params.AnnotateTag = true
+
+	result, err := generate.Release(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.4.17", result.SemverTag)
+	assert.Equal(t, 1, gc.CreateTagFnInvoked)
+	assert.Equal(t, 0, gc.PushTagFnInvoked)
+}
+
+func TestRelease_SignedTag(t *testing.T) {
+	gc := initGitClientMock(t, "v1.4.17-alpha.1", "v1.4.16", "master", "develop", "81918ffc")
+	gc.CreateTagFn = func(opts git.TagOptions) error {
+		assert.Equal(t, git.TagOptions{
+			Name:          "v1.4.17",
+			Ref:           "81918ffc",
+			Message:       "1.4.17 after v1.4.17-alpha.1 (develop into master: final)",
+			Sign:          true,
+			SigningKey:    "ABCDEF",
+			SigningFormat: "openpgp",
+		}, opts)
+
+		return nil
+	}
+
+	params := releaseParams()
+	params.CreateTag = true
+	params.TagMessageTemplate = "{{ .Version }} after {{ .PreviousTag }} ({{ .BumpReason }})"
+	params.SignTag = true
+	params.SigningKey = "ABCDEF"
+	params.SigningFormat = "openpgp"
+
+	_, err := generate.Release(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, gc.CreateTagFnInvoked)
+}
+
+func TestRelease_PushTagRetry(t *testing.T) {
+	latestTag := "v1.4.17-alpha.1"
+
+	gc := initGitClientMock(t, "", "", "develop", "some-branch", "81918ffc")
+	gc.LatestTagFn = func() string {
+		return latestTag
+	}
+	gc.CreateTagFn = func(opts git.TagOptions) error {
+		assert.Empty(t, opts.Message)
+		return nil
+	}
+	gc.PushTagFn = func(remote, name string) error {
+		assert.Equal(t, "origin", remote)
+
+		if name == "v1.4.17-alpha.2" {
+			return fmt.Errorf("rejected: %w", git.ErrPushRejected)
+		}
+
+		return nil
+	}
+	gc.DeleteTagFn = func(name string) error {
+		assert.Equal(t, "v1.4.17-alpha.2", name)
+		return nil
+	}
+	gc.FetchTagsFn = func(remote string) error {
+		assert.Equal(t, "origin", remote)
+
+		latestTag = "v1.4.17-alpha.2"
+
+		return nil
+	}
+
+	params := releaseParams()
+	params.CreateTag = true
+	params.PushTag = true
+
+	result, err := generate.Release(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.4.17-alpha.3", result.SemverTag)
+	assert.Equal(t, 2, gc.CreateTagFnInvoked)
+	assert.Equal(t, 2, gc.PushTagFnInvoked)
+	assert.Equal(t, 1, gc.DeleteTagFnInvoked)
+	assert.Equal(t, 1, gc.FetchTagsFnInvoked)
+}
+
+func TestRelease_PushTagRetriesExhausted(t *testing.T) {
+	gc := initGitClientMock(t, "v1.4.17-alpha.1", "", "master", "develop", "81918ffc")
+	gc.CreateTagFn = func(opts git.TagOptions) error {
+		return nil
+	}
+	gc.PushTagFn = func(remote, name string) error {
+		return git.ErrPushRejected
+	}
+	gc.DeleteTagFn = func(name string) error {
+		return nil
+	}
+	gc.FetchTagsFn = func(remote string) error {
+		return nil
+	}
+
+	params := releaseParams()
+	params.CreateTag = true
+	params.PushTag = true
+	params.PushRetries = 2
+
+	_, err := generate.Release(context.Background(), params, gc)
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "failed to push tag: push rejected by remote")
+	assert.Equal(t, 3, gc.PushTagFnInvoked)
+}
+
+func TestRelease_PushTagErr(t *testing.T) {
+	gc := initGitClientMock(t, "v1.4.17-alpha.1", "", "master", "develop", "81918ffc")
+	gc.CreateTagFn = func(opts git.TagOptions) error {
+		return nil
+	}
+	gc.PushTagFn = func(remote, name string) error {
+		return errors.New("error")
+	}
+
+	params := releaseParams()
+	params.CreateTag = true
+	params.PushTag = true
+
+	_, err := generate.Release(context.Background(), params, gc)
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "failed to push tag: error")
+	assert.Equal(t, 1, gc.PushTagFnInvoked)
+}
+
+func releaseParams() generate.Params {
+	return generate.Params{
+		CommitSha:         "81918ffc",
+		Bump:              "auto",
+		Prefix:            "v",
+		PrereleaseID:      "alpha",
+		MainBranchName:    "master",
+		DevelopBranchName: "develop",
+		Remote:            "origin",
+		PushRetries:       3,
+	}
+}
+
+func TestRenderTagMessage(t *testing.T) {
+	tests := map[string]struct {
+		Template string
+		Expected string
+	}{
+		"default": {
+			Expected: "Release v1.5.0",
+		},
+		"all fields": {
+			Template: "{{ .Tag }} {{ .Version }} {{ .PreviousTag }} {{ .AncestorTag }} {{ .Range }}\n" +
+				"{{ .BumpReason }}\n\n{{ .Changelog }}",
+			Expected: "v1.5.0 1.5.0 v1.5.0-pre.2 v1.4.0 v1.4.0..81918ffc\n" +
+				"develop into master: final\n\n### Features\n\n- Add login",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			message, err := generate.RenderTagMessage(test.Template, generate.Params{
+				CommitSha: "81918ffc",
+				Prefix:    "v",
+			}, generate.Result{
+				PreviousTag: "v1.5.0-pre.2",
+				AncestorTag: "v1.4.0",
+				SemverTag:   "v1.5.0",
+				BumpReason:  "develop into master: final",
+				Changelog:   "### Features\n\n- Add login\n",
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Expected, message)
+		})
+	}
+}
+
+func TestRenderTagMessage_Err(t *testing.T) {
+	_, err := generate.RenderTagMessage("{{ .Unknown }}", generate.Params{}, generate.Result{})
+	require.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wakatime/semver-action/pkg/output"
+
+	"github.com/blang/semver/v4"
+)
+
+// setVersionComponents fills the version component fields from the semver tag.
+func (r *Result) setVersionComponents(prefix string) error {
+	version, err := semver.Parse(strings.TrimPrefix(r.SemverTag, prefix))
+	if err != nil {
+		return &InvalidTagError{
+			Tag: r.SemverTag,
+			Err: fmt.Errorf("failed to parse tag %q or not valid semantic version: %s", r.SemverTag, err),
+		}
+	}
+
+	r.Version = version.String()
+	r.Major = version.Major
+	r.Minor = version.Minor
+	r.Patch = version.Patch
+	r.Prerelease = ""
+	r.PrereleaseNumber = ""
+
+	if len(version.Pre) > 0 {
+		pre := make([]string, len(version.Pre))
+		for i, p := range version.Pre {
+			pre[i] = p.String()
+
+			if p.IsNum {
+				r.PrereleaseNumber = p.String()
+			}
+		}
+
+		r.Prerelease = strings.Join(pre, ".")
+	}
+
+	r.BuildMetadata = strings.Join(version.Build, ".")
+
+	previous, err := semver.ParseTolerant(strings.TrimPrefix(r.PreviousTag, prefix))
+	r.IsNewMajor = err == nil && version.Major > previous.Major
+
+	return nil
+}
+
+// JSON returns the result encoded as json.
+func (r Result) JSON() (string, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %s", err)
+	}
+
+	return string(data), nil
+}
+
+// Outputs returns the result as a list of named outputs.
+func (r Result) Outputs() ([]output.Value, error) {
+	reason, err := r.Decision.JSON()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := r.JSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return []output.Value{
+		{Name: "previous_tag", Value: r.PreviousTag},
+		{Name: "ancestor_tag", Value: r.AncestorTag},
+		{Name: "semver_tag", Value: r.SemverTag},
+		{Name: "is_prerelease", Value: strconv.FormatBool(r.IsPrerelease)},
+		{Name: "is_preview", Value: strconv.FormatBool(r.IsPreview)},
+		{Name: "version", Value: r.Version},
+		{Name: "major", Value: strconv.FormatUint(r.Major, 10)},
+		{Name: "minor", Value: strconv.FormatUint(r.Minor, 10)},
+		{Name: "patch", Value: strconv.FormatUint(r.Patch, 10)},
+		{Name: "prerelease", Value: r.Prerelease},
+		{Name: "prerelease_number", Value: r.PrereleaseNumber},
+		{Name: "build_metadata", Value: r.BuildMetadata},
+		{Name: "bump_type", Value: r.BumpType},
+		{Name: "source_branch", Value: r.SourceBranch},
+		{Name: "dest_branch", Value: r.DestBranch},
+		{Name: "is_new_major", Value: strconv.FormatBool(r.IsNewMajor)},
+		{Name: "already_tagged", Value: strconv.FormatBool(r.AlreadyTagged)},
+		{Name: "reason", Value: reason},
+		{Name: "changelog", Value: r.Changelog},
+		{Name: "json", Value: data},
+	}, nil
+}
@@ -0,0 +1,76 @@
+package generate_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/wakatime/semver-action/cmd/generate"
+
+	"github.com/alecthomas/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResult_Outputs(t *testing.T) {
+	result := generate.Result{
+		PreviousTag:      "v1.5.3-pre.2",
+		AncestorTag:      "v1.5.3-pre.2",
+		SemverTag:        "v1.6.0-pre.1",
+		IsPrerelease:     true,
+		Version:          "1.6.0-pre.1",
+		Major:            1,
+		Minor:            6,
+		Prerelease:       "pre.1",
+		PrereleaseNumber: "1",
+		BumpType:         "minor",
+		SourceBranch:     "feature/login",
+		DestBranch:       "develop",
+		Changelog:        "### Features\n\n- Add login\n",
+	}
+
+	outputs, err := result.Outputs()
+	require.NoError(t, err)
+
+	values := map[string]string{}
+	for _, output := range outputs {
+		values[output.Name] = output.Value
+	}
+
+	assert.Equal(t, "v1.6.0-pre.1", values["semver_tag"])
+	assert.Equal(t, "true", values["is_prerelease"])
+	assert.Equal(t, "false", values["is_preview"])
+	assert.Equal(t, "1.6.0-pre.1", values["version"])
+	assert.Equal(t, "1", values["major"])
+	assert.Equal(t, "6", values["minor"])
+	assert.Equal(t, "0", values["patch"])
+	assert.Equal(t, "pre.1", values["prerelease"])
+	assert.Equal(t, "1", values["prerelease_number"])
+	assert.Equal(t, "", values["build_metadata"])
+	assert.Equal(t, "minor", values["bump_type"])
+	assert.Equal(t, "feature/login", values["source_branch"])
+	assert.Equal(t, "develop", values["dest_branch"])
+	assert.Equal(t, "false", values["is_new_major"])
+	assert.Equal(t, "false", values["already_tagged"])
+	assert.Equal(t, "### Features\n\n- Add login\n", values["changelog"])
+
+	var decoded generate.Result
+
+	require.NoError(t, json.Unmarshal([]byte(values["json"]), &decoded))
+
+	assert.Equal(t, result, decoded)
+}
+
+func TestResult_JSON(t *testing.T) {
+	data, err := generate.Result{SemverTag: "v1.0.0", Version: "1.0.0", Major: 1}.JSON()
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+
+	require.NoError(t, json.Unmarshal([]byte(data), &decoded))
+
+	assert.Equal(t, "v1.0.0", decoded["semver_tag"])
+	assert.Equal(t, "1.0.0", decoded["version"])
+	assert.Equal(t, float64(1), decoded["major"])
+
+	_, ok := decoded["reason"]
+	assert.True(t, ok)
+}
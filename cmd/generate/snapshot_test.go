@@ -0,0 +1,151 @@
+package generate_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wakatime/semver-action/cmd/generate"
+
+	"github.com/alecthomas/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTag_BranchSnapshot(t *testing.T) {
+	gc := initGitClientMock(t, "v1.5.3", "v1.5.3", "feature/Login_OAuth", "", "81918ffc")
+	gc.TagsFn = func(reachableFrom string) ([]string, error) {
+		assert.Empty(t, reachableFrom)
+
+		return []string{
+			"v1.5.3",
+			"v1.6.0-feature-login-oauth.3",
+			"v1.6.0-feature-signup.7",
+		}, nil
+	}
+	gc.AncestorTagFn = func(include, exclude, branch string) string {
+		assert.Equal(t, "81918ffc", branch)
+
+		return "v1.6.0-pre.1"
+	}
+
+	result, err := generate.Tag(context.Background(), snapshotParams(), gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.6.0-feature-login-oauth.4", result.SemverTag)
+	assert.Equal(t, "feature-login-oauth.4", result.Prerelease)
+	assert.Equal(t, "v1.5.3", result.PreviousTag)
+	assert.Equal(t, "feature/Login_OAuth", result.SourceBranch)
+	assert.Equal(t, "develop", result.DestBranch)
+	assert.Equal(t, "v1.6.0-pre.1", result.AncestorTag)
+	assert.Equal(t, "feature/Login_OAuth", result.Decision.SnapshotBranch)
+	assert.True(t, result.IsPrerelease)
+	assert.Equal(t, 0, gc.SourceBranchFnInvoked)
+}
+
+func TestTag_BranchSnapshotDocBranch(t *testing.T) {
+	gc := initGitClientMock(t, "v1.5.3", "v1.5.3", "doc/readme", "", "81918ffc")
+	gc.TagsFn = func(reachableFrom string) ([]string, error) {
+		return nil, nil
+	}
+	gc.AncestorTagFn = func(include, exclude, branch string) string {
+		assert.Equal(t, "81918ffc", branch)
+
+		return "v1.6.0-pre.1"
+	}
+
+	result, err := generate.Tag(context.Background(), snapshotParams(), gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.5.3-doc-readme.1", result.SemverTag)
+	assert.Equal(t, "snapshot of a branch not merged yet", result.Decision.Substitution.Reason)
+	assert.Equal(t, 1, gc.AncestorTagFnInvoked)
+}
+
+func TestTag_BranchSnapshotAlreadyTagged(t *testing.T) {
+	gc := initGitClientMock(t, "v1.5.3", "v1.5.3", "feature/login", "", "81918ffc")
+	gc.TagsAtFn = func(rev string) ([]string, error) {
+		return []string{"v1.6.0-pre.1", "v1.6.0-feature-login.2"}, nil
+	}
+
+	result, err := generate.Tag(context.Background(), snapshotParams(), gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.6.0-feature-login.2", result.SemverTag)
+	assert.True(t, result.AlreadyTagged)
+	assert.Equal(t, "feature/login", result.Decision.SnapshotBranch)
+}
+
+func TestTag_BranchSnapshotMainAndDevelop(t *testing.T) {
+	tests := map[string]struct {
+		CurrentBranch string
+		SourceBranch  string
+		LatestTag     string
+		Expected      string
+	}{
+		"develop": {
+			CurrentBranch: "develop",
+			SourceBranch:  "feature/login",
+			LatestTag:     "v1.5.3",
+			Expected:      "v1.6.0-pre.1",
+		},
+		"main": {
+			CurrentBranch: "master",
+			SourceBranch:  "develop",
+			LatestTag:     "v1.6.0-pre.2",
+			Expected:      "v1.6.0",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gc := initGitClientMock(t, test.LatestTag, test.LatestTag, test.CurrentBranch, test.SourceBranch, "81918ffc")
+
+			result, err := generate.Tag(context.Background(), snapshotParams(), gc)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Expected, result.SemverTag)
+			assert.Empty(t, result.Decision.SnapshotBranch)
+		})
+	}
+}
+
+func TestTag_BranchSnapshotIgnoredAsLatestTag(t *testing.T) {
+	gc := initGitClientMock(t, "v1.6.0-feature-signup.2", "v1.6.0-pre.1", "develop", "feature/login", "81918ffc")
+	gc.TagsFn = func(reachableFrom string) ([]string, error) {
+		assert.Equal(t, "81918ffc", reachableFrom)
+
+		return []string{"v1.5.3", "v1.6.0-pre.1", "v1.6.0-feature-signup.2", "v1.7.0-feature-other.1"}, nil
+	}
+
+	result, err := generate.Tag(context.Background(), snapshotParams(), gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.6.0-pre.1", result.PreviousTag)
+	assert.Equal(t, "v1.7.0-pre.1", result.SemverTag)
+}
+
+func TestTag_BranchSnapshotDisabled(t *testing.T) {
+	gc := initGitClientMock(t, "v1.6.0-feature-signup.2", "v1.6.0-pre.1", "feature/login", "feature/login", "81918ffc")
+
+	params := snapshotParams()
+	params.BranchSnapshots = false
+
+	result, err := generate.Tag(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.6.0-feature-signup.2", result.PreviousTag)
+	assert.Empty(t, result.Decision.SnapshotBranch)
+	assert.Equal(t, 1, gc.SourceBranchFnInvoked)
+}
+
+func snapshotParams() generate.Params {
+	return generate.Params{
+		CommitSha:         "81918ffc",
+		Bump:              "auto",
+		Prefix:            "v",
+		PrereleaseID:      "pre",
+		MainBranchName:    "master",
+		DevelopBranchName: "develop",
+		Remote:            "origin",
+		BranchSnapshots:   true,
+	}
+}
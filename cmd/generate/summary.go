@@ -0,0 +1,42 @@
+package generate
+
+import (
+	"github.com/wakatime/semver-action/pkg/actions"
+)
+
+// Summary returns a markdown report of the result for the job summary.
+func (r Result) Summary() string {
+	s := actions.NewSummary().
+		AddHeading("Semantic Version "+r.SemverTag, 2).
+		AddTable(
+			[]string{"Previous tag", "New tag", "Bump type", "Bump reason", "Ancestor tag"},
+			[][]string{{inlineCode(r.PreviousTag), inlineCode(r.SemverTag), r.BumpType, r.BumpReason, inlineCode(r.AncestorTag)}},
+		).
+		AddHeading("Changelog", 3)
+
+	if len(r.ChangelogEntries) == 0 {
+		return s.AddParagraph("No changes.").String()
+	}
+
+	rows := make([][]string, 0, len(r.ChangelogEntries))
+
+	for _, e := range r.ChangelogEntries {
+		var pr string
+		if e.PRNumber != "" {
+			pr = "#" + e.PRNumber
+		}
+
+		rows = append(rows, []string{e.Category, e.Title, pr, e.Author, inlineCode(e.ShortHash)})
+	}
+
+	return s.AddTable([]string{"Category", "Change", "Pull request", "Author", "Commit"}, rows).String()
+}
+
+// inlineCode formats a value as inline code.
+func inlineCode(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return "`" + value + "`"
+}
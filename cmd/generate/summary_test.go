@@ -0,0 +1,39 @@
+package generate_test
+
+import (
+	"testing"
+
+	"github.com/wakatime/semver-action/cmd/generate"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestResult_Summary(t *testing.T) {
+	result := generate.Result{
+		PreviousTag: "v1.0.0",
+		AncestorTag: "v1.0.0",
+		SemverTag:   "v1.1.0",
+		BumpType:    "minor",
+		BumpReason:  "bump forced to minor",
+		ChangelogEntries: []generate.ChangelogEntry{
+			{Category: "Features", Title: "Add a | b", PRNumber: "12", Author: "John Doe", ShortHash: "e63c125"},
+			{Category: "Other", Title: "Update readme", Author: "Jane Doe", ShortHash: "a1b2c3d"},
+		},
+	}
+
+	assert.Equal(t, "## Semantic Version v1.1.0\n\n"+
+		"| Previous tag | New tag | Bump type | Bump reason | Ancestor tag |\n"+
+		"| --- | --- | --- | --- | --- |\n"+
+		"| `v1.0.0` | `v1.1.0` | minor | bump forced to minor | `v1.0.0` |\n\n"+
+		"### Changelog\n\n"+
+		"| Category | Change | Pull request | Author | Commit |\n"+
+		"| --- | --- | --- | --- | --- |\n"+
+		"| Features | Add a \\| b | #12 | John Doe | `e63c125` |\n"+
+		"| Other | Update readme |  | Jane Doe | `a1b2c3d` |\n\n", result.Summary())
+}
+
+func TestResult_SummaryNoChanges(t *testing.T) {
+	result := generate.Result{PreviousTag: "v1.0.0", SemverTag: "v1.0.1"}
+
+	assert.Contains(t, result.Summary(), "### Changelog\n\nNo changes.\n\n")
+}
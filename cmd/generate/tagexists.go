@@ -0,0 +1,228 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blang/semver/v4"
+)
+
+// Policies applied when the calculated tag already exists.
+const (
+	tagExistsFail  = "fail"
+	tagExistsBump  = "bump"
+	tagExistsReuse = "reuse"
+)
+
+// ruleAlreadyTagged is the decision rule used when the commit already carries a tag.
+const ruleAlreadyTagged = "already-tagged"
+
+// highestTagAt returns the highest semantic version tag with the prefix pointing
+// at the revision or an empty string if there is none. Only prerelease tags with
+// the prerelease id are considered when it is set, otherwise only final
+// versions, so a prerelease tag is not returned for a release into main.
+func highestTagAt(ctx context.Context, params Params, gc queryClient, rev, prereleaseID string) (string, error) {
+	tags, err := gc.TagsAt(ctx, rev)
+	if err != nil {
+		return "", err
+	}
+
+	var candidates []string
+
+	for _, tag := range tags {
+		version, err := semver.Parse(strings.TrimPrefix(tag, params.Prefix))
+		if err != nil {
+			continue
+		}
+
+		if prereleaseID != "" {
+			if len(version.Pre) == 0 || version.Pre[0].VersionStr != prereleaseID {
+				continue
+			}
+		} else if len(version.Pre) > 0 {
+			continue
+		}
+
+		candidates = append(candidates, tag)
+	}
+
+	return highestTag(candidates, params.Prefix), nil
+}
+
+// alreadyTagged returns the result for a commit that already carries the tag.
+// The previous tag and changelog are taken from the tags before the commit.
+func alreadyTagged(ctx context.Context, params Params, gc queryClient, tag, source, dest, rev string) (Result, error) {
+	includePattern := fmt.Sprintf("%s[0-9]*", params.Prefix)
+	ancestorTag, err := gc.AncestorTag(ctx, includePattern, "", rev+"^")
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get ancestor tag: %s", err)
+	}
+
+	previousTag := ancestorTag
+	if rootCommitRegex.MatchString(ancestorTag) {
+		previousTag = ""
+	}
+
+	decision := Decision{
+		SourceBranch:      source,
+		DestBranch:        dest,
+		Bump:              params.Bump,
+		Rule:              ruleAlreadyTagged,
+		Method:            "existing",
+		PreviousTag:       previousTag,
+		PreviousTagSource: "git",
+		AncestorPatterns: []AncestorPattern{{
+			Purpose: "ancestor tag",
+			Include: includePattern,
+			Branch:  rev + "^",
+			Result:  ancestorTag,
+		}},
+		Substitution: Substitution{Reason: "commit is already tagged"},
+		TagExists:    fmt.Sprintf("commit is already tagged with %s", tag),
+	}
+
+	commits, err := gc.Commits(ctx, ancestorTag, rev)
+	if err != nil {
+		log.Warnf("failed to get commits for changelog, leaving it empty: %s\n", err)
+	}
+
+	changelog := NewChangelog(commits)
+
+	result := Result{
+		PreviousTag:   previousTag,
+		AncestorTag:   ancestorTag,
+		SemverTag:     tag,
+		SourceBranch:  source,
+		DestBranch:    dest,
+		BumpReason:    decision.Reason(),
+		Changelog:     changelog.Markdown(),
+		Decision:      decision,
+		AlreadyTagged: true,
+
+		ChangelogEntries: changelog.Entries,
+	}
+
+	if err := result.setVersionComponents(params.Prefix); err != nil {
+		return Result{}, err
+	}
+
+	result.IsPrerelease = result.Prerelease != ""
+
+	return result, nil
+}
+
+// resolveExistingTag checks whether the calculated tag is already taken and
+// applies the tag exists policy. With bump the last numeric prerelease
+// identifier is incremented until the tag is free, with reuse the tag is
+// returned as is when it already points at the commit.
+func resolveExistingTag(ctx context.Context, params Params, gc queryClient, result *Result) error {
+	existing, err := tagCommit(ctx, params, gc, result.SemverTag)
+	if err != nil {
+		return err
+	}
+
+	if existing == "" {
+		return nil
+	}
+
+	rev := params.CommitSha
+	if rev == "" {
+		rev = "HEAD"
+	}
+
+	commit, err := gc.ResolveCommit(ctx, rev)
+	if err != nil {
+		return fmt.Errorf("failed to resolve commit: %s", err)
+	}
+
+	switch params.TagExists {
+	case tagExistsReuse:
+		if existing != commit {
+			return fmt.Errorf("%w: %s on commit %s", ErrTagExists, result.SemverTag, existing)
+		}
+
+		result.AlreadyTagged = true
+		result.Decision.TagExists = fmt.Sprintf("%s already points at %s, reused", result.SemverTag, commit)
+	case tagExistsBump:
+		tag, err := nextFreePrerelease(ctx, params, gc, result.SemverTag)
+		if err != nil {
+			return err
+		}
+
+		result.Decision.TagExists = fmt.Sprintf("%s already exists on %s, bumped to %s", result.SemverTag, existing, tag)
+		result.SemverTag = tag
+	default:
+		return fmt.Errorf("%w: %s on commit %s", ErrTagExists, result.SemverTag, existing)
+	}
+
+	result.BumpReason = result.Decision.Reason()
+
+	log.Warnf("tag exists: %s\n", result.Decision.TagExists)
+
+	return nil
+}
+
+// nextFreePrerelease increments the prerelease counter of the tag until no tag
+// with that name exists.
+func nextFreePrerelease(ctx context.Context, params Params, gc queryClient, tag string) (string, error) {
+	version, err := semver.Parse(strings.TrimPrefix(tag, params.Prefix))
+	if err != nil {
+		return "", &InvalidTagError{
+			Tag: tag,
+			Err: fmt.Errorf("failed to parse tag %q or not valid semantic version: %s", tag, err),
+		}
+	}
+
+	counter := -1
+
+	for i, pre := range version.Pre {
+		if pre.IsNum {
+			counter = i
+		}
+	}
+
+	if counter == -1 {
+		return "", fmt.Errorf("%w: %s has no prerelease counter to bump", ErrTagExists, tag)
+	}
+
+	for {
+		version.Pre[counter].VersionNum++
+
+		next := params.Prefix + version.String()
+
+		existing, err := tagCommit(ctx, params, gc, next)
+		if err != nil {
+			return "", err
+		}
+
+		if existing == "" {
+			return next, nil
+		}
+	}
+}
+
+// tagCommit returns the commit the tag points at locally or, if enabled, on the
+// remote. An empty string is returned when the tag does not exist.
+func tagCommit(ctx context.Context, params Params, gc queryClient, tag string) (string, error) {
+	commit, err := gc.TagCommit(ctx, tag)
+	if err != nil {
+		return "", fmt.Errorf("failed to check local tags: %s", err)
+	}
+
+	if commit != "" {
+		return commit, nil
+	}
+
+	if !params.CheckRemoteTags {
+		return "", nil
+	}
+
+	commit, err = gc.RemoteTagCommit(ctx, params.Remote, tag)
+	if err != nil {
+		return "", fmt.Errorf("failed to check remote tags: %s", err)
+	}
+
+	return commit, nil
+}
@@ -0,0 +1,260 @@
+package generate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/wakatime/semver-action/cmd/generate"
+	"github.com/wakatime/semver-action/pkg/git"
+
+	"github.com/alecthomas/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTag_TagExistsFail(t *testing.T) {
+	gc := initGitClientMock(t, "v1.2.3", "v1.2.3", "develop", "feature/some", "81918ffc")
+	gc.TagCommitFn = func(name string) string {
+		if name == "v1.3.0-pre.1" {
+			return "2f08f7b455ec64741d135216d19d7e0c4dd46458"
+		}
+
+		return ""
+	}
+
+	_, err := generate.Tag(context.Background(), tagExistsParams("fail"), gc)
+	require.Error(t, err)
+
+	assert.True(t, errors.Is(err, generate.ErrTagExists))
+	assert.EqualError(t, err, "tag already exists: v1.3.0-pre.1 on commit 2f08f7b455ec64741d135216d19d7e0c4dd46458")
+}
+
+func TestTag_TagExistsBump(t *testing.T) {
+	gc := initGitClientMock(t, "v1.2.3", "v1.2.3", "develop", "feature/some", "81918ffc")
+	gc.TagCommitFn = func(name string) string {
+		switch name {
+		case "v1.3.0-pre.1", "v1.3.0-pre.2":
+			return "2f08f7b455ec64741d135216d19d7e0c4dd46458"
+		default:
+			return ""
+		}
+	}
+
+	result, err := generate.Tag(context.Background(), tagExistsParams("bump"), gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.3.0-pre.3", result.SemverTag)
+	assert.Equal(t, "pre.3", result.Prerelease)
+	assert.Equal(t, "3", result.PrereleaseNumber)
+	assert.False(t, result.AlreadyTagged)
+	assert.Equal(t,
+		"v1.3.0-pre.1 already exists on 2f08f7b455ec64741d135216d19d7e0c4dd46458, bumped to v1.3.0-pre.3",
+		result.Decision.TagExists)
+	assert.Equal(t,
+		"feature/some into develop: build minor, v1.3.0-pre.1 already exists on 2f08f7b455ec64741d135216d19d7e0c4dd46458,"+
+			" bumped to v1.3.0-pre.3",
+		result.BumpReason)
+}
+
+func TestTag_TagExistsBumpFinal(t *testing.T) {
+	gc := initGitClientMock(t, "v1.2.3", "v1.2.3", "master", "hotfix/some", "81918ffc")
+	gc.TagCommitFn = func(name string) string {
+		return "2f08f7b455ec64741d135216d19d7e0c4dd46458"
+	}
+
+	_, err := generate.Tag(context.Background(), tagExistsParams("bump"), gc)
+	require.Error(t, err)
+
+	assert.True(t, errors.Is(err, generate.ErrTagExists))
+	assert.EqualError(t, err, "tag already exists: v1.2.4 has no prerelease counter to bump")
+}
+
+func TestTag_TagExistsReuse(t *testing.T) {
+	gc := initGitClientMock(t, "v1.2.3", "v1.2.3", "develop", "feature/some", "81918ffc")
+	gc.TagCommitFn = func(name string) string {
+		return "81918ffcd8a1a1d4ed7a4dac2d7d3f5e1e1f8a0b"
+	}
+	gc.ResolveCommitFn = func(rev string) (string, error) {
+		assert.Equal(t, "81918ffc", rev)
+
+		return "81918ffcd8a1a1d4ed7a4dac2d7d3f5e1e1f8a0b", nil
+	}
+
+	result, err := generate.Tag(context.Background(), tagExistsParams("reuse"), gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.3.0-pre.1", result.SemverTag)
+	assert.True(t, result.AlreadyTagged)
+	assert.Equal(t,
+		"feature/some into develop: build minor, v1.3.0-pre.1 already points at 81918ffcd8a1a1d4ed7a4dac2d7d3f5e1e1f8a0b, reused",
+		result.BumpReason)
+}
+
+func TestTag_TagExistsReuseOtherCommit(t *testing.T) {
+	gc := initGitClientMock(t, "v1.2.3", "v1.2.3", "develop", "feature/some", "81918ffc")
+	gc.TagCommitFn = func(name string) string {
+		return "2f08f7b455ec64741d135216d19d7e0c4dd46458"
+	}
+	gc.ResolveCommitFn = func(rev string) (string, error) {
+		return "81918ffcd8a1a1d4ed7a4dac2d7d3f5e1e1f8a0b", nil
+	}
+
+	_, err := generate.Tag(context.Background(), tagExistsParams("reuse"), gc)
+	require.Error(t, err)
+
+	assert.True(t, errors.Is(err, generate.ErrTagExists))
+	assert.EqualError(t, err, "tag already exists: v1.3.0-pre.1 on commit 2f08f7b455ec64741d135216d19d7e0c4dd46458")
+}
+
+func TestTag_TagExistsRemote(t *testing.T) {
+	gc := initGitClientMock(t, "v1.2.3", "v1.2.3", "develop", "feature/some", "81918ffc")
+	gc.RemoteTagCommitFn = func(remote, name string) (string, error) {
+		assert.Equal(t, "origin", remote)
+
+		if name == "v1.3.0-pre.1" {
+			return "2f08f7b455ec64741d135216d19d7e0c4dd46458", nil
+		}
+
+		return "", nil
+	}
+
+	params := tagExistsParams("bump")
+	params.CheckRemoteTags = true
+
+	result, err := generate.Tag(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.3.0-pre.2", result.SemverTag)
+	assert.Equal(t, 2, gc.RemoteTagCommitFnInvoked)
+}
+
+func TestTag_TagExistsRemoteErr(t *testing.T) {
+	gc := initGitClientMock(t, "v1.2.3", "v1.2.3", "develop", "feature/some", "81918ffc")
+	gc.RemoteTagCommitFn = func(remote, name string) (string, error) {
+		return "", errors.New("could not list tags of origin: fatal: repository not found")
+	}
+
+	params := tagExistsParams("fail")
+	params.CheckRemoteTags = true
+
+	_, err := generate.Tag(context.Background(), params, gc)
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "failed to check remote tags: could not list tags of origin: fatal: repository not found")
+}
+
+func TestRelease_AlreadyTagged(t *testing.T) {
+	gc := initGitClientMock(t, "v1.2.3", "v1.2.3", "develop", "feature/some", "81918ffc")
+	gc.TagCommitFn = func(name string) string {
+		return "81918ffc"
+	}
+
+	params := tagExistsParams("reuse")
+	params.CreateTag = true
+	params.PushTag = true
+
+	result, err := generate.Release(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.3.0-pre.1", result.SemverTag)
+	assert.Equal(t, 0, gc.CreateTagFnInvoked)
+	assert.Equal(t, 0, gc.PushTagFnInvoked)
+}
+
+func tagExistsParams(policy string) generate.Params {
+	return generate.Params{
+		CommitSha:         "81918ffc",
+		Bump:              "auto",
+		Prefix:            "v",
+		PrereleaseID:      "pre",
+		MainBranchName:    "master",
+		DevelopBranchName: "develop",
+		Remote:            "origin",
+		TagExists:         policy,
+	}
+}
+
+func TestTag_AlreadyTagged(t *testing.T) {
+	gc := initGitClientMock(t, "v1.3.0-pre.2", "v1.2.3", "develop", "feature/some", "81918ffc")
+	gc.TagsAtFn = func(rev string) ([]string, error) {
+		assert.Equal(t, "81918ffc", rev)
+
+		return []string{"latest", "v1.3.0-pre.1", "v1.3.0-pre.2", "v1.3.0-rc.1", "v1.3.0", "x1.4.0-pre.1"}, nil
+	}
+	gc.AncestorTagFn = func(include, exclude, branch string) string {
+		assert.Equal(t, "v[0-9]*", include)
+		assert.Equal(t, "81918ffc^", branch)
+
+		return "v1.2.3"
+	}
+
+	result, err := generate.Tag(context.Background(), tagExistsParams("fail"), gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.3.0-pre.2", result.SemverTag)
+	assert.Equal(t, "v1.2.3", result.PreviousTag)
+	assert.Equal(t, "v1.2.3", result.AncestorTag)
+	assert.True(t, result.AlreadyTagged)
+	assert.True(t, result.IsPrerelease)
+	assert.Equal(t, "2", result.PrereleaseNumber)
+	assert.Equal(t, "commit is already tagged with v1.3.0-pre.2", result.BumpReason)
+	assert.Equal(t, "already-tagged", result.Decision.Rule)
+	assert.Equal(t, 0, gc.LatestTagFnInvoked)
+}
+
+func TestTag_AlreadyTaggedChangelogErr(t *testing.T) {
+	gc := initGitClientMock(t, "v1.3.0-pre.2", "v1.2.3", "develop", "feature/some", "81918ffc")
+	gc.TagsAtFn = func(rev string) ([]string, error) {
+		return []string{"v1.3.0-pre.2"}, nil
+	}
+	gc.CommitsFn = func(from, to string) ([]git.Commit, error) {
+		return nil, errors.New("error")
+	}
+
+	result, err := generate.Tag(context.Background(), tagExistsParams("fail"), gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.3.0-pre.2", result.SemverTag)
+	assert.True(t, result.AlreadyTagged)
+	assert.Empty(t, result.Changelog)
+	assert.Equal(t, 1, gc.CommitsFnInvoked)
+}
+
+func TestTag_AlreadyTaggedIgnoresPrereleaseForFinal(t *testing.T) {
+	gc := initGitClientMock(t, "v1.3.0-pre.2", "v1.2.3", "master", "develop", "81918ffc")
+	gc.TagsAtFn = func(rev string) ([]string, error) {
+		return []string{"v1.3.0-pre.2"}, nil
+	}
+
+	result, err := generate.Tag(context.Background(), tagExistsParams("fail"), gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.3.0", result.SemverTag)
+	assert.False(t, result.AlreadyTagged)
+}
+
+func TestTag_AlreadyTaggedRootCommit(t *testing.T) {
+	gc := initGitClientMock(t, "v1.0.0", "a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9", "master", "hotfix/some", "81918ffc")
+	gc.TagsAtFn = func(rev string) ([]string, error) {
+		return []string{"v1.0.0"}, nil
+	}
+
+	result, err := generate.Tag(context.Background(), tagExistsParams("fail"), gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.0.0", result.SemverTag)
+	assert.Empty(t, result.PreviousTag)
+	assert.False(t, result.IsNewMajor)
+}
+
+func TestTag_TagsAtErr(t *testing.T) {
+	gc := initGitClientMock(t, "v1.0.0", "v1.0.0", "master", "hotfix/some", "81918ffc")
+	gc.TagsAtFn = func(rev string) ([]string, error) {
+		return nil, errors.New("could not get tags at 81918ffc: fatal")
+	}
+
+	_, err := generate.Tag(context.Background(), tagExistsParams("fail"), gc)
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "failed to get tags at commit: could not get tags at 81918ffc: fatal")
+}
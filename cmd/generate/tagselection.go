@@ -0,0 +1,103 @@
+package generate
+
+import (
+	"context"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blang/semver/v4"
+)
+
+// Strategies to select the latest tag the next version is calculated from.
+const (
+	// tagSelectionLatest uses the tag on the most recently tagged commit.
+	tagSelectionLatest = "latest"
+	// tagSelectionHighest uses the highest semantic version of all tags.
+	tagSelectionHighest = "highest"
+	// tagSelectionHighestReachable uses the highest semantic version of the tags
+	// reachable from the commit.
+	tagSelectionHighestReachable = "highest-reachable"
+)
+
+// selectLatestTag returns the tag the next version is calculated from using the
+// configured tag selection. An empty string is returned if there is none.
+//
+// With branch snapshots enabled only final and prerelease_id tags are
+// considered. When the most recently tagged commit carries a snapshot, the
+// highest of these tags reachable from the commit is used instead.
+func selectLatestTag(ctx context.Context, params Params, gc queryClient, rev string) (string, error) {
+	var reachableFrom string
+
+	switch params.TagSelection {
+	case tagSelectionHighest:
+	case tagSelectionHighestReachable:
+		reachableFrom = rev
+	default:
+		latest, err := gc.LatestTag(ctx)
+		if err != nil || !params.BranchSnapshots || isReleaseTag(latest, params) {
+			return latest, err
+		}
+
+		log.Debugf("latest tag %q is a snapshot, using the highest release tag reachable from %s\n", latest, rev)
+
+		reachableFrom = rev
+	}
+
+	tags, err := gc.Tags(ctx, reachableFrom)
+	if err != nil {
+		return "", err
+	}
+
+	if params.BranchSnapshots {
+		var release []string
+
+		for _, tag := range tags {
+			if isReleaseTag(tag, params) {
+				release = append(release, tag)
+			}
+		}
+
+		tags = release
+	}
+
+	return highestTag(tags, params.Prefix), nil
+}
+
+// isReleaseTag returns false for prerelease tags whose first identifier isn't
+// the prerelease id, like branch snapshots. Tags that can't be parsed are kept
+// so they still fail the calculation.
+func isReleaseTag(tag string, params Params) bool {
+	version, err := semver.ParseTolerant(strings.TrimPrefix(tag, params.Prefix))
+	if err != nil || len(version.Pre) == 0 {
+		return true
+	}
+
+	return version.Pre[0].VersionStr == params.PrereleaseID
+}
+
+// highestTag returns the tag with the prefix and the highest semantic version.
+// Tags without the prefix or not valid semantic versions are ignored.
+func highestTag(tags []string, prefix string) string {
+	var (
+		highest *semver.Version
+		result  string
+	)
+
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, prefix) {
+			continue
+		}
+
+		version, err := semver.Parse(strings.TrimPrefix(tag, prefix))
+		if err != nil {
+			continue
+		}
+
+		if highest == nil || version.GT(*highest) {
+			highest = &version
+			result = tag
+		}
+	}
+
+	return result
+}
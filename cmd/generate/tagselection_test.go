@@ -0,0 +1,89 @@
+package generate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/wakatime/semver-action/cmd/generate"
+
+	"github.com/alecthomas/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTag_TagSelection(t *testing.T) {
+	tests := map[string]struct {
+		TagSelection  string
+		ReachableFrom string
+		Tags          []string
+		Expected      string
+	}{
+		"highest": {
+			TagSelection: "highest",
+			Tags:         []string{"latest", "v1.9.0", "v1.10.0", "v1.2.0", "release-2.0.0", "v1.11"},
+			Expected:     "v1.10.0-pre.1",
+		},
+		"highest reachable": {
+			TagSelection:  "highest-reachable",
+			ReachableFrom: "81918ffc",
+			Tags:          []string{"v1.2.0", "v1.3.0-pre.4", "v1.3.0-pre.10"},
+			Expected:      "v1.3.0-pre.11",
+		},
+		"highest without tags": {
+			TagSelection: "highest",
+			Expected:     "v0.0.0-pre.1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// doc branches keep the version and only bump the prerelease counter
+			gc := initGitClientMock(t, "v5.0.0", "v1.2.0-pre.1", "develop", "doc/some", "81918ffc")
+			gc.TagsFn = func(reachableFrom string) ([]string, error) {
+				assert.Equal(t, test.ReachableFrom, reachableFrom)
+
+				return test.Tags, nil
+			}
+
+			params := tagExistsParams("fail")
+			params.TagSelection = test.TagSelection
+
+			result, err := generate.Tag(context.Background(), params, gc)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Expected, result.SemverTag)
+			assert.Equal(t, test.TagSelection, result.Decision.TagSelection)
+			assert.Equal(t, 0, gc.LatestTagFnInvoked)
+			assert.Equal(t, 1, gc.TagsFnInvoked)
+		})
+	}
+}
+
+func TestTag_TagSelectionLatest(t *testing.T) {
+	gc := initGitClientMock(t, "v5.0.0", "v1.2.0", "develop", "feature/some", "81918ffc")
+
+	params := tagExistsParams("fail")
+	params.TagSelection = "latest"
+
+	result, err := generate.Tag(context.Background(), params, gc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v5.1.0-pre.1", result.SemverTag)
+	assert.Equal(t, 1, gc.LatestTagFnInvoked)
+	assert.Equal(t, 0, gc.TagsFnInvoked)
+}
+
+func TestTag_TagSelectionErr(t *testing.T) {
+	gc := initGitClientMock(t, "v5.0.0", "v1.2.0", "develop", "feature/some", "81918ffc")
+	gc.TagsFn = func(reachableFrom string) ([]string, error) {
+		return nil, errors.New("could not list tags: fatal")
+	}
+
+	params := tagExistsParams("fail")
+	params.TagSelection = "highest"
+
+	_, err := generate.Tag(context.Background(), params, gc)
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "failed to select latest tag: could not list tags: fatal")
+}
@@ -1,10 +1,15 @@
 package actions
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"strings"
 )
 
+// ErrInputRequired is returned when a required input is not supplied.
+var ErrInputRequired = errors.New("input required and not supplied") // nolint
+
 // GetInput gets the input by the given name.
 func GetInput(name string) string {
 	e := strings.ReplaceAll(name, " ", "_")
@@ -13,3 +18,44 @@ func GetInput(name string) string {
 
 	return strings.TrimSpace(os.Getenv(e))
 }
+
+// GetRequiredInput gets the input by the given name and returns an error
+// wrapping ErrInputRequired if it is empty.
+func GetRequiredInput(name string) (string, error) {
+	value := GetInput(name)
+	if value == "" {
+		return "", fmt.Errorf("%w: %s", ErrInputRequired, name)
+	}
+
+	return value, nil
+}
+
+// GetBooleanInput gets the input by the given name as a boolean. Following the
+// YAML 1.2 core schema only true, True, TRUE, false, False and FALSE are valid.
+// An empty input is false.
+func GetBooleanInput(name string) (bool, error) {
+	value := GetInput(name)
+
+	switch value {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "", "false", "False", "FALSE":
+		return false, nil
+	default:
+		return false, fmt.Errorf("input does not meet YAML 1.2 core schema specification: %s", name)
+	}
+}
+
+// GetMultilineInput gets the input by the given name split into lines. Lines
+// are trimmed and empty lines are dropped.
+func GetMultilineInput(name string) []string {
+	var lines []string
+
+	for _, line := range strings.Split(GetInput(name), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
@@ -1,10 +1,12 @@
 package actions_test
 
 import (
+	"errors"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/wakatime/semver-action/pkg/actions"
 )
 
@@ -37,3 +39,58 @@ func TestGetInput(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRequiredInput(t *testing.T) {
+	t.Setenv("INPUT_PREFIX", "v")
+
+	value, err := actions.GetRequiredInput("prefix")
+	require.NoError(t, err)
+
+	assert.Equal(t, "v", value)
+}
+
+func TestGetRequiredInput_Missing(t *testing.T) {
+	t.Setenv("INPUT_PREFIX", " ")
+
+	_, err := actions.GetRequiredInput("prefix")
+	require.Error(t, err)
+
+	assert.True(t, errors.Is(err, actions.ErrInputRequired))
+	assert.EqualError(t, err, "input required and not supplied: prefix")
+}
+
+func TestGetBooleanInput(t *testing.T) {
+	tests := map[string]bool{
+		"":      false,
+		"true":  true,
+		"True":  true,
+		"TRUE":  true,
+		"false": false,
+		"False": false,
+		"FALSE": false,
+	}
+
+	for value, expected := range tests {
+		t.Run(value, func(t *testing.T) {
+			t.Setenv("INPUT_DEBUG", value)
+
+			parsed, err := actions.GetBooleanInput("debug")
+			require.NoError(t, err)
+
+			assert.Equal(t, expected, parsed)
+		})
+	}
+}
+
+func TestGetBooleanInput_Invalid(t *testing.T) {
+	t.Setenv("INPUT_DEBUG", "yes")
+
+	_, err := actions.GetBooleanInput("debug")
+	require.Error(t, err)
+}
+
+func TestGetMultilineInput(t *testing.T) {
+	t.Setenv("INPUT_OUTPUT_FORMATS", "dotenv\n\n  json=semver.json  \ngithub_env\n")
+
+	assert.Equal(t, []string{"dotenv", "json=semver.json", "github_env"}, actions.GetMultilineInput("output_formats"))
+}
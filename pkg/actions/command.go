@@ -0,0 +1,102 @@
+package actions
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// nolint: gochecknoglobals
+var failed int32
+
+// IssueCommand writes a workflow command, e.g. `::warning file=a.go::message`.
+func IssueCommand(w io.Writer, command string, properties map[string]string, message string) {
+	var b strings.Builder
+
+	b.WriteString("::")
+	b.WriteString(command)
+
+	if len(properties) > 0 {
+		keys := make([]string, 0, len(properties))
+		for k := range properties {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		for i, k := range keys {
+			if i == 0 {
+				b.WriteString(" ")
+			} else {
+				b.WriteString(",")
+			}
+
+			b.WriteString(k + "=" + escapeProperty(properties[k]))
+		}
+	}
+
+	b.WriteString("::")
+	b.WriteString(escapeData(message))
+
+	_, _ = fmt.Fprintln(w, b.String())
+}
+
+// Notice writes a notice annotation.
+func Notice(w io.Writer, message string) {
+	IssueCommand(w, "notice", nil, message)
+}
+
+// Warning writes a warning annotation.
+func Warning(w io.Writer, message string) {
+	IssueCommand(w, "warning", nil, message)
+}
+
+// Error writes an error annotation.
+func Error(w io.Writer, message string) {
+	IssueCommand(w, "error", nil, message)
+}
+
+// AddMask masks the value in all later log output.
+func AddMask(w io.Writer, value string) {
+	IssueCommand(w, "add-mask", nil, value)
+}
+
+// SetFailed writes an error annotation and marks the action as failed, see ExitCode.
+func SetFailed(w io.Writer, message string) {
+	atomic.StoreInt32(&failed, 1)
+
+	Error(w, message)
+}
+
+// ExitCode returns 1 if SetFailed was called and 0 otherwise.
+func ExitCode() int {
+	return int(atomic.LoadInt32(&failed))
+}
+
+// Group starts a collapsible group in the log.
+func Group(w io.Writer, name string) {
+	IssueCommand(w, "group", nil, name)
+}
+
+// EndGroup ends the current group.
+func EndGroup(w io.Writer) {
+	IssueCommand(w, "endgroup", nil, "")
+}
+
+// WithGroup runs fn inside a group and ends the group when fn returns.
+func WithGroup(w io.Writer, name string, fn func() error) error {
+	Group(w, name)
+	defer EndGroup(w)
+
+	return fn()
+}
+
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
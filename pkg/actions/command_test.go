@@ -0,0 +1,64 @@
+package actions_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wakatime/semver-action/pkg/actions"
+)
+
+func TestIssueCommand(t *testing.T) {
+	var buf bytes.Buffer
+
+	actions.IssueCommand(&buf, "warning", map[string]string{
+		"title": "semver, tag",
+		"file":  "a:b.go",
+	}, "100% done\nnext line")
+
+	assert.Equal(t, "::warning file=a%3Ab.go,title=semver%2C tag::100%25 done%0Anext line\n", buf.String())
+}
+
+func TestGroup(t *testing.T) {
+	var buf bytes.Buffer
+
+	actions.Group(&buf, "Explanation")
+	actions.Notice(&buf, "calculated v1.0.0")
+	actions.EndGroup(&buf)
+
+	assert.Equal(t, "::group::Explanation\n::notice::calculated v1.0.0\n::endgroup::\n", buf.String())
+}
+
+func TestAddMask(t *testing.T) {
+	var buf bytes.Buffer
+
+	actions.AddMask(&buf, "secret\nvalue")
+
+	assert.Equal(t, "::add-mask::secret%0Avalue\n", buf.String())
+}
+
+func TestSetFailed(t *testing.T) {
+	var buf bytes.Buffer
+
+	assert.Equal(t, 0, actions.ExitCode())
+
+	actions.SetFailed(&buf, "failed to generate semver version")
+
+	assert.Equal(t, "::error::failed to generate semver version\n", buf.String())
+	assert.Equal(t, 1, actions.ExitCode())
+}
+
+func TestWithGroup(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := actions.WithGroup(&buf, "Tags", func() error {
+		buf.WriteString("v1.0.0\n")
+
+		return errors.New("failed")
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, "::group::Tags\nv1.0.0\n::endgroup::\n", buf.String())
+}
@@ -0,0 +1,94 @@
+package actions
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// SetOutput sets an output of the step by appending it to GITHUB_OUTPUT.
+func SetOutput(name, value string) error {
+	fp, err := fileCommandPath("GITHUB_OUTPUT")
+	if err != nil {
+		return err
+	}
+
+	return AppendKeyValue(fp, name, value)
+}
+
+// ExportVariable sets an environment variable for this and later steps by
+// appending it to GITHUB_ENV.
+func ExportVariable(name, value string) error {
+	fp, err := fileCommandPath("GITHUB_ENV")
+	if err != nil {
+		return err
+	}
+
+	if err := os.Setenv(name, value); err != nil {
+		return fmt.Errorf("failed to set environment variable %s: %s", name, err)
+	}
+
+	return AppendKeyValue(fp, name, value)
+}
+
+// AddPath prepends a directory to PATH for this and later steps by
+// appending it to GITHUB_PATH.
+func AddPath(path string) error {
+	fp, err := fileCommandPath("GITHUB_PATH")
+	if err != nil {
+		return err
+	}
+
+	if err := appendFile(fp, path+"\n"); err != nil {
+		return err
+	}
+
+	return os.Setenv("PATH", path+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// AppendKeyValue appends a name and value to a file command file such as
+// GITHUB_OUTPUT or GITHUB_ENV. The value is wrapped in a random heredoc
+// delimiter so it may span multiple lines.
+func AppendKeyValue(fp, name, value string) error {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return fmt.Errorf("failed to generate delimiter uuid: %s", err)
+	}
+
+	delimiter := fmt.Sprintf("ghadelimiter_%s", id.String())
+
+	if strings.Contains(name, delimiter) || strings.Contains(value, delimiter) {
+		return fmt.Errorf("unexpected input: name or value contains the delimiter %s", delimiter)
+	}
+
+	return appendFile(fp, fmt.Sprintf("%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter))
+}
+
+// fileCommandPath returns the path of a file command from the environment.
+func fileCommandPath(envKey string) (string, error) {
+	fp := os.Getenv(envKey)
+	if fp == "" {
+		return "", fmt.Errorf("%s is not set", envKey)
+	}
+
+	return fp, nil
+}
+
+func appendFile(fp, content string) error {
+	f, err := os.OpenFile(fp, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // nolint:gosec
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %s", fp, err)
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	if _, err := f.WriteString(content); err != nil {
+		return fmt.Errorf("failed to write to %s: %s", fp, err)
+	}
+
+	return nil
+}
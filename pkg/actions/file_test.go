@@ -0,0 +1,80 @@
+package actions_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wakatime/semver-action/pkg/actions"
+)
+
+func TestSetOutput(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "output")
+	t.Setenv("GITHUB_OUTPUT", fp)
+
+	require.NoError(t, actions.SetOutput("semver_tag", "v1.0.0"))
+	require.NoError(t, actions.SetOutput("changelog", "- first\n- second"))
+
+	assert.Equal(t, "semver_tag<<EOF\nv1.0.0\nEOF\nchangelog<<EOF\n- first\n- second\nEOF\n", readFileCommand(t, fp))
+}
+
+func TestSetOutput_Unset(t *testing.T) {
+	t.Setenv("GITHUB_OUTPUT", "")
+
+	err := actions.SetOutput("semver_tag", "v1.0.0")
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "GITHUB_OUTPUT is not set")
+}
+
+func TestExportVariable(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "env")
+	t.Setenv("GITHUB_ENV", fp)
+	t.Setenv("SEMVER_TAG", "")
+
+	require.NoError(t, actions.ExportVariable("SEMVER_TAG", "v1.0.0"))
+
+	assert.Equal(t, "v1.0.0", os.Getenv("SEMVER_TAG"))
+	assert.Equal(t, "SEMVER_TAG<<EOF\nv1.0.0\nEOF\n", readFileCommand(t, fp))
+}
+
+func TestAddPath(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "path")
+	t.Setenv("GITHUB_PATH", fp)
+	t.Setenv("PATH", "/usr/bin")
+
+	require.NoError(t, actions.AddPath("/opt/semver/bin"))
+
+	content, err := os.ReadFile(fp)
+	require.NoError(t, err)
+
+	assert.Equal(t, "/opt/semver/bin\n", string(content))
+	assert.Equal(t, "/opt/semver/bin"+string(os.PathListSeparator)+"/usr/bin", os.Getenv("PATH"))
+}
+
+func TestAppendKeyValue_UniqueDelimiter(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "output")
+
+	require.NoError(t, actions.AppendKeyValue(fp, "a", "1"))
+	require.NoError(t, actions.AppendKeyValue(fp, "b", "2"))
+
+	content, err := os.ReadFile(fp)
+	require.NoError(t, err)
+
+	delimiters := regexp.MustCompile(`ghadelimiter_[0-9a-f-]+`).FindAllString(string(content), -1)
+	require.Len(t, delimiters, 4)
+
+	assert.Equal(t, delimiters[0], delimiters[1])
+	assert.NotEqual(t, delimiters[0], delimiters[2])
+}
+
+// readFileCommand reads a file command file replacing the random delimiters with EOF.
+func readFileCommand(t *testing.T, fp string) string {
+	content, err := os.ReadFile(fp)
+	require.NoError(t, err)
+
+	return regexp.MustCompile(`ghadelimiter_[0-9a-f-]+`).ReplaceAllString(string(content), "EOF")
+}
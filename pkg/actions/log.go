@@ -0,0 +1,47 @@
+package actions
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/apex/log"
+)
+
+// Handler is an apex/log handler writing warnings and errors as workflow
+// commands, so they show up as annotations on the workflow run.
+type Handler struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewHandler creates a new workflow command handler writing to w.
+func NewHandler(w io.Writer) *Handler {
+	return &Handler{w: w}
+}
+
+// HandleLog implements log.Handler.
+func (h *Handler) HandleLog(e *log.Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	message := strings.TrimRight(e.Message, "\n")
+
+	for _, name := range e.Fields.Names() {
+		message += fmt.Sprintf(" %s=%v", name, e.Fields.Get(name))
+	}
+
+	switch e.Level {
+	case log.DebugLevel:
+		IssueCommand(h.w, "debug", nil, message)
+	case log.WarnLevel:
+		Warning(h.w, message)
+	case log.ErrorLevel, log.FatalLevel:
+		Error(h.w, message)
+	default:
+		_, _ = fmt.Fprintln(h.w, message)
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package actions_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/wakatime/semver-action/pkg/actions"
+)
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := &log.Logger{
+		Handler: actions.NewHandler(&buf),
+		Level:   log.DebugLevel,
+	}
+
+	logger.Debug("debug logs enabled\n")
+	logger.Info("plain message")
+	logger.WithField("tag", "v1.0.0").Warn("no previous tag found")
+	logger.Errorf("failed:\n%s", "some reason")
+
+	assert.Equal(t, "::debug::debug logs enabled\n"+
+		"plain message\n"+
+		"::warning::no previous tag found tag=v1.0.0\n"+
+		"::error::failed:%0Asome reason\n", buf.String())
+}
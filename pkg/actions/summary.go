@@ -0,0 +1,152 @@
+package actions
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Summary builds markdown for the job summary.
+type Summary struct {
+	b strings.Builder
+}
+
+// NewSummary creates an empty summary.
+func NewSummary() *Summary {
+	return &Summary{}
+}
+
+// AddRaw adds raw text.
+func (s *Summary) AddRaw(text string) *Summary {
+	s.b.WriteString(text)
+
+	return s
+}
+
+// AddEOL adds a line break.
+func (s *Summary) AddEOL() *Summary {
+	return s.AddRaw("\n")
+}
+
+// AddHeading adds a heading of the given level between 1 and 6.
+func (s *Summary) AddHeading(text string, level int) *Summary {
+	if level < 1 || level > 6 {
+		level = 1
+	}
+
+	return s.AddRaw(fmt.Sprintf("%s %s\n\n", strings.Repeat("#", level), text))
+}
+
+// AddParagraph adds a paragraph of text.
+func (s *Summary) AddParagraph(text string) *Summary {
+	return s.AddRaw(text + "\n\n")
+}
+
+// AddCodeBlock adds a fenced code block with an optional language.
+func (s *Summary) AddCodeBlock(code, lang string) *Summary {
+	return s.AddRaw(fmt.Sprintf("```%s\n%s\n```\n\n", lang, strings.TrimRight(code, "\n")))
+}
+
+// AddList adds a bulleted list, or a numbered list if ordered is set.
+func (s *Summary) AddList(items []string, ordered bool) *Summary {
+	for i, item := range items {
+		if ordered {
+			s.AddRaw(fmt.Sprintf("%d. %s\n", i+1, item))
+		} else {
+			s.AddRaw(fmt.Sprintf("- %s\n", item))
+		}
+	}
+
+	return s.AddEOL()
+}
+
+// AddTable adds a table with a header row. Pipes and line breaks in cells are escaped.
+func (s *Summary) AddTable(header []string, rows [][]string) *Summary {
+	s.addRow(header)
+
+	separator := make([]string, len(header))
+	for i := range separator {
+		separator[i] = "---"
+	}
+
+	s.addRow(separator)
+
+	for _, row := range rows {
+		s.addRow(row)
+	}
+
+	return s.AddEOL()
+}
+
+// AddDetails adds a collapsible section.
+func (s *Summary) AddDetails(label, content string) *Summary {
+	return s.AddRaw(fmt.Sprintf("<details><summary>%s</summary>\n\n%s\n\n</details>\n\n",
+		label, strings.TrimRight(content, "\n")))
+}
+
+// AddLink adds a link.
+func (s *Summary) AddLink(text, href string) *Summary {
+	return s.AddRaw(fmt.Sprintf("[%s](%s)", text, href))
+}
+
+// AddSeparator adds a horizontal rule.
+func (s *Summary) AddSeparator() *Summary {
+	return s.AddRaw("---\n\n")
+}
+
+// String returns the summary markdown.
+func (s *Summary) String() string {
+	return s.b.String()
+}
+
+// IsEmpty reports whether nothing was added to the summary.
+func (s *Summary) IsEmpty() bool {
+	return s.b.Len() == 0
+}
+
+// Clear removes everything added to the summary.
+func (s *Summary) Clear() *Summary {
+	s.b.Reset()
+
+	return s
+}
+
+// Write appends the summary to the job summary file and clears it.
+func (s *Summary) Write() error {
+	if err := AppendSummary(s.String()); err != nil {
+		return err
+	}
+
+	s.Clear()
+
+	return nil
+}
+
+func (s *Summary) addRow(cells []string) {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = EscapeTableCell(cell)
+	}
+
+	s.AddRaw("| " + strings.Join(escaped, " | ") + " |\n")
+}
+
+// EscapeTableCell escapes pipes and line breaks in a markdown table cell.
+func EscapeTableCell(value string) string {
+	return strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>").Replace(value)
+}
+
+// AppendSummary appends markdown to the job summary. It does nothing when
+// GITHUB_STEP_SUMMARY is not set.
+func AppendSummary(markdown string) error {
+	fp := os.Getenv("GITHUB_STEP_SUMMARY")
+	if fp == "" {
+		return nil
+	}
+
+	if err := appendFile(fp, markdown); err != nil {
+		return fmt.Errorf("failed to write step summary: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package actions_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wakatime/semver-action/pkg/actions"
+)
+
+func TestAppendSummary(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "summary")
+	t.Setenv("GITHUB_STEP_SUMMARY", fp)
+
+	require.NoError(t, actions.AppendSummary("## First\n"))
+	require.NoError(t, actions.AppendSummary("## Second\n"))
+
+	content, err := os.ReadFile(fp)
+	require.NoError(t, err)
+
+	assert.Equal(t, "## First\n## Second\n", string(content))
+}
+
+func TestAppendSummary_Unset(t *testing.T) {
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+
+	require.NoError(t, actions.AppendSummary("## First\n"))
+}
+
+func TestSummary(t *testing.T) {
+	s := actions.NewSummary().
+		AddHeading("Release", 2).
+		AddParagraph("Calculated a new version.").
+		AddTable([]string{"Tag", "Reason"}, [][]string{{"v1.0.0", "a | b\nc"}}).
+		AddList([]string{"first", "second"}, false).
+		AddList([]string{"first"}, true).
+		AddCodeBlock("SEMVER_TAG=v1.0.0\n", "sh").
+		AddDetails("Changelog", "- first").
+		AddLink("v1.0.0", "https://github.com/wakatime/semver-action").
+		AddEOL().
+		AddSeparator()
+
+	assert.Equal(t, "## Release\n\n"+
+		"Calculated a new version.\n\n"+
+		"| Tag | Reason |\n| --- | --- |\n| v1.0.0 | a \\| b<br>c |\n\n"+
+		"- first\n- second\n\n"+
+		"1. first\n\n"+
+		"```sh\nSEMVER_TAG=v1.0.0\n```\n\n"+
+		"<details><summary>Changelog</summary>\n\n- first\n\n</details>\n\n"+
+		"[v1.0.0](https://github.com/wakatime/semver-action)\n"+
+		"---\n\n", s.String())
+}
+
+func TestSummary_Write(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "summary")
+	t.Setenv("GITHUB_STEP_SUMMARY", fp)
+
+	s := actions.NewSummary().AddHeading("Release", 1)
+	require.NoError(t, s.Write())
+
+	assert.True(t, s.IsEmpty())
+
+	content, err := os.ReadFile(fp)
+	require.NoError(t, err)
+
+	assert.Equal(t, "# Release\n\n", string(content))
+}
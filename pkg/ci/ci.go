@@ -0,0 +1,229 @@
+package ci
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var githubPullRefRegex = regexp.MustCompile(`^refs/pull/([0-9]+)/`) // nolint
+
+// pullRequestURLRegex matches the number at the end of a pull request url, e.g.
+// https://github.com/wakatime/semver-action/pull/482.
+var pullRequestURLRegex = regexp.MustCompile(`/([0-9]+)/?$`) // nolint
+
+// Provider identifies a CI system.
+type Provider string
+
+const (
+	// Unknown is used when no CI system is detected.
+	Unknown Provider = ""
+	// GitHub is GitHub Actions.
+	GitHub Provider = "github"
+	// GitLab is GitLab CI.
+	GitLab Provider = "gitlab"
+	// Jenkins is Jenkins.
+	Jenkins Provider = "jenkins"
+	// CircleCI is CircleCI.
+	CircleCI Provider = "circleci"
+	// Azure is Azure Pipelines.
+	Azure Provider = "azure"
+	// Buildkite is Buildkite.
+	Buildkite Provider = "buildkite"
+	// Drone is Drone CI.
+	Drone Provider = "drone"
+)
+
+// Environment contains the commit and branches of the current CI run.
+type Environment struct {
+	Provider Provider
+	// CommitSha is the commit being built.
+	CommitSha string
+	// Branch is the branch being built on push builds.
+	Branch string
+	// SourceBranch and TargetBranch are set on pull request builds.
+	SourceBranch string
+	TargetBranch string
+	// PullRequest is the number of the pull request on pull request builds.
+	PullRequest string
+}
+
+// Detect detects the CI system from the environment variables.
+func Detect() Environment {
+	return DetectFrom(os.Getenv)
+}
+
+// DetectFrom detects the CI system using the given environment variable getter.
+func DetectFrom(getenv func(key string) string) Environment {
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		env := Environment{
+			Provider:  GitHub,
+			CommitSha: getenv("GITHUB_SHA"),
+		}
+
+		// GITHUB_BASE_REF and GITHUB_HEAD_REF are only set on pull request events
+		if getenv("GITHUB_BASE_REF") != "" {
+			env.SourceBranch = getenv("GITHUB_HEAD_REF")
+			env.TargetBranch = getenv("GITHUB_BASE_REF")
+			env.PullRequest = githubPullRequest(getenv)
+		} else if getenv("GITHUB_REF_TYPE") == "branch" {
+			env.Branch = getenv("GITHUB_REF_NAME")
+		}
+
+		return env
+	case getenv("GITLAB_CI") == "true":
+		return Environment{
+			Provider:     GitLab,
+			CommitSha:    getenv("CI_COMMIT_SHA"),
+			Branch:       getenv("CI_COMMIT_BRANCH"),
+			SourceBranch: getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME"),
+			TargetBranch: getenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME"),
+			PullRequest:  getenv("CI_MERGE_REQUEST_IID"),
+		}
+	case getenv("TF_BUILD") != "":
+		env := Environment{
+			Provider:  Azure,
+			CommitSha: getenv("BUILD_SOURCEVERSION"),
+		}
+
+		if getenv("SYSTEM_PULLREQUEST_PULLREQUESTID") != "" {
+			env.SourceBranch = trimRef(getenv("SYSTEM_PULLREQUEST_SOURCEBRANCH"))
+			env.TargetBranch = trimRef(getenv("SYSTEM_PULLREQUEST_TARGETBRANCH"))
+			// the number is only set for GitHub and Bitbucket repositories
+			env.PullRequest = getenv("SYSTEM_PULLREQUEST_PULLREQUESTNUMBER")
+
+			if env.PullRequest == "" {
+				env.PullRequest = getenv("SYSTEM_PULLREQUEST_PULLREQUESTID")
+			}
+		} else if ref := getenv("BUILD_SOURCEBRANCH"); strings.HasPrefix(ref, "refs/heads/") {
+			env.Branch = trimRef(ref)
+		}
+
+		return env
+	case getenv("CIRCLECI") == "true":
+		env := Environment{
+			Provider:  CircleCI,
+			CommitSha: getenv("CIRCLE_SHA1"),
+		}
+
+		// the target branch of pull requests is not exposed
+		if pr := circlePullRequest(getenv); pr != "" {
+			env.SourceBranch = getenv("CIRCLE_BRANCH")
+			env.PullRequest = pr
+		} else {
+			env.Branch = getenv("CIRCLE_BRANCH")
+		}
+
+		return env
+	case getenv("BUILDKITE") == "true":
+		env := Environment{
+			Provider:  Buildkite,
+			CommitSha: getenv("BUILDKITE_COMMIT"),
+		}
+
+		if pr := getenv("BUILDKITE_PULL_REQUEST"); pr != "" && pr != "false" {
+			env.SourceBranch = getenv("BUILDKITE_BRANCH")
+			env.TargetBranch = getenv("BUILDKITE_PULL_REQUEST_BASE_BRANCH")
+			env.PullRequest = pr
+		} else {
+			env.Branch = getenv("BUILDKITE_BRANCH")
+		}
+
+		return env
+	case getenv("DRONE") == "true":
+		env := Environment{
+			Provider:  Drone,
+			CommitSha: getenv("DRONE_COMMIT_SHA"),
+		}
+
+		if getenv("DRONE_BUILD_EVENT") == "pull_request" {
+			env.SourceBranch = getenv("DRONE_SOURCE_BRANCH")
+			env.TargetBranch = getenv("DRONE_TARGET_BRANCH")
+			env.PullRequest = getenv("DRONE_PULL_REQUEST")
+		} else {
+			env.Branch = getenv("DRONE_BRANCH")
+		}
+
+		return env
+	case getenv("JENKINS_URL") != "":
+		env := Environment{
+			Provider:  Jenkins,
+			CommitSha: getenv("GIT_COMMIT"),
+		}
+
+		if getenv("CHANGE_ID") != "" {
+			env.SourceBranch = getenv("CHANGE_BRANCH")
+			env.TargetBranch = getenv("CHANGE_TARGET")
+			env.PullRequest = getenv("CHANGE_ID")
+		} else if branch := getenv("BRANCH_NAME"); branch != "" {
+			env.Branch = branch
+		} else {
+			env.Branch = strings.TrimPrefix(getenv("GIT_BRANCH"), "origin/")
+		}
+
+		return env
+	default:
+		return Environment{}
+	}
+}
+
+// DestBranch returns the branch the commit is merged into, which is the target
+// branch on pull request builds and the current branch otherwise.
+func (e Environment) DestBranch() string {
+	if e.TargetBranch != "" {
+		return e.TargetBranch
+	}
+
+	return e.Branch
+}
+
+// githubPullRequest returns the pull request number from the event payload. The
+// merge ref, e.g. refs/pull/482/merge, is used if the payload can't be read.
+func githubPullRequest(getenv func(key string) string) string {
+	if path := getenv("GITHUB_EVENT_PATH"); path != "" {
+		if data, err := os.ReadFile(path); err == nil { // nolint:gosec
+			var event struct {
+				Number      int `json:"number"`
+				PullRequest struct {
+					Number int `json:"number"`
+				} `json:"pull_request"`
+			}
+
+			if err := json.Unmarshal(data, &event); err == nil {
+				switch {
+				case event.PullRequest.Number > 0:
+					return strconv.Itoa(event.PullRequest.Number)
+				case event.Number > 0:
+					return strconv.Itoa(event.Number)
+				}
+			}
+		}
+	}
+
+	if match := githubPullRefRegex.FindStringSubmatch(getenv("GITHUB_REF")); match != nil {
+		return match[1]
+	}
+
+	return ""
+}
+
+// circlePullRequest returns the pull request number. CIRCLE_PR_NUMBER is only
+// set for pull requests from forks, else it is taken from the pull request url.
+func circlePullRequest(getenv func(key string) string) string {
+	if pr := getenv("CIRCLE_PR_NUMBER"); pr != "" {
+		return pr
+	}
+
+	if match := pullRequestURLRegex.FindStringSubmatch(getenv("CIRCLE_PULL_REQUEST")); match != nil {
+		return match[1]
+	}
+
+	return ""
+}
+
+func trimRef(ref string) string {
+	return strings.TrimPrefix(ref, "refs/heads/")
+}
@@ -0,0 +1,282 @@
+package ci_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wakatime/semver-action/pkg/ci"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFrom(t *testing.T) {
+	tests := map[string]struct {
+		Env      map[string]string
+		Expected ci.Environment
+	}{
+		"none": {
+			Env:      map[string]string{},
+			Expected: ci.Environment{},
+		},
+		"github": {
+			Env: map[string]string{
+				"GITHUB_ACTIONS": "true",
+				"GITHUB_SHA":     "81918ffc",
+			},
+			Expected: ci.Environment{Provider: ci.GitHub, CommitSha: "81918ffc"},
+		},
+		"github push": {
+			Env: map[string]string{
+				"GITHUB_ACTIONS":  "true",
+				"GITHUB_SHA":      "81918ffc",
+				"GITHUB_REF_TYPE": "branch",
+				"GITHUB_REF_NAME": "develop",
+			},
+			Expected: ci.Environment{Provider: ci.GitHub, CommitSha: "81918ffc", Branch: "develop"},
+		},
+		"github tag push": {
+			Env: map[string]string{
+				"GITHUB_ACTIONS":  "true",
+				"GITHUB_SHA":      "81918ffc",
+				"GITHUB_REF_TYPE": "tag",
+				"GITHUB_REF_NAME": "v1.2.3",
+			},
+			Expected: ci.Environment{Provider: ci.GitHub, CommitSha: "81918ffc"},
+		},
+		"github pull request": {
+			Env: map[string]string{
+				"GITHUB_ACTIONS":  "true",
+				"GITHUB_SHA":      "81918ffc",
+				"GITHUB_REF_TYPE": "branch",
+				"GITHUB_REF":      "refs/pull/12/merge",
+				"GITHUB_REF_NAME": "12/merge",
+				"GITHUB_HEAD_REF": "feature/some",
+				"GITHUB_BASE_REF": "develop",
+			},
+			Expected: ci.Environment{
+				Provider:     ci.GitHub,
+				CommitSha:    "81918ffc",
+				SourceBranch: "feature/some",
+				TargetBranch: "develop",
+				PullRequest:  "12",
+			},
+		},
+		"gitlab push": {
+			Env: map[string]string{
+				"GITLAB_CI":        "true",
+				"CI_COMMIT_SHA":    "81918ffc",
+				"CI_COMMIT_BRANCH": "develop",
+			},
+			Expected: ci.Environment{Provider: ci.GitLab, CommitSha: "81918ffc", Branch: "develop"},
+		},
+		"gitlab merge request": {
+			Env: map[string]string{
+				"GITLAB_CI":                           "true",
+				"CI_COMMIT_SHA":                       "81918ffc",
+				"CI_MERGE_REQUEST_IID":                "12",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "feature/some",
+				"CI_MERGE_REQUEST_TARGET_BRANCH_NAME": "develop",
+			},
+			Expected: ci.Environment{
+				Provider:     ci.GitLab,
+				CommitSha:    "81918ffc",
+				SourceBranch: "feature/some",
+				TargetBranch: "develop",
+				PullRequest:  "12",
+			},
+		},
+		"jenkins multibranch": {
+			Env: map[string]string{
+				"JENKINS_URL": "https://jenkins.example.com",
+				"GIT_COMMIT":  "81918ffc",
+				"BRANCH_NAME": "develop",
+			},
+			Expected: ci.Environment{Provider: ci.Jenkins, CommitSha: "81918ffc", Branch: "develop"},
+		},
+		"jenkins git plugin": {
+			Env: map[string]string{
+				"JENKINS_URL": "https://jenkins.example.com",
+				"GIT_COMMIT":  "81918ffc",
+				"GIT_BRANCH":  "origin/develop",
+			},
+			Expected: ci.Environment{Provider: ci.Jenkins, CommitSha: "81918ffc", Branch: "develop"},
+		},
+		"jenkins change request": {
+			Env: map[string]string{
+				"JENKINS_URL":   "https://jenkins.example.com",
+				"GIT_COMMIT":    "81918ffc",
+				"BRANCH_NAME":   "PR-12",
+				"CHANGE_ID":     "12",
+				"CHANGE_BRANCH": "feature/some",
+				"CHANGE_TARGET": "develop",
+			},
+			Expected: ci.Environment{
+				Provider:     ci.Jenkins,
+				CommitSha:    "81918ffc",
+				SourceBranch: "feature/some",
+				TargetBranch: "develop",
+				PullRequest:  "12",
+			},
+		},
+		"circleci": {
+			Env: map[string]string{
+				"CIRCLECI":      "true",
+				"CIRCLE_SHA1":   "81918ffc",
+				"CIRCLE_BRANCH": "develop",
+			},
+			Expected: ci.Environment{Provider: ci.CircleCI, CommitSha: "81918ffc", Branch: "develop"},
+		},
+		"circleci pull request": {
+			Env: map[string]string{
+				"CIRCLECI":            "true",
+				"CIRCLE_SHA1":         "81918ffc",
+				"CIRCLE_BRANCH":       "feature/some",
+				"CIRCLE_PULL_REQUEST": "https://github.com/wakatime/semver-action/pull/12",
+			},
+			Expected: ci.Environment{
+				Provider:     ci.CircleCI,
+				CommitSha:    "81918ffc",
+				SourceBranch: "feature/some",
+				PullRequest:  "12",
+			},
+		},
+		"circleci fork pull request": {
+			Env: map[string]string{
+				"CIRCLECI":         "true",
+				"CIRCLE_SHA1":      "81918ffc",
+				"CIRCLE_BRANCH":    "pull/12",
+				"CIRCLE_PR_NUMBER": "12",
+			},
+			Expected: ci.Environment{
+				Provider:     ci.CircleCI,
+				CommitSha:    "81918ffc",
+				SourceBranch: "pull/12",
+				PullRequest:  "12",
+			},
+		},
+		"azure push": {
+			Env: map[string]string{
+				"TF_BUILD":            "True",
+				"BUILD_SOURCEVERSION": "81918ffc",
+				"BUILD_SOURCEBRANCH":  "refs/heads/develop",
+			},
+			Expected: ci.Environment{Provider: ci.Azure, CommitSha: "81918ffc", Branch: "develop"},
+		},
+		"azure pull request": {
+			Env: map[string]string{
+				"TF_BUILD":                         "True",
+				"BUILD_SOURCEVERSION":              "81918ffc",
+				"BUILD_SOURCEBRANCH":               "refs/pull/12/merge",
+				"SYSTEM_PULLREQUEST_PULLREQUESTID": "12",
+				"SYSTEM_PULLREQUEST_SOURCEBRANCH":  "refs/heads/feature/some",
+				"SYSTEM_PULLREQUEST_TARGETBRANCH":  "refs/heads/develop",
+			},
+			Expected: ci.Environment{
+				Provider:     ci.Azure,
+				CommitSha:    "81918ffc",
+				SourceBranch: "feature/some",
+				TargetBranch: "develop",
+				PullRequest:  "12",
+			},
+		},
+		"buildkite pull request": {
+			Env: map[string]string{
+				"BUILDKITE":                          "true",
+				"BUILDKITE_COMMIT":                   "81918ffc",
+				"BUILDKITE_BRANCH":                   "feature/some",
+				"BUILDKITE_PULL_REQUEST":             "12",
+				"BUILDKITE_PULL_REQUEST_BASE_BRANCH": "develop",
+			},
+			Expected: ci.Environment{
+				Provider:     ci.Buildkite,
+				CommitSha:    "81918ffc",
+				SourceBranch: "feature/some",
+				TargetBranch: "develop",
+				PullRequest:  "12",
+			},
+		},
+		"buildkite push": {
+			Env: map[string]string{
+				"BUILDKITE":              "true",
+				"BUILDKITE_COMMIT":       "81918ffc",
+				"BUILDKITE_BRANCH":       "develop",
+				"BUILDKITE_PULL_REQUEST": "false",
+			},
+			Expected: ci.Environment{Provider: ci.Buildkite, CommitSha: "81918ffc", Branch: "develop"},
+		},
+		"drone pull request": {
+			Env: map[string]string{
+				"DRONE":               "true",
+				"DRONE_COMMIT_SHA":    "81918ffc",
+				"DRONE_BUILD_EVENT":   "pull_request",
+				"DRONE_PULL_REQUEST":  "12",
+				"DRONE_SOURCE_BRANCH": "feature/some",
+				"DRONE_TARGET_BRANCH": "develop",
+			},
+			Expected: ci.Environment{
+				Provider:     ci.Drone,
+				CommitSha:    "81918ffc",
+				SourceBranch: "feature/some",
+				TargetBranch: "develop",
+				PullRequest:  "12",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := ci.DetectFrom(func(key string) string {
+				return test.Env[key]
+			})
+
+			assert.Equal(t, test.Expected, env)
+		})
+	}
+}
+
+func TestDetectFrom_GitHubEventPayload(t *testing.T) {
+	tests := map[string]struct {
+		Payload  string
+		Expected string
+	}{
+		"pull request": {
+			Payload:  `{"number": 482, "pull_request": {"number": 482}}`,
+			Expected: "482",
+		},
+		"number only": {
+			Payload:  `{"number": 482}`,
+			Expected: "482",
+		},
+		"invalid payload falls back to ref": {
+			Payload:  `{`,
+			Expected: "12",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "event.json")
+			require.NoError(t, os.WriteFile(path, []byte(test.Payload), 0600))
+
+			env := ci.DetectFrom(func(key string) string {
+				return map[string]string{
+					"GITHUB_ACTIONS":    "true",
+					"GITHUB_EVENT_NAME": "pull_request",
+					"GITHUB_EVENT_PATH": path,
+					"GITHUB_REF":        "refs/pull/12/merge",
+					"GITHUB_HEAD_REF":   "feature/some",
+					"GITHUB_BASE_REF":   "develop",
+				}[key]
+			})
+
+			assert.Equal(t, test.Expected, env.PullRequest)
+		})
+	}
+}
+
+func TestEnvironment_DestBranch(t *testing.T) {
+	assert.Equal(t, "develop", ci.Environment{Branch: "develop"}.DestBranch())
+	assert.Equal(t, "master", ci.Environment{Branch: "develop", TargetBranch: "master"}.DestBranch())
+}
@@ -0,0 +1,527 @@
+package git_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wakatime/semver-action/pkg/git"
+
+	"github.com/alecthomas/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readClient contains the read methods all backends implement.
+type readClient interface {
+	IsRepo(ctx context.Context) (bool, error)
+	IsShallow(ctx context.Context) (bool, error)
+	CurrentBranch(ctx context.Context) (string, error)
+	SourceBranch(ctx context.Context, commitHash string) (string, error)
+	LatestTag(ctx context.Context) (string, error)
+	Tags(ctx context.Context, reachableFrom string) ([]string, error)
+	AncestorTag(ctx context.Context, include, exclude, branch string) (string, error)
+	Commits(ctx context.Context, from, to string) ([]git.Commit, error)
+	TagCommit(ctx context.Context, name string) (string, error)
+	TagsAt(ctx context.Context, rev string) ([]string, error)
+	ResolveCommit(ctx context.Context, rev string) (string, error)
+	RemoteBranches(ctx context.Context, remote, rev string) ([]git.Branch, error)
+	CountCommits(ctx context.Context, from, to string) (int, error)
+}
+
+// nolint:gochecknoglobals
+var backends = []struct {
+	Name string
+	New  func(repoDir string) readClient
+}{
+	{Name: "exec", New: func(repoDir string) readClient { return git.NewGit(repoDir) }},
+	{Name: "native", New: func(repoDir string) readClient { return git.NewNative(repoDir) }},
+	{Name: "snapshot", New: func(repoDir string) readClient { return git.NewSnapshot(repoDir) }},
+}
+
+func TestConformance(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.Name, func(t *testing.T) {
+			t.Run("loose", func(t *testing.T) {
+				repo, shas := conformanceRepo(t)
+
+				testConformance(t, backend.New(repo), repo, shas)
+			})
+
+			t.Run("packed", func(t *testing.T) {
+				repo, shas := conformanceRepo(t)
+				runGit(t, repo, "gc", "--quiet", "--aggressive")
+
+				assert.Contains(t, runGit(t, repo, "count-objects", "-v"), "count: 0")
+				assert.Contains(t, verifyPack(t, repo), "chain length", "expected delta compressed objects")
+
+				testConformance(t, backend.New(repo), repo, shas)
+			})
+
+			t.Run("subdirectory", func(t *testing.T) {
+				repo, shas := conformanceRepo(t)
+
+				testConformance(t, backend.New(filepath.Join(repo, "src")), repo, shas)
+			})
+
+			t.Run("worktree", func(t *testing.T) {
+				repo, shas := conformanceRepo(t)
+
+				worktree := filepath.Join(t.TempDir(), "worktree")
+				runGit(t, repo, "worktree", "add", "--quiet", worktree, "master")
+
+				gc := backend.New(worktree)
+
+				assert.True(t, isRepo(t, gc))
+
+				branch, err := gc.CurrentBranch(context.Background())
+				require.NoError(t, err)
+
+				assert.Equal(t, "master", branch)
+
+				head, err := gc.ResolveCommit(context.Background(), "HEAD")
+				require.NoError(t, err)
+
+				assert.Equal(t, shas["h1"], head)
+				assert.Equal(t, "v1.2.0-pre.2", latestTag(t, gc))
+				assert.Equal(t, "v1.1.0", ancestorTag(t, gc, "v[0-9]*", "", "HEAD"))
+			})
+
+			t.Run("shallow", func(t *testing.T) {
+				repo, shas := conformanceRepo(t)
+
+				shallow := filepath.Join(t.TempDir(), "shallow")
+				runGit(t, "", "clone", "--quiet", "--depth=2", "--branch=develop", "file://"+repo, shallow)
+
+				gc := backend.New(shallow)
+
+				isShallow, err := gc.IsShallow(context.Background())
+				require.NoError(t, err)
+
+				assert.True(t, isShallow)
+				assert.Equal(t, shas["f5"], ancestorTag(t, gc, "v1.0.*", "", "HEAD"))
+
+				commits, err := gc.Commits(context.Background(), "", "HEAD")
+				require.NoError(t, err)
+
+				assert.Equal(t, []string{shas["m1"], shas["d1"]}, commitHashes(commits))
+			})
+
+			t.Run("detached", func(t *testing.T) {
+				repo, shas := conformanceRepo(t)
+				runGit(t, repo, "checkout", "--quiet", "--detach", "v1.0.0")
+
+				branch, err := backend.New(repo).CurrentBranch(context.Background())
+				require.NoError(t, err)
+
+				assert.Equal(t, "HEAD", branch)
+
+				head, err := backend.New(repo).ResolveCommit(context.Background(), "HEAD")
+				require.NoError(t, err)
+
+				assert.Equal(t, shas["c1"], head)
+			})
+
+			t.Run("remote branches", func(t *testing.T) {
+				repo, shas := conformanceRepo(t)
+
+				clone := filepath.Join(t.TempDir(), "clone")
+				runGit(t, "", "clone", "--quiet", "--branch=develop", "file://"+repo, clone)
+				runGit(t, clone, "checkout", "--quiet", "--detach", shas["c3"])
+
+				gc := backend.New(clone)
+
+				branches, err := gc.RemoteBranches(context.Background(), "origin", "HEAD")
+				require.NoError(t, err)
+
+				assert.Equal(t, []git.Branch{
+					{Name: "develop", Commit: shas["m1"]},
+					{Name: "feature/login", Commit: shas["f5"]},
+					{Name: "master", Commit: shas["h1"]},
+				}, branches)
+
+				branches, err = gc.RemoteBranches(context.Background(), "origin", shas["h1"])
+				require.NoError(t, err)
+
+				assert.Equal(t, []git.Branch{{Name: "master", Commit: shas["h1"]}}, branches)
+
+				branches, err = gc.RemoteBranches(context.Background(), "upstream", "HEAD")
+				require.NoError(t, err)
+
+				assert.Empty(t, branches)
+			})
+
+			t.Run("not a repository", func(t *testing.T) {
+				gc := backend.New(t.TempDir())
+
+				assert.False(t, isRepo(t, gc))
+				assert.Empty(t, latestTag(t, gc))
+
+				_, err := gc.ResolveCommit(context.Background(), "HEAD")
+				require.Error(t, err)
+			})
+
+			t.Run("no tags", func(t *testing.T) {
+				repo := filepath.Join(t.TempDir(), "repo")
+				runGit(t, "", "init", "--quiet", "--initial-branch=master", repo)
+				commitAt(t, repo, 1, "initial commit")
+
+				gc := backend.New(repo)
+
+				assert.Empty(t, latestTag(t, gc))
+				assert.Equal(t, runGit(t, repo, "rev-parse", "HEAD"), ancestorTag(t, gc, "v[0-9]*", "", "master"))
+
+				tags, err := gc.Tags(context.Background(), "")
+				require.NoError(t, err)
+
+				assert.Empty(t, tags)
+			})
+		})
+	}
+}
+
+func testConformance(t *testing.T, gc readClient, repo string, shas map[string]string) {
+	t.Run("IsRepo", func(t *testing.T) {
+		assert.True(t, isRepo(t, gc))
+	})
+
+	t.Run("IsShallow", func(t *testing.T) {
+		isShallow, err := gc.IsShallow(context.Background())
+		require.NoError(t, err)
+
+		assert.False(t, isShallow)
+	})
+
+	t.Run("CurrentBranch", func(t *testing.T) {
+		branch, err := gc.CurrentBranch(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, "develop", branch)
+	})
+
+	t.Run("SourceBranch", func(t *testing.T) {
+		branch, err := gc.SourceBranch(context.Background(), shas["m1"])
+		require.NoError(t, err)
+
+		assert.Equal(t, "feature/login", branch)
+
+		_, err = gc.SourceBranch(context.Background(), shas["c2"])
+		require.Error(t, err)
+
+		assert.True(t, errors.Is(err, git.ErrNoSourceBranch))
+		assert.EqualError(t, err, "no source branch found")
+
+		_, err = gc.SourceBranch(context.Background(), "")
+		require.Error(t, err)
+	})
+
+	t.Run("LatestTag", func(t *testing.T) {
+		assert.Equal(t, "v1.2.0-pre.2", latestTag(t, gc))
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		tags, err := gc.Tags(context.Background(), "")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"a-nightly", "latest", "v1.0.0", "v1.1.0", "v1.2.0-pre.1", "v1.2.0-pre.2"}, tags)
+
+		tags, err = gc.Tags(context.Background(), "master")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"latest", "v1.0.0", "v1.1.0"}, tags)
+
+		_, err = gc.Tags(context.Background(), "unknown")
+		require.Error(t, err)
+	})
+
+	t.Run("AncestorTag", func(t *testing.T) {
+		tests := []struct {
+			Include  string
+			Exclude  string
+			Branch   string
+			Expected string
+		}{
+			{Include: "v[0-9]*", Exclude: "v[0-9]*-pre*", Branch: "develop", Expected: "v1.1.0"},
+			{Include: "v[0-9]*-pre*", Branch: "develop", Expected: "v1.2.0-pre.2"},
+			{Include: "v[0-9]*-pre*", Branch: "develop^", Expected: "v1.2.0-pre.1"},
+			{Include: "v[0-9]*-pre*", Branch: shas["m1"] + "^", Expected: "v1.2.0-pre.1"},
+			{Include: "v[0-9]*", Branch: "master", Expected: "v1.1.0"},
+			{Include: "v[0-9]*", Branch: "master~2", Expected: "v1.0.0"},
+			{Include: "*", Branch: "feature/login", Expected: "v1.2.0-pre.1"},
+			{Include: "*", Branch: "develop", Expected: "v1.2.0-pre.2"},
+			{Include: "x*", Branch: "develop", Expected: shas["c1"]},
+			{Include: "v[0-9]*", Branch: "unknown", Expected: shas["c1"]},
+		}
+
+		for _, test := range tests {
+			name := fmt.Sprintf("%s %s %s", test.Include, test.Exclude, test.Branch)
+
+			t.Run(name, func(t *testing.T) {
+				assert.Equal(t, test.Expected, ancestorTag(t, gc, test.Include, test.Exclude, test.Branch))
+			})
+		}
+	})
+
+	t.Run("Commits", func(t *testing.T) {
+		commits, err := gc.Commits(context.Background(), "v1.1.0", "develop")
+		require.NoError(t, err)
+
+		assert.Equal(t, []git.Commit{
+			{
+				Hash:      shas["m1"],
+				ShortHash: runGit(t, repo, "rev-parse", "--short", shas["m1"]),
+				Author:    "John Doe",
+				Subject:   "Merge pull request #12 from wakatime/feature/login",
+				Body:      "Add login",
+			},
+			{
+				Hash:      shas["d1"],
+				ShortHash: runGit(t, repo, "rev-parse", "--short", shas["d1"]),
+				Author:    "John Doe",
+				Subject:   "feat: add logout",
+			},
+		}, commits)
+
+		commits, err = gc.Commits(context.Background(), "v1.2.0-pre.1", "feature/login")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{shas["f5"], shas["f4"], shas["f3"], shas["f2"], shas["f1"]}, commitHashes(commits))
+		assert.Equal(t, "feat: login step 1 with a subject on two lines", commits[4].Subject)
+		assert.True(t, strings.HasPrefix(commits[4].Body, "Lorem ipsum"))
+
+		commits, err = gc.Commits(context.Background(), "", "master")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{shas["h1"], shas["c3"], shas["c2"], shas["c1"]}, commitHashes(commits))
+		assert.Equal(t, "The config file may be empty.", commits[2].Body)
+
+		commits, err = gc.Commits(context.Background(), "develop", "master")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{shas["h1"]}, commitHashes(commits))
+
+		commits, err = gc.Commits(context.Background(), "develop", "develop")
+		require.NoError(t, err)
+
+		assert.Empty(t, commits)
+
+		_, err = gc.Commits(context.Background(), "unknown", "develop")
+		require.Error(t, err)
+	})
+
+	t.Run("CountCommits", func(t *testing.T) {
+		tests := []struct {
+			From     string
+			To       string
+			Expected int
+		}{
+			{From: "master", To: "develop", Expected: 6},
+			{From: "v1.1.0", To: "master", Expected: 1},
+			{From: "feature/login", To: "develop", Expected: 0},
+			{From: "develop", To: "feature/login", Expected: 0},
+			{From: "v1.2.0-pre.1", To: shas["m1"], Expected: 5},
+			{From: shas["m1"] + "^1", To: shas["m1"], Expected: 5},
+			{From: "", To: "feature/login", Expected: 9},
+		}
+
+		for _, test := range tests {
+			t.Run(test.From+".."+test.To, func(t *testing.T) {
+				count, err := gc.CountCommits(context.Background(), test.From, test.To)
+				require.NoError(t, err)
+
+				assert.Equal(t, test.Expected, count)
+			})
+		}
+
+		_, err := gc.CountCommits(context.Background(), "unknown", "develop")
+		require.Error(t, err)
+	})
+
+	t.Run("TagCommit", func(t *testing.T) {
+		assert.Equal(t, shas["c1"], tagCommit(t, gc, "v1.0.0"))
+		assert.Equal(t, shas["c3"], tagCommit(t, gc, "v1.1.0"))
+		assert.Equal(t, shas["m1"], tagCommit(t, gc, "a-nightly"))
+		assert.Empty(t, tagCommit(t, gc, "v9.9.9"))
+	})
+
+	t.Run("TagsAt", func(t *testing.T) {
+		tags, err := gc.TagsAt(context.Background(), shas["c3"])
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"latest", "v1.1.0"}, tags)
+
+		tags, err = gc.TagsAt(context.Background(), "HEAD")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"a-nightly", "v1.2.0-pre.2"}, tags)
+
+		tags, err = gc.TagsAt(context.Background(), shas["h1"])
+		require.NoError(t, err)
+
+		assert.Empty(t, tags)
+
+		_, err = gc.TagsAt(context.Background(), "unknown")
+		require.Error(t, err)
+	})
+
+	t.Run("ResolveCommit", func(t *testing.T) {
+		tests := map[string]string{
+			"HEAD":                     shas["m1"],
+			"develop":                  shas["m1"],
+			"develop^":                 shas["d1"],
+			"develop^2":                shas["f5"],
+			"develop^2~4":              shas["f1"],
+			"master~2":                 shas["c2"],
+			"v1.0.0":                   shas["c1"],
+			"v1.0.0^{}":                shas["c1"],
+			"refs/tags/v1.2.0-pre.2":   shas["m1"],
+			"heads/feature/login":      shas["f5"],
+			"refs/heads/feature/login": shas["f5"],
+			shas["c2"]:                 shas["c2"],
+			shas["c2"][:10]:            shas["c2"],
+		}
+
+		for rev, expected := range tests {
+			t.Run(rev, func(t *testing.T) {
+				commit, err := gc.ResolveCommit(context.Background(), rev)
+				require.NoError(t, err)
+
+				assert.Equal(t, expected, commit)
+			})
+		}
+
+		for _, rev := range []string{"unknown", "master~10", "develop^3", ""} {
+			t.Run("invalid "+rev, func(t *testing.T) {
+				_, err := gc.ResolveCommit(context.Background(), rev)
+				require.Error(t, err)
+			})
+		}
+	})
+}
+
+// conformanceRepo creates a repository with a master, develop and feature
+// branch, annotated and lightweight tags and a merged pull request. Commit
+// dates are fixed, so the shas are the same on every run.
+//
+//	master:  c1 (v1.0.0) - c2 - c3 (v1.1.0, latest) - h1
+//	develop: c3 - d1 (v1.2.0-pre.1) - m1 (v1.2.0-pre.2, a-nightly)
+//	feature/login: d1 - f1 - f2 - f3 - f4 - f5, merged into develop by m1
+func conformanceRepo(t *testing.T) (string, map[string]string) {
+	repo := filepath.Join(t.TempDir(), "repo")
+	shas := map[string]string{}
+
+	runGit(t, "", "init", "--quiet", "--initial-branch=master", repo)
+	runGit(t, repo, "config", "gc.auto", "0")
+
+	n := 0
+	commit := func(name string, messages ...string) {
+		n++
+		shas[name] = commitAt(t, repo, n, messages...)
+	}
+
+	commit("c1", "initial commit")
+	runGit(t, repo, "tag", "-a", "v1.0.0", "-m", "release v1.0.0")
+	commit("c2", "fix: handle empty config", "The config file may be empty.")
+	commit("c3", "feat: add login form")
+	runGit(t, repo, "tag", "v1.1.0")
+	runGit(t, repo, "tag", "latest")
+	commit("h1", "fix: crash on start (#13)")
+
+	runGit(t, repo, "checkout", "--quiet", "-b", "develop", shas["c3"])
+	commit("d1", "feat: add logout")
+	runGit(t, repo, "tag", "v1.2.0-pre.1")
+
+	runGit(t, repo, "checkout", "--quiet", "-b", "feature/login")
+
+	body := strings.Repeat("Lorem ipsum dolor sit amet, consectetur adipiscing elit. ", 40)
+
+	commit("f1", "feat: login step 1 with a subject\non two lines", body)
+
+	for i := 2; i <= 5; i++ {
+		commit(fmt.Sprintf("f%d", i), fmt.Sprintf("feat: login step %d", i), body+fmt.Sprintf("Step %d.", i))
+	}
+
+	runGit(t, repo, "checkout", "--quiet", "develop")
+
+	n++
+	setCommitDate(t, n)
+	runGit(t, repo, "merge", "--quiet", "--no-ff", "feature/login",
+		"-m", "Merge pull request #12 from wakatime/feature/login", "-m", "Add login")
+	shas["m1"] = runGit(t, repo, "rev-parse", "HEAD")
+
+	runGit(t, repo, "tag", "-a", "v1.2.0-pre.2", "-m", "release v1.2.0-pre.2")
+	runGit(t, repo, "tag", "a-nightly")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "src"), 0750))
+
+	return repo, shas
+}
+
+// commitAt creates an empty commit with a fixed date n minutes after a base date.
+func commitAt(t *testing.T, repo string, n int, messages ...string) string {
+	setCommitDate(t, n)
+
+	args := []string{"commit", "--quiet", "--allow-empty"}
+	for _, m := range messages {
+		args = append(args, "-m", m)
+	}
+
+	runGit(t, repo, args...)
+
+	return runGit(t, repo, "rev-parse", "HEAD")
+}
+
+func setCommitDate(t *testing.T, n int) {
+	date := fmt.Sprintf("%d +0000", 1600000000+n*60)
+
+	t.Setenv("GIT_AUTHOR_DATE", date)
+	t.Setenv("GIT_COMMITTER_DATE", date)
+}
+
+func verifyPack(t *testing.T, repo string) string {
+	packs, err := filepath.Glob(filepath.Join(repo, ".git", "objects", "pack", "*.idx"))
+	require.NoError(t, err)
+	require.NotEmpty(t, packs)
+
+	return runGit(t, repo, append([]string{"verify-pack", "-v"}, packs...)...)
+}
+
+func commitHashes(commits []git.Commit) []string {
+	hashes := make([]string, 0, len(commits))
+	for _, c := range commits {
+		hashes = append(hashes, c.Hash)
+	}
+
+	return hashes
+}
+
+func isRepo(t testing.TB, gc readClient) bool {
+	ok, err := gc.IsRepo(context.Background())
+	require.NoError(t, err)
+
+	return ok
+}
+
+func latestTag(t testing.TB, gc readClient) string {
+	tag, err := gc.LatestTag(context.Background())
+	require.NoError(t, err)
+
+	return tag
+}
+
+func ancestorTag(t testing.TB, gc readClient, include, exclude, branch string) string {
+	tag, err := gc.AncestorTag(context.Background(), include, exclude, branch)
+	require.NoError(t, err)
+
+	return tag
+}
+
+func tagCommit(t testing.TB, gc readClient, name string) string {
+	sha, err := gc.TagCommit(context.Background(), name)
+	require.NoError(t, err)
+
+	return sha
+}
@@ -0,0 +1,414 @@
+package git
+
+import (
+	"errors"
+	"sort"
+)
+
+// describeMaxCandidates is the number of candidate tags considered by
+// `git describe` before it gives up searching.
+const describeMaxCandidates = 10
+
+// commitSource returns parsed commits by sha.
+type commitSource interface {
+	commit(h hash) (*commitObject, error)
+}
+
+// tagRef is a tag with the commit it points at.
+type tagRef struct {
+	name       string
+	commit     hash
+	annotated  bool
+	taggerTime int64
+}
+
+// tagName is the name chosen for a tagged commit, see add_to_known_names in
+// git's builtin/describe.c.
+type tagName struct {
+	name       string
+	prio       int
+	taggerTime int64
+}
+
+// knownNames returns the tags matching the patterns by the commit they point
+// at. Annotated tags win over lightweight tags and newer annotated tags win
+// over older ones. The tags must be sorted by name.
+func knownNames(tags []tagRef, include, exclude string) map[hash]*tagName {
+	names := make(map[hash]*tagName)
+
+	for _, tag := range tags {
+		if exclude != "" && wildmatch(exclude, tag.name) {
+			continue
+		}
+
+		if include != "" && !wildmatch(include, tag.name) {
+			continue
+		}
+
+		prio := 1
+		if tag.annotated {
+			prio = 2
+		}
+
+		e, ok := names[tag.commit]
+		if ok && (e.prio > prio || (e.prio == prio && (prio == 1 || e.taggerTime >= tag.taggerTime))) {
+			continue
+		}
+
+		names[tag.commit] = &tagName{name: tag.name, prio: prio, taggerTime: tag.taggerTime}
+	}
+
+	return names
+}
+
+// latestTag returns the name of the tag on the most recently committed tagged
+// commit like `git describe --tags $(git rev-list --tags --max-count=1)`.
+func latestTag(src commitSource, tags []tagRef) (string, error) {
+	var latest *commitObject
+
+	for _, tag := range tags {
+		c, err := src.commit(tag.commit)
+		if err != nil {
+			// tags of trees and blobs are ignored
+			continue
+		}
+
+		if latest == nil || c.committerTime > latest.committerTime {
+			latest = c
+		}
+	}
+
+	if latest == nil {
+		return "", errors.New("no tags found")
+	}
+
+	return knownNames(tags, "", "")[latest.hash].name, nil
+}
+
+// describe returns the nearest tag reachable from the commit like
+// `git describe --tags --abbrev=0 --match include --exclude exclude`.
+func describe(src commitSource, tags []tagRef, start hash, include, exclude string) (string, error) {
+	names := knownNames(tags, include, exclude)
+
+	if n, ok := names[start]; ok {
+		return n.name, nil
+	}
+
+	type candidate struct {
+		name       *tagName
+		depth      int
+		flag       uint
+		foundOrder int
+	}
+
+	const seen uint = 1
+
+	var (
+		candidates   []*candidate
+		annotatedCnt int
+		seenCommits  int
+	)
+
+	flags := map[hash]uint{start: seen}
+
+	queue := &dateQueue{}
+	if err := queue.push(src, start); err != nil {
+		return "", err
+	}
+
+	for queue.len() > 0 {
+		c := queue.pop()
+		seenCommits++
+
+		if n, ok := names[c.hash]; ok {
+			if len(candidates) == describeMaxCandidates {
+				break
+			}
+
+			t := &candidate{
+				name:       n,
+				depth:      seenCommits - 1,
+				flag:       1 << (len(candidates) + 1),
+				foundOrder: len(candidates) + 1,
+			}
+			candidates = append(candidates, t)
+			flags[c.hash] |= t.flag
+
+			if n.prio == 2 {
+				annotatedCnt++
+			}
+		}
+
+		for _, t := range candidates {
+			if flags[c.hash]&t.flag == 0 {
+				t.depth++
+			}
+		}
+
+		if annotatedCnt > 0 && queue.len() == 0 {
+			break
+		}
+
+		for _, p := range c.parents {
+			if flags[p]&seen == 0 {
+				if err := queue.push(src, p); err != nil {
+					return "", err
+				}
+			}
+
+			flags[p] |= flags[c.hash]
+		}
+	}
+
+	if len(candidates) == 0 {
+		return "", errors.New("no tags can describe the commit")
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].depth != candidates[j].depth {
+			return candidates[i].depth < candidates[j].depth
+		}
+
+		return candidates[i].foundOrder < candidates[j].foundOrder
+	})
+
+	return candidates[0].name.name, nil
+}
+
+// rootCommit returns the first commit without parents in the order
+// `git rev-list --max-parents=0` prints them.
+func rootCommit(src commitSource, start hash) (hash, error) {
+	seen := map[hash]bool{start: true}
+
+	queue := &dateQueue{}
+	if err := queue.push(src, start); err != nil {
+		return hash{}, err
+	}
+
+	for queue.len() > 0 {
+		c := queue.pop()
+
+		if len(c.parents) == 0 {
+			return c.hash, nil
+		}
+
+		for _, p := range c.parents {
+			if seen[p] {
+				continue
+			}
+
+			seen[p] = true
+
+			if err := queue.push(src, p); err != nil {
+				return hash{}, err
+			}
+		}
+	}
+
+	return hash{}, errors.New("no root commit found")
+}
+
+// ancestors returns the commit and all commits reachable from it.
+func ancestors(src commitSource, start hash) (map[hash]bool, error) {
+	result := map[hash]bool{start: true}
+	stack := []hash{start}
+
+	for len(stack) > 0 {
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		c, err := src.commit(h)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range c.parents {
+			if !result[p] {
+				result[p] = true
+				stack = append(stack, p)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// reaches returns true if the target is the start commit or one of its
+// ancestors. Commits in unreachable are known not to lead to the target and
+// are skipped. It is filled when the target is not found, so it can be shared
+// by calls with the same target.
+func reaches(src commitSource, start, target hash, unreachable map[hash]bool) (bool, error) {
+	visited := map[hash]bool{start: true}
+	stack := []hash{start}
+
+	for len(stack) > 0 {
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if h == target {
+			return true, nil
+		}
+
+		if unreachable[h] {
+			continue
+		}
+
+		c, err := src.commit(h)
+		if err != nil {
+			return false, err
+		}
+
+		for _, p := range c.parents {
+			if !visited[p] {
+				visited[p] = true
+				stack = append(stack, p)
+			}
+		}
+	}
+
+	for h := range visited {
+		unreachable[h] = true
+	}
+
+	return false, nil
+}
+
+// dateQueue orders commits by committer date, newest first. Commits with the
+// same date keep their insertion order like git's commit_list_insert_by_date.
+type dateQueue struct {
+	commits []*commitObject
+}
+
+func (q *dateQueue) push(src commitSource, h hash) error {
+	c, err := src.commit(h)
+	if err != nil {
+		return err
+	}
+
+	i := sort.Search(len(q.commits), func(i int) bool {
+		return q.commits[i].committerTime < c.committerTime
+	})
+
+	q.commits = append(q.commits, nil)
+	copy(q.commits[i+1:], q.commits[i:])
+	q.commits[i] = c
+
+	return nil
+}
+
+func (q *dateQueue) pop() *commitObject {
+	c := q.commits[0]
+	q.commits = q.commits[1:]
+
+	return c
+}
+
+func (q *dateQueue) len() int {
+	return len(q.commits)
+}
+
+// wildmatch matches a tag name against a glob pattern like git's wildmatch
+// without WM_PATHNAME, so `*` also matches slashes.
+func wildmatch(pattern, name string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+
+			if pattern == "" {
+				return true
+			}
+
+			for i := 0; i <= len(name); i++ {
+				if wildmatch(pattern, name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		case '?':
+			if name == "" {
+				return false
+			}
+
+			pattern, name = pattern[1:], name[1:]
+		case '[':
+			if name == "" {
+				return false
+			}
+
+			matched, rest, ok := matchClass(pattern, name[0])
+			if !ok {
+				if name[0] != '[' {
+					return false
+				}
+
+				pattern, name = pattern[1:], name[1:]
+
+				continue
+			}
+
+			if !matched {
+				return false
+			}
+
+			pattern, name = rest, name[1:]
+		default:
+			if pattern[0] == '\\' && len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+
+			if name == "" || pattern[0] != name[0] {
+				return false
+			}
+
+			pattern, name = pattern[1:], name[1:]
+		}
+	}
+
+	return name == ""
+}
+
+// matchClass matches a character against the bracket expression at the start
+// of the pattern. It returns the rest of the pattern and false if the bracket
+// is not closed.
+func matchClass(pattern string, c byte) (bool, string, bool) {
+	i := 1
+	negate := false
+
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		negate = true
+		i++
+	}
+
+	matched := false
+
+	for first := true; i < len(pattern); first = false {
+		if pattern[i] == ']' && !first {
+			return matched != negate, pattern[i+1:], true
+		}
+
+		lo := pattern[i]
+		if lo == '\\' && i+1 < len(pattern) {
+			i++
+			lo = pattern[i]
+		}
+
+		hi := lo
+
+		if i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']' {
+			hi = pattern[i+2]
+			i += 2
+		}
+
+		if lo <= c && c <= hi {
+			matched = true
+		}
+
+		i++
+	}
+
+	return false, "", false
+}
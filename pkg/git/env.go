@@ -0,0 +1,77 @@
+package git
+
+import (
+	"os"
+	"sort"
+)
+
+// nolint: gochecknoglobals
+var (
+	// inheritedEnv are the environment variables passed on to git. Everything
+	// else, like GIT_DIR or GIT_CONFIG_PARAMETERS, is dropped so the output
+	// doesn't depend on the environment the tool runs in.
+	inheritedEnv = []string{
+		// locating git and the global config
+		"PATH", "HOME", "XDG_CONFIG_HOME", "GIT_CONFIG_GLOBAL", "GIT_EXEC_PATH",
+		"USER", "LOGNAME", "TMPDIR",
+		// identity of created tags
+		"GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL", "GIT_AUTHOR_DATE",
+		"GIT_COMMITTER_NAME", "GIT_COMMITTER_EMAIL", "GIT_COMMITTER_DATE",
+		// signing tags
+		"GNUPGHOME", "GPG_TTY",
+		// authentication and transport for fetch and push
+		"GIT_ASKPASS", "GIT_SSH", "GIT_SSH_COMMAND", "GIT_SSH_VARIANT", "SSH_AUTH_SOCK",
+		"GIT_SSL_CAINFO", "SSL_CERT_FILE", "SSL_CERT_DIR",
+		"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "ALL_PROXY",
+		"http_proxy", "https_proxy", "no_proxy", "all_proxy",
+		// windows
+		"SystemRoot", "ComSpec", "PATHEXT", "TEMP", "TMP",
+		"USERPROFILE", "HOMEDRIVE", "HOMEPATH", "APPDATA", "LOCALAPPDATA", "ProgramData",
+	}
+	// fixedEnv makes the output of git stable. It is applied after the
+	// inherited variables.
+	fixedEnv = []string{
+		// ignore /etc/gitconfig
+		"GIT_CONFIG_NOSYSTEM=1",
+		// untranslated messages and C collation
+		"LC_ALL=C",
+		// fail instead of prompting for credentials
+		"GIT_TERMINAL_PROMPT=0",
+	}
+	// fixedConfig overrides user config changing the output of the commands
+	// that are parsed.
+	fixedConfig = []string{
+		"-c", "log.showSignature=false",
+		"-c", "log.decorate=false",
+		"-c", "tag.sort=refname",
+		"-c", "column.ui=never",
+		"-c", "color.ui=false",
+	}
+)
+
+// gitEnv returns the environment git runs with. It starts from the inherited
+// variables and the fixed ones, the extra variables are merged on top.
+func gitEnv(extra map[string]string) []string {
+	var env []string
+
+	for _, name := range inheritedEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+
+	env = append(env, fixedEnv...)
+
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		env = append(env, name+"="+extra[name])
+	}
+
+	return env
+}
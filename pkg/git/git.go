@@ -2,22 +2,80 @@ package git
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/apex/log"
 )
 
 var mergePRRegex = regexp.MustCompile(`Merge pull request #([0-9])+ from (?P<source>.*)+`) // nolint
 
+// pushRejectedRegex matches local rejections of a push because the remote tag already
+// exists or the local ref is stale. Remote rejections, e.g. by hooks, are not matched.
+var pushRejectedRegex = regexp.MustCompile( // nolint
+	`\[rejected\].*\((already exists|stale info|fetch first|non-fast-forward)\)`)
+
+// ErrPushRejected is returned when the remote rejects a pushed ref.
+var ErrPushRejected = errors.New("push rejected by remote") // nolint
+
+// ErrNotRepository is returned when the directory is not inside a git repository.
+var ErrNotRepository = errors.New("not a git repository") // nolint
+
+// ErrUnsupportedRepository is returned by the native backend for repositories
+// it can't read, e.g. with sha256 object names or reftable refs.
+var ErrUnsupportedRepository = errors.New("unsupported repository format") // nolint
+
+// ErrNoSourceBranch is returned when the commit message is not the merge of a
+// pull request, so the source branch is unknown.
+var ErrNoSourceBranch = errors.New("no source branch found") // nolint
+
+// Commit contains the details of a single commit.
+type Commit struct {
+	Hash      string
+	ShortHash string
+	Author    string
+	Subject   string
+	Body      string
+}
+
+// TagOptions contains the options to create a tag.
+type TagOptions struct {
+	Name string
+	Ref  string
+	// Message creates an annotated tag when not empty.
+	Message string
+	// Sign creates a signed tag. SigningKey and SigningFormat are optional
+	// and fall back to the git configuration.
+	Sign          bool
+	SigningKey    string
+	SigningFormat string
+}
+
+// Branch is a branch and the commit it points at.
+type Branch struct {
+	Name   string
+	Commit string
+}
+
+// waitDelay is how long to wait for the output of a killed git command. A
+// child process like a credential helper may keep it open.
+const waitDelay = 5 * time.Second
+
 // Client is an empty struct to run git.
 type Client struct {
 	repoDir string
-	GitCmd  func(env map[string]string, args ...string) (string, error)
+	// safeDirectory is passed as safe.directory to every command once set by MakeSafe.
+	safeDirectory string
+	// Timeout limits how long a single git command may run. Zero means no limit.
+	Timeout time.Duration
+	GitCmd  func(ctx context.Context, env map[string]string, args ...string) (string, error)
 }
 
 // NewGit creates a new git instance.
@@ -29,21 +87,18 @@ func NewGit(repoDir string) *Client {
 }
 
 // gitCmdFn runs a git command with the specified env vars and returns its output or errors.
-func gitCmdFn(env map[string]string, args ...string) (string, error) {
-	var extraArgs = []string{
-		"-c", "log.showSignature=false",
-	}
+// The command is killed when the context is done. Git runs in a controlled
+// environment, see gitEnv, and never prompts for credentials, so a missing
+// credential fails the command instead of hanging.
+func gitCmdFn(ctx context.Context, env map[string]string, args ...string) (string, error) {
+	invocation := "git " + strings.Join(args, " ")
 
-	args = append(extraArgs, args...)
+	args = append(append([]string{}, fixedConfig...), args...)
 	/* #nosec */
-	var cmd = exec.Command("git", args...)
+	var cmd = exec.CommandContext(ctx, "git", args...)
 
-	if env != nil {
-		cmd.Env = []string{}
-		for k, v := range env {
-			cmd.Env = append(cmd.Env, k+"="+v)
-		}
-	}
+	cmd.Env = gitEnv(env)
+	cmd.WaitDelay = waitDelay
 
 	stdout := bytes.Buffer{}
 	stderr := bytes.Buffer{}
@@ -59,53 +114,134 @@ func gitCmdFn(env map[string]string, args ...string) (string, error) {
 		WithField("stderr", stderr.String()).
 		Debug("git result")
 
-	if err != nil {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "", fmt.Errorf("%s timed out: %w", invocation, ctx.Err())
+	case errors.Is(ctx.Err(), context.Canceled):
+		return "", fmt.Errorf("%s was canceled: %w", invocation, ctx.Err())
+	case err != nil:
 		return "", errors.New(stderr.String())
 	}
 
 	return stdout.String(), nil
 }
 
+// isDone returns true if the error was caused by a timeout or cancellation.
+// Queries that treat a failing command as not found still return these.
+func isDone(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
+}
+
 // Clean the output.
 func (Client) Clean(output string, err error) (string, error) {
 	output = strings.ReplaceAll(strings.Split(output, "\n")[0], "'", "")
 
-	if err != nil {
+	if err != nil && strings.HasSuffix(err.Error(), "\n") {
 		err = errors.New(strings.TrimSuffix(err.Error(), "\n"))
 	}
 
 	return output, err
 }
 
-// Run runs a git command and returns its output or errors.
-func (c *Client) Run(args ...string) (string, error) {
-	return c.GitCmd(nil, args...)
+// Run runs a git command and returns its output or errors. The command is
+// killed when it takes longer than Timeout or the context is done.
+func (c *Client) Run(ctx context.Context, args ...string) (string, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	if c.safeDirectory != "" {
+		args = append([]string{"-c", "safe.directory=" + c.safeDirectory}, args...)
+	}
+
+	return c.GitCmd(ctx, nil, args...)
 }
 
-// MakeSafe adds safe.directory global config.
-func (c *Client) MakeSafe() error {
+// MakeSafe trusts the repository for all further commands of the client,
+// even if it is owned by another user. safe.directory is passed on the
+// command line, the git config is not changed.
+func (c *Client) MakeSafe(_ context.Context) error {
 	dir, err := filepath.Abs(c.repoDir)
 	if err != nil {
 		return fmt.Errorf("failed to get absolute path for: %s", c.repoDir)
 	}
 
-	_, err = c.Run("config", "--global", "--add", "safe.directory", dir)
+	c.safeDirectory = dir
+
+	return nil
+}
+
+// AddSafeDirectory adds the repository to the safe.directory global config,
+// so git commands run by other tools trust it too. Nothing is added if the
+// directory is already listed.
+func (c *Client) AddSafeDirectory(ctx context.Context) error {
+	dir, err := filepath.Abs(c.repoDir)
 	if err != nil {
-		return fmt.Errorf("failed to set safe current directory")
+		return fmt.Errorf("failed to get absolute path for: %s", c.repoDir)
+	}
+
+	// exits with 1 if the key is not set
+	out, _ := c.Run(ctx, "config", "--global", "--get-all", "safe.directory")
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) == dir {
+			return nil
+		}
+	}
+
+	_, err = c.Run(ctx, "config", "--global", "--add", "safe.directory", dir)
+	if err != nil {
+		return fmt.Errorf("failed to add safe directory to global config: %s", err)
 	}
 
 	return nil
 }
 
-// IsRepo returns true if current folder is a git repository.
-func (c *Client) IsRepo() bool {
-	out, err := c.Run("rev-parse", "--is-inside-work-tree")
-	return err == nil && strings.TrimSpace(out) == "true"
+// IsRepo returns true if current folder is a git repository. An error is only
+// returned if git timed out or was canceled.
+func (c *Client) IsRepo(ctx context.Context) (bool, error) {
+	out, err := c.Run(ctx, "-C", c.repoDir, "rev-parse", "--is-inside-work-tree")
+	if isDone(err) {
+		return false, err
+	}
+
+	return err == nil && strings.TrimSpace(out) == "true", nil
+}
+
+// IsShallow returns true if the repository is a shallow clone.
+func (c *Client) IsShallow(ctx context.Context) (bool, error) {
+	out, err := c.Clean(c.Run(ctx, "-C", c.repoDir, "rev-parse", "--is-shallow-repository"))
+	if err != nil {
+		return false, fmt.Errorf("could not check for shallow repository: %s", err)
+	}
+
+	return out == "true", nil
+}
+
+// Deepen fetches the given number of additional commits and the tags from the remote.
+func (c *Client) Deepen(ctx context.Context, remote string, depth int) error {
+	_, err := c.Clean(c.Run(ctx, "-C", c.repoDir, "fetch", "--quiet", "--tags", fmt.Sprintf("--deepen=%d", depth), remote))
+	if err != nil {
+		return fmt.Errorf("could not deepen history from %s: %s", remote, err)
+	}
+
+	return nil
+}
+
+// Unshallow fetches the complete history and the tags from the remote.
+func (c *Client) Unshallow(ctx context.Context, remote string) error {
+	if _, err := c.Clean(c.Run(ctx, "-C", c.repoDir, "fetch", "--quiet", "--tags", "--unshallow", remote)); err != nil {
+		return fmt.Errorf("could not unshallow history from %s: %s", remote, err)
+	}
+
+	return nil
 }
 
 // CurrentBranch returns the current branch checked out.
-func (c *Client) CurrentBranch() (string, error) {
-	dest, err := c.Clean(c.Run("-C", c.repoDir, "rev-parse", "--abbrev-ref", "HEAD", "--quiet"))
+func (c *Client) CurrentBranch(ctx context.Context) (string, error) {
+	dest, err := c.Clean(c.Run(ctx, "-C", c.repoDir, "rev-parse", "--abbrev-ref", "HEAD", "--quiet"))
 	if err != nil {
 		return "", fmt.Errorf("could not get current branch: %s", err)
 	}
@@ -114,12 +250,18 @@ func (c *Client) CurrentBranch() (string, error) {
 }
 
 // SourceBranch tries to get branch from commit message.
-func (c *Client) SourceBranch(commitHash string) (string, error) {
-	message, err := c.Clean(c.Run("-C", c.repoDir, "log", "-1", "--pretty=%B", commitHash))
+func (c *Client) SourceBranch(ctx context.Context, commitHash string) (string, error) {
+	message, err := c.Clean(c.Run(ctx, "-C", c.repoDir, "log", "-1", "--pretty=%B", commitHash))
 	if err != nil {
 		return "", fmt.Errorf("could not get message from commit: %s", err)
 	}
 
+	return sourceBranchFromMessage(message)
+}
+
+// sourceBranchFromMessage returns the source branch of a merged pull request
+// from the first line of the commit message.
+func sourceBranchFromMessage(message string) (string, error) {
 	match := mergePRRegex.FindStringSubmatch(message)
 
 	paramsMap := make(map[string]string)
@@ -131,38 +273,302 @@ func (c *Client) SourceBranch(commitHash string) (string, error) {
 	}
 
 	if len(paramsMap) == 0 || paramsMap["source"] == "" {
-		return "", errors.New("no source branch found")
+		return "", ErrNoSourceBranch
 	}
 
 	splitted := strings.SplitN(paramsMap["source"], "/", 2)
 
 	if len(splitted) < 2 {
-		return "", fmt.Errorf("commit message does not contain expected format: %s", paramsMap["source"])
+		return "", fmt.Errorf("%w, commit message does not contain expected format: %s", ErrNoSourceBranch, paramsMap["source"])
 	}
 
 	return splitted[1], nil
 }
 
-// LatestTag returns the latest tag if found.
-func (c *Client) LatestTag() string {
-	var result string
+// LatestTag returns the latest tag if found. An error is only returned when
+// git timed out or was canceled.
+func (c *Client) LatestTag(ctx context.Context) (string, error) {
+	commitSha, err := c.Clean(c.Run(ctx, "-C", c.repoDir, "rev-list", "--tags", "--max-count=1"))
+	if isDone(err) {
+		return "", err
+	}
+
+	if commitSha == "" {
+		return "", nil
+	}
+
+	result, err := c.Clean(c.Run(ctx, "-C", c.repoDir, "describe", "--tags", commitSha))
+	if isDone(err) {
+		return "", err
+	}
+
+	return result, nil
+}
 
-	commitSha, _ := c.Clean(c.Run("-C", c.repoDir, "rev-list", "--tags", "--max-count=1"))
-	if commitSha != "" {
-		result, _ = c.Clean(c.Run("-C", c.repoDir, "describe", "--tags", commitSha))
+// Tags returns all tags with a single for-each-ref call. If reachableFrom is
+// not empty only tags reachable from that revision are returned.
+func (c *Client) Tags(ctx context.Context, reachableFrom string) ([]string, error) {
+	args := []string{"-C", c.repoDir, "for-each-ref", "--format=%(refname:strip=2)"}
+	if reachableFrom != "" {
+		args = append(args, "--merged", reachableFrom)
 	}
 
-	return result
+	out, err := c.Run(ctx, append(args, "refs/tags")...)
+	if err != nil {
+		return nil, fmt.Errorf("could not list tags: %s", strings.TrimSuffix(err.Error(), "\n"))
+	}
+
+	return strings.Fields(out), nil
 }
 
 // AncestorTag returns the previous tag that matches specific pattern if found.
-func (c *Client) AncestorTag(include, exclude, branch string) string {
-	result, _ := c.Clean(c.Run(
+// The root commit is returned when no tag matches. An error is only returned
+// when git timed out or was canceled.
+func (c *Client) AncestorTag(ctx context.Context, include, exclude, branch string) (string, error) {
+	result, err := c.Clean(c.Run(ctx,
 		"-C", c.repoDir, "describe", "--tags", "--abbrev=0",
 		"--match", include, "--exclude", exclude, branch))
+	if isDone(err) {
+		return "", err
+	}
+
 	if result == "" {
-		result, _ = c.Clean(c.Run("-C", c.repoDir, "rev-list", "--max-parents=0", "HEAD"))
+		result, err = c.Clean(c.Run(ctx, "-C", c.repoDir, "rev-list", "--max-parents=0", "HEAD"))
+		if isDone(err) {
+			return "", err
+		}
 	}
 
-	return result
+	return result, nil
+}
+
+// Commits returns the first-parent commits reachable from `to` but not from `from`.
+// If `from` is empty all commits reachable from `to` are returned.
+func (c *Client) Commits(ctx context.Context, from, to string) ([]Commit, error) {
+	rev := to
+	if from != "" {
+		rev = from + ".." + to
+	}
+
+	out, err := c.Run(ctx,
+		"-C", c.repoDir, "log", "--first-parent",
+		"--format=%H%x1f%h%x1f%an%x1f%s%x1f%b%x1e", rev)
+	if err != nil {
+		return nil, fmt.Errorf("could not get commits for %s: %s", rev, strings.TrimSuffix(err.Error(), "\n"))
+	}
+
+	var commits []Commit
+
+	for _, record := range strings.Split(out, "\x1e") {
+		record = strings.Trim(record, "\n")
+		if record == "" {
+			continue
+		}
+
+		fields := strings.SplitN(record, "\x1f", 5)
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("unexpected log format: %q", record)
+		}
+
+		commits = append(commits, Commit{
+			Hash:      fields[0],
+			ShortHash: fields[1],
+			Author:    fields[2],
+			Subject:   fields[3],
+			Body:      strings.TrimSpace(fields[4]),
+		})
+	}
+
+	return commits, nil
+}
+
+// CreateTag creates a lightweight tag, an annotated tag if a message is given
+// or a signed tag if signing is enabled.
+func (c *Client) CreateTag(ctx context.Context, opts TagOptions) error {
+	args := []string{"-C", c.repoDir}
+
+	if opts.Sign && opts.SigningFormat != "" {
+		args = append(args, "-c", "gpg.format="+opts.SigningFormat)
+	}
+
+	args = append(args, "tag")
+
+	message := opts.Message
+	if opts.Sign && message == "" {
+		message = opts.Name
+	}
+
+	switch {
+	case opts.Sign && opts.SigningKey != "":
+		args = append(args, "-u", opts.SigningKey)
+	case opts.Sign:
+		args = append(args, "-s")
+	case message != "":
+		args = append(args, "-a")
+	}
+
+	if message != "" {
+		args = append(args, "-m", message)
+	}
+
+	args = append(args, opts.Name)
+
+	if opts.Ref != "" {
+		args = append(args, opts.Ref)
+	}
+
+	if _, err := c.Clean(c.Run(ctx, args...)); err != nil {
+		return fmt.Errorf("could not create tag %s: %s", opts.Name, err)
+	}
+
+	return nil
+}
+
+// TagCommit returns the commit sha the tag points at or an empty string if the
+// tag does not exist. An error is only returned when git timed out or was canceled.
+func (c *Client) TagCommit(ctx context.Context, name string) (string, error) {
+	result, err := c.Clean(c.Run(ctx, "-C", c.repoDir, "rev-parse", "--verify", "--quiet", "refs/tags/"+name+"^{commit}"))
+	if isDone(err) {
+		return "", err
+	}
+
+	return result, nil
+}
+
+// TagsAt returns the tags pointing at the revision.
+func (c *Client) TagsAt(ctx context.Context, rev string) ([]string, error) {
+	out, err := c.Run(ctx, "-C", c.repoDir, "tag", "--points-at", rev)
+	if err != nil {
+		return nil, fmt.Errorf("could not get tags at %s: %s", rev, strings.TrimSuffix(err.Error(), "\n"))
+	}
+
+	return strings.Fields(out), nil
+}
+
+// RemoteTagCommit returns the commit sha the tag points at on the remote or an
+// empty string if the remote has no such tag.
+func (c *Client) RemoteTagCommit(ctx context.Context, remote, name string) (string, error) {
+	ref := "refs/tags/" + name
+
+	out, err := c.Run(ctx, "-C", c.repoDir, "ls-remote", "--tags", remote, ref, ref+"^{}")
+	if err != nil {
+		return "", fmt.Errorf("could not list tags of %s: %s", remote, strings.TrimSuffix(err.Error(), "\n"))
+	}
+
+	var result string
+
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		switch fields[1] {
+		case ref + "^{}":
+			// the peeled commit of an annotated tag
+			return fields[0], nil
+		case ref:
+			result = fields[0]
+		}
+	}
+
+	return result, nil
+}
+
+// RemoteBranches returns the remote tracking branches of the remote containing
+// the revision, sorted by name and without the remote prefix.
+func (c *Client) RemoteBranches(ctx context.Context, remote, rev string) ([]Branch, error) {
+	prefix := "refs/remotes/" + remote + "/"
+
+	out, err := c.Run(ctx, "-C", c.repoDir, "for-each-ref", "--contains", rev, "--format=%(objectname) %(refname)", prefix)
+	if err != nil {
+		return nil, fmt.Errorf("could not get branches of %s containing %s: %s",
+			remote, rev, strings.TrimSuffix(err.Error(), "\n"))
+	}
+
+	var result []Branch
+
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		name := strings.TrimPrefix(fields[1], prefix)
+		if name == "HEAD" {
+			continue
+		}
+
+		result = append(result, Branch{Name: name, Commit: fields[0]})
+	}
+
+	return result, nil
+}
+
+// CountCommits returns the number of commits reachable from `to` but not from
+// `from`, or all commits reachable from `to` when `from` is empty. Merge commits
+// are not counted.
+func (c *Client) CountCommits(ctx context.Context, from, to string) (int, error) {
+	rev := to
+	if from != "" {
+		rev = from + ".." + to
+	}
+
+	out, err := c.Clean(c.Run(ctx, "-C", c.repoDir, "rev-list", "--count", "--no-merges", rev))
+	if err != nil {
+		return 0, fmt.Errorf("could not count commits for %s: %s", rev, err)
+	}
+
+	count, err := strconv.Atoi(out)
+	if err != nil {
+		return 0, fmt.Errorf("could not count commits for %s: unexpected output %q", rev, out)
+	}
+
+	return count, nil
+}
+
+// ResolveCommit returns the full commit sha of the revision.
+func (c *Client) ResolveCommit(ctx context.Context, rev string) (string, error) {
+	result, err := c.Clean(c.Run(ctx, "-C", c.repoDir, "rev-parse", "--verify", rev+"^{commit}"))
+	if err != nil {
+		return "", fmt.Errorf("could not resolve commit %s: %s", rev, err)
+	}
+
+	return result, nil
+}
+
+// DeleteTag deletes a local tag.
+func (c *Client) DeleteTag(ctx context.Context, name string) error {
+	if _, err := c.Clean(c.Run(ctx, "-C", c.repoDir, "tag", "-d", name)); err != nil {
+		return fmt.Errorf("could not delete tag %s: %s", name, err)
+	}
+
+	return nil
+}
+
+// PushTag pushes a tag to the remote. ErrPushRejected is returned when the remote
+// already has a tag with the same name or the local ref is stale. Rejections by
+// hooks, protected tags or missing permissions are returned as plain errors.
+func (c *Client) PushTag(ctx context.Context, remote, name string) error {
+	_, err := c.Run(ctx, "-C", c.repoDir, "push", remote, "refs/tags/"+name)
+	if err == nil {
+		return nil
+	}
+
+	msg := strings.TrimSpace(err.Error())
+
+	if pushRejectedRegex.MatchString(msg) {
+		return fmt.Errorf("could not push tag %s to %s: %w: %s", name, remote, ErrPushRejected, msg)
+	}
+
+	return fmt.Errorf("could not push tag %s to %s: %s", name, remote, msg)
+}
+
+// FetchTags fetches all tags from the remote.
+func (c *Client) FetchTags(ctx context.Context, remote string) error {
+	if _, err := c.Clean(c.Run(ctx, "-C", c.repoDir, "fetch", "--tags", remote)); err != nil {
+		return fmt.Errorf("could not fetch tags from %s: %s", remote, err)
+	}
+
+	return nil
 }
@@ -1,8 +1,14 @@
 package git_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/wakatime/semver-action/pkg/git"
 
@@ -31,14 +37,14 @@ func TestCleanErr(t *testing.T) {
 
 func TestCurrentBranch(t *testing.T) {
 	gc := git.NewGit("/path/to/repo")
-	gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 		assert.Nil(t, env)
 		assert.Equal(t, args, []string{"-C", "/path/to/repo", "rev-parse", "--abbrev-ref", "HEAD", "--quiet"})
 
 		return "develop", nil
 	}
 
-	value, err := gc.CurrentBranch()
+	value, err := gc.CurrentBranch(context.Background())
 	require.NoError(t, err)
 
 	assert.Equal(t, "develop", value)
@@ -46,14 +52,14 @@ func TestCurrentBranch(t *testing.T) {
 
 func TestCurrentBranchErr(t *testing.T) {
 	gc := git.NewGit("/path/to/repo")
-	gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 		assert.Nil(t, env)
 		assert.Equal(t, args, []string{"-C", "/path/to/repo", "rev-parse", "--abbrev-ref", "HEAD", "--quiet"})
 
 		return "", errors.New("error")
 	}
 
-	_, err := gc.CurrentBranch()
+	_, err := gc.CurrentBranch(context.Background())
 	require.Error(t, err)
 
 	assert.EqualError(t, err, "could not get current branch: error")
@@ -61,14 +67,14 @@ func TestCurrentBranchErr(t *testing.T) {
 
 func TestSourceBranch(t *testing.T) {
 	gc := git.NewGit("/path/to/repo")
-	gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 		assert.Nil(t, env)
 		assert.Equal(t, args, []string{"-C", "/path/to/repo", "log", "-1", "--pretty=%B", "81918ffc"})
 
 		return "Merge pull request #123 from wakatime/feature/semver-initial", nil
 	}
 
-	value, err := gc.SourceBranch("81918ffc")
+	value, err := gc.SourceBranch(context.Background(), "81918ffc")
 	require.NoError(t, err)
 
 	assert.Equal(t, "feature/semver-initial", value)
@@ -76,39 +82,41 @@ func TestSourceBranch(t *testing.T) {
 
 func TestSourceBranch_NotValidPullRequestMessage(t *testing.T) {
 	gc := git.NewGit("/path/to/repo")
-	gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 		assert.Nil(t, env)
 		assert.Equal(t, args, []string{"-C", "/path/to/repo", "log", "-1", "--pretty=%B", "81918ffc"})
 
 		return "not valid pull request message", nil
 	}
 
-	_, err := gc.SourceBranch("81918ffc")
+	_, err := gc.SourceBranch(context.Background(), "81918ffc")
 	require.Error(t, err)
 
+	assert.True(t, errors.Is(err, git.ErrNoSourceBranch))
 	assert.EqualError(t, err, "no source branch found")
 }
 
 func TestSourceBranch_NotValiddBranchName(t *testing.T) {
 	gc := git.NewGit("/path/to/repo")
-	gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 		assert.Nil(t, env)
 		assert.Equal(t, args, []string{"-C", "/path/to/repo", "log", "-1", "--pretty=%B", "81918ffc"})
 
 		return "Merge pull request #123 from semver-initial", nil
 	}
 
-	_, err := gc.SourceBranch("81918ffc")
+	_, err := gc.SourceBranch(context.Background(), "81918ffc")
 	require.Error(t, err)
 
-	assert.EqualError(t, err, "commit message does not contain expected format: semver-initial")
+	assert.True(t, errors.Is(err, git.ErrNoSourceBranch))
+	assert.EqualError(t, err, "no source branch found, commit message does not contain expected format: semver-initial")
 }
 
 func TestLatestTag(t *testing.T) {
 	var numCalls int
 
 	gc := git.NewGit("/path/to/repo")
-	gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 		numCalls++
 
 		assert.Nil(t, env)
@@ -126,25 +134,91 @@ func TestLatestTag(t *testing.T) {
 		return "v2.4.79", nil
 	}
 
-	value := gc.LatestTag()
+	value := latestTag(t, gc)
 
 	assert.Equal(t, "v2.4.79", value)
 }
 
 func TestLatestTag_NoTagFound(t *testing.T) {
 	gc := git.NewGit("/path/to/repo")
-	gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 		assert.Nil(t, env)
 		assert.Equal(t, args, []string{"-C", "/path/to/repo", "rev-list", "--tags", "--max-count=1"})
 
 		return "", nil
 	}
 
-	value := gc.LatestTag()
+	value := latestTag(t, gc)
 
 	assert.Empty(t, value)
 }
 
+func TestLatestTag_Timeout(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		return "", fmt.Errorf("git rev-list --tags --max-count=1 timed out: %w", context.DeadlineExceeded)
+	}
+
+	_, err := gc.LatestTag(context.Background())
+	require.Error(t, err)
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.EqualError(t, err, "git rev-list --tags --max-count=1 timed out: context deadline exceeded")
+}
+
+func TestTags(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		assert.Nil(t, env)
+		assert.Equal(t, []string{"-C", "/path/to/repo", "for-each-ref", "--format=%(refname:strip=2)", "refs/tags"}, args)
+
+		return "latest\nv1.2.0\nv1.10.0-pre.1\n", nil
+	}
+
+	tags, err := gc.Tags(context.Background(), "")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"latest", "v1.2.0", "v1.10.0-pre.1"}, tags)
+}
+
+func TestTags_Reachable(t *testing.T) {
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, "", "init", "--bare", remote)
+
+	repo := cloneRepo(t, remote)
+	runGit(t, repo, "tag", "v1.0.0")
+	runGit(t, repo, "checkout", "--quiet", "-b", "other")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "unrelated")
+	runGit(t, repo, "tag", "v9.0.0")
+	runGit(t, repo, "checkout", "--quiet", "-")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "second")
+	runGit(t, repo, "tag", "v1.1.0")
+
+	gc := git.NewGit(repo)
+
+	tags, err := gc.Tags(context.Background(), "HEAD")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0"}, tags)
+
+	tags, err = gc.Tags(context.Background(), "")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0", "v9.0.0"}, tags)
+}
+
+func TestTagsErr(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		return "", errors.New("fatal: not a git repository\n")
+	}
+
+	_, err := gc.Tags(context.Background(), "HEAD")
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "could not list tags: fatal: not a git repository")
+}
+
 func TestAncestorTag(t *testing.T) {
 	tests := map[string]struct {
 		IncludePattern string
@@ -169,7 +243,7 @@ func TestAncestorTag(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+			gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 				assert.Nil(t, env)
 				assert.Equal(
 					t,
@@ -182,7 +256,7 @@ func TestAncestorTag(t *testing.T) {
 				return test.ExpectedTag, nil
 			}
 
-			value := gc.AncestorTag(test.IncludePattern, test.ExcludePattern, test.Branch)
+			value := ancestorTag(t, gc, test.IncludePattern, test.ExcludePattern, test.Branch)
 
 			assert.Equal(t, test.ExpectedTag, value)
 		})
@@ -193,7 +267,7 @@ func TestAncestorTag_NoTagFound(t *testing.T) {
 	var numCalls int
 
 	gc := git.NewGit("/path/to/repo")
-	gc.GitCmd = func(env map[string]string, args ...string) (string, error) {
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
 		numCalls++
 
 		assert.Nil(t, env)
@@ -213,7 +287,539 @@ func TestAncestorTag_NoTagFound(t *testing.T) {
 		return "", nil
 	}
 
-	value := gc.AncestorTag("", "", "")
+	value := ancestorTag(t, gc, "", "", "")
 
 	assert.Empty(t, value)
 }
+
+func TestCommits(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		assert.Nil(t, env)
+		assert.Equal(t, args, []string{
+			"-C", "/path/to/repo", "log", "--first-parent",
+			"--format=%H%x1f%h%x1f%an%x1f%s%x1f%b%x1e", "v1.2.0..81918ffc"},
+		)
+
+		return "da81ce0ec20cab645ffe03e760dad1cdfccf7c94\x1fda81ce0\x1fJohn Doe\x1f" +
+			"Merge pull request #12 from wakatime/feature/login\x1fAdd login\n\x1e\n" +
+			"e63c125b5b5fcb1c9c2b2dcd9f1cfe9fa3e7e2e1\x1fe63c125\x1fJane Doe\x1fUpdate README\x1f\x1e\n", nil
+	}
+
+	commits, err := gc.Commits(context.Background(), "v1.2.0", "81918ffc")
+	require.NoError(t, err)
+
+	assert.Equal(t, []git.Commit{
+		{
+			Hash:      "da81ce0ec20cab645ffe03e760dad1cdfccf7c94",
+			ShortHash: "da81ce0",
+			Author:    "John Doe",
+			Subject:   "Merge pull request #12 from wakatime/feature/login",
+			Body:      "Add login",
+		},
+		{
+			Hash:      "e63c125b5b5fcb1c9c2b2dcd9f1cfe9fa3e7e2e1",
+			ShortHash: "e63c125",
+			Author:    "Jane Doe",
+			Subject:   "Update README",
+		},
+	}, commits)
+}
+
+func TestCommits_NoFrom(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		assert.Equal(t, "HEAD", args[len(args)-1])
+
+		return "", nil
+	}
+
+	commits, err := gc.Commits(context.Background(), "", "HEAD")
+	require.NoError(t, err)
+
+	assert.Empty(t, commits)
+}
+
+func TestCommitsErr(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		return "", errors.New("error\n")
+	}
+
+	_, err := gc.Commits(context.Background(), "v1.2.0", "HEAD")
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "could not get commits for v1.2.0..HEAD: error")
+}
+
+func TestCreateTag(t *testing.T) {
+	tests := map[string]struct {
+		Opts     git.TagOptions
+		Expected []string
+	}{
+		"lightweight": {
+			Opts:     git.TagOptions{Name: "v1.0.0", Ref: "81918ffc"},
+			Expected: []string{"-C", "/path/to/repo", "tag", "v1.0.0", "81918ffc"},
+		},
+		"annotated": {
+			Opts:     git.TagOptions{Name: "v1.0.0", Ref: "81918ffc", Message: "Release v1.0.0"},
+			Expected: []string{"-C", "/path/to/repo", "tag", "-a", "-m", "Release v1.0.0", "v1.0.0", "81918ffc"},
+		},
+		"signed": {
+			Opts:     git.TagOptions{Name: "v1.0.0", Ref: "81918ffc", Message: "Release v1.0.0", Sign: true},
+			Expected: []string{"-C", "/path/to/repo", "tag", "-s", "-m", "Release v1.0.0", "v1.0.0", "81918ffc"},
+		},
+		"signed without message": {
+			Opts:     git.TagOptions{Name: "v1.0.0", Sign: true},
+			Expected: []string{"-C", "/path/to/repo", "tag", "-s", "-m", "v1.0.0", "v1.0.0"},
+		},
+		"signed with ssh key": {
+			Opts: git.TagOptions{
+				Name:          "v1.0.0",
+				Message:       "Release v1.0.0",
+				Sign:          true,
+				SigningKey:    "/home/runner/.ssh/id_ed25519.pub",
+				SigningFormat: "ssh",
+			},
+			Expected: []string{
+				"-C", "/path/to/repo", "-c", "gpg.format=ssh", "tag",
+				"-u", "/home/runner/.ssh/id_ed25519.pub", "-m", "Release v1.0.0", "v1.0.0"},
+		},
+		"without ref": {
+			Opts:     git.TagOptions{Name: "v1.0.0"},
+			Expected: []string{"-C", "/path/to/repo", "tag", "v1.0.0"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gc := git.NewGit("/path/to/repo")
+			gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+				assert.Nil(t, env)
+				assert.Equal(t, test.Expected, args)
+
+				return "", nil
+			}
+
+			err := gc.CreateTag(context.Background(), test.Opts)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCreateTag_SignedSSH(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not found")
+	}
+
+	dir := t.TempDir()
+	key := filepath.Join(dir, "id_ed25519")
+
+	out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	repo := filepath.Join(dir, "repo")
+	runGit(t, "", "init", "--quiet", repo)
+	runGit(t, repo, "commit", "--allow-empty", "-m", "initial commit")
+
+	gc := git.NewGit(repo)
+
+	err = gc.CreateTag(context.Background(), git.TagOptions{
+		Name:          "v1.0.0",
+		Message:       "Release v1.0.0",
+		Sign:          true,
+		SigningKey:    key,
+		SigningFormat: "ssh",
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, runGit(t, repo, "cat-file", "tag", "v1.0.0"), "-----BEGIN SSH SIGNATURE-----")
+}
+
+func TestPushTag_Rejected(t *testing.T) {
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, "", "init", "--bare", remote)
+
+	first := cloneRepo(t, remote)
+	second := cloneRepo(t, remote)
+
+	gc := git.NewGit(first)
+
+	require.NoError(t, gc.CreateTag(context.Background(), git.TagOptions{Name: "v1.0.0", Message: "Release v1.0.0"}))
+	require.NoError(t, gc.PushTag(context.Background(), "origin", "v1.0.0"))
+
+	assert.Equal(t, "tag", runGit(t, remote, "cat-file", "-t", "v1.0.0"))
+
+	gc = git.NewGit(second)
+
+	require.NoError(t, gc.CreateTag(context.Background(), git.TagOptions{Name: "v1.0.0"}))
+
+	err := gc.PushTag(context.Background(), "origin", "v1.0.0")
+	require.Error(t, err)
+
+	assert.True(t, errors.Is(err, git.ErrPushRejected))
+
+	require.NoError(t, gc.DeleteTag(context.Background(), "v1.0.0"))
+	require.NoError(t, gc.FetchTags(context.Background(), "origin"))
+
+	assert.Equal(t, runGit(t, first, "rev-parse", "HEAD"), runGit(t, second, "rev-parse", "v1.0.0^{commit}"))
+}
+
+func TestPushTag_Err(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		assert.Equal(t, []string{"-C", "/path/to/repo", "push", "origin", "refs/tags/v1.0.0"}, args)
+
+		return "", errors.New("fatal: could not read from remote repository\n")
+	}
+
+	err := gc.PushTag(context.Background(), "origin", "v1.0.0")
+	require.Error(t, err)
+
+	assert.False(t, errors.Is(err, git.ErrPushRejected))
+	assert.EqualError(t, err, "could not push tag v1.0.0 to origin: fatal: could not read from remote repository")
+}
+
+func TestPushTag_RejectionKinds(t *testing.T) {
+	tests := map[string]struct {
+		Stderr    string
+		Retryable bool
+	}{
+		"already exists": {
+			Stderr:    " ! [rejected]        v1.0.0 -> v1.0.0 (already exists)\nerror: failed to push some refs\n",
+			Retryable: true,
+		},
+		"stale info": {
+			Stderr:    " ! [rejected]        v1.0.0 -> v1.0.0 (stale info)\nerror: failed to push some refs\n",
+			Retryable: true,
+		},
+		"fetch first": {
+			Stderr:    " ! [rejected]        v1.0.0 -> v1.0.0 (fetch first)\nerror: failed to push some refs\n",
+			Retryable: true,
+		},
+		"hook declined": {
+			Stderr: " ! [remote rejected] v1.0.0 -> v1.0.0 (pre-receive hook declined)\nerror: failed to push some refs\n",
+		},
+		"protected tag": {
+			Stderr: "remote: error: GH013: Repository rule violations found for refs/tags/v1.0.0.\n" +
+				" ! [remote rejected] v1.0.0 -> v1.0.0 (push declined due to repository rule violations)\n",
+		},
+		"permission denied": {
+			Stderr: "remote: Permission to wakatime/semver-action.git denied to bot.\n" +
+				"fatal: unable to access 'https://github.com/wakatime/semver-action.git/': The requested URL returned error: 403\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gc := git.NewGit("/path/to/repo")
+			gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+				return "", errors.New(test.Stderr)
+			}
+
+			err := gc.PushTag(context.Background(), "origin", "v1.0.0")
+			require.Error(t, err)
+
+			assert.Equal(t, test.Retryable, errors.Is(err, git.ErrPushRejected))
+		})
+	}
+}
+
+func TestTagCommit(t *testing.T) {
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, "", "init", "--bare", remote)
+
+	repo := cloneRepo(t, remote)
+	head := runGit(t, repo, "rev-parse", "HEAD")
+
+	gc := git.NewGit(repo)
+
+	require.NoError(t, gc.CreateTag(context.Background(), git.TagOptions{Name: "v1.0.0", Message: "Release v1.0.0"}))
+
+	assert.Equal(t, head, tagCommit(t, gc, "v1.0.0"))
+	assert.Empty(t, tagCommit(t, gc, "v2.0.0"))
+
+	commit, err := gc.ResolveCommit(context.Background(), "v1.0.0")
+	require.NoError(t, err)
+
+	assert.Equal(t, head, commit)
+
+	_, err = gc.ResolveCommit(context.Background(), "v2.0.0")
+	require.Error(t, err)
+}
+
+func TestTagsAt(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		assert.Equal(t, []string{"-C", "/path/to/repo", "tag", "--points-at", "81918ffc"}, args)
+
+		return "v1.2.0\nv1.2.0-pre.3\nlatest\n", nil
+	}
+
+	tags, err := gc.TagsAt(context.Background(), "81918ffc")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"v1.2.0", "v1.2.0-pre.3", "latest"}, tags)
+}
+
+func TestTagsAtErr(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		return "", errors.New("fatal: malformed object name 81918ffc\n")
+	}
+
+	_, err := gc.TagsAt(context.Background(), "81918ffc")
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "could not get tags at 81918ffc: fatal: malformed object name 81918ffc")
+}
+
+func TestRemoteTagCommit(t *testing.T) {
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, "", "init", "--bare", remote)
+
+	repo := cloneRepo(t, remote)
+	head := runGit(t, repo, "rev-parse", "HEAD")
+
+	gc := git.NewGit(repo)
+
+	require.NoError(t, gc.CreateTag(context.Background(), git.TagOptions{Name: "v1.0.0", Message: "Release v1.0.0"}))
+	require.NoError(t, gc.CreateTag(context.Background(), git.TagOptions{Name: "v1.0.1"}))
+	require.NoError(t, gc.PushTag(context.Background(), "origin", "v1.0.0"))
+	require.NoError(t, gc.PushTag(context.Background(), "origin", "v1.0.1"))
+
+	commit, err := gc.RemoteTagCommit(context.Background(), "origin", "v1.0.0")
+	require.NoError(t, err)
+
+	assert.Equal(t, head, commit)
+
+	commit, err = gc.RemoteTagCommit(context.Background(), "origin", "v1.0.1")
+	require.NoError(t, err)
+
+	assert.Equal(t, head, commit)
+
+	commit, err = gc.RemoteTagCommit(context.Background(), "origin", "v2.0.0")
+	require.NoError(t, err)
+
+	assert.Empty(t, commit)
+}
+
+func TestIsShallow(t *testing.T) {
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, "", "init", "--bare", remote)
+
+	repo := cloneRepo(t, remote)
+	runGit(t, repo, "tag", "v1.0.0")
+
+	for i := 0; i < 3; i++ {
+		runGit(t, repo, "commit", "--allow-empty", "-m", "commit")
+	}
+
+	runGit(t, repo, "push", "--quiet", "--tags", "origin", "HEAD:master")
+
+	shallow := filepath.Join(t.TempDir(), "shallow")
+	runGit(t, "", "clone", "--quiet", "--depth=1", "--no-tags", "--branch=master", "file://"+remote, shallow)
+
+	gc := git.NewGit(shallow)
+
+	isShallow, err := gc.IsShallow(context.Background())
+	require.NoError(t, err)
+
+	assert.True(t, isShallow)
+
+	require.NoError(t, gc.Deepen(context.Background(), "origin", 1))
+
+	assert.Equal(t, "2", runGit(t, shallow, "rev-list", "--count", "HEAD"))
+
+	require.NoError(t, gc.Unshallow(context.Background(), "origin"))
+
+	isShallow, err = gc.IsShallow(context.Background())
+	require.NoError(t, err)
+
+	assert.False(t, isShallow)
+	assert.Equal(t, "v1.0.0", runGit(t, shallow, "describe", "--tags", "--abbrev=0"))
+
+	isShallow, err = git.NewGit(repo).IsShallow(context.Background())
+	require.NoError(t, err)
+
+	assert.False(t, isShallow)
+}
+
+func TestDeepen_Err(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		assert.Equal(t, []string{"-C", "/path/to/repo", "fetch", "--quiet", "--tags", "--deepen=50", "origin"}, args)
+
+		return "", errors.New("fatal: could not read from remote repository\n")
+	}
+
+	err := gc.Deepen(context.Background(), "origin", 50)
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "could not deepen history from origin: fatal: could not read from remote repository")
+}
+
+func TestMakeSafe(t *testing.T) {
+	gc := git.NewGit("/path/to/repo")
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		assert.Equal(t, []string{
+			"-c", "safe.directory=/path/to/repo", "-C", "/path/to/repo", "rev-parse", "--is-inside-work-tree",
+		}, args)
+
+		return "true\n", nil
+	}
+
+	err := gc.MakeSafe(context.Background())
+	require.NoError(t, err)
+
+	isRepo, err := gc.IsRepo(context.Background())
+	require.NoError(t, err)
+
+	assert.True(t, isRepo)
+}
+
+func TestAddSafeDirectory(t *testing.T) {
+	tests := map[string]struct {
+		Configured string
+		Expected   [][]string
+	}{
+		"not configured": {
+			Expected: [][]string{
+				{"config", "--global", "--get-all", "safe.directory"},
+				{"config", "--global", "--add", "safe.directory", "/path/to/repo"},
+			},
+		},
+		"already configured": {
+			Configured: "/other/repo\n/path/to/repo\n",
+			Expected: [][]string{
+				{"config", "--global", "--get-all", "safe.directory"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls [][]string
+
+			gc := git.NewGit("/path/to/repo")
+			gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+				calls = append(calls, args)
+
+				if args[2] == "--get-all" && test.Configured == "" {
+					return "", errors.New("")
+				}
+
+				return test.Configured, nil
+			}
+
+			err := gc.AddSafeDirectory(context.Background())
+			require.NoError(t, err)
+
+			assert.Equal(t, test.Expected, calls)
+		})
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	repo := t.TempDir()
+	runGit(t, repo, "init", "--quiet")
+
+	gc := git.NewGit(repo)
+	gc.Timeout = time.Nanosecond
+
+	_, err := gc.IsShallow(context.Background())
+	require.Error(t, err)
+
+	assert.EqualError(t, err, fmt.Sprintf("could not check for shallow repository:"+
+		" git -C %s rev-parse --is-shallow-repository timed out: context deadline exceeded", repo))
+}
+
+func TestRun_Canceled(t *testing.T) {
+	repo := t.TempDir()
+	runGit(t, repo, "init", "--quiet")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := git.NewGit(repo).AncestorTag(ctx, "v[0-9]*", "", "HEAD")
+	require.Error(t, err)
+
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Contains(t, err.Error(), "describe --tags --abbrev=0 --match v[0-9]* --exclude  HEAD was canceled")
+}
+
+func TestRun_NoTerminalPrompt(t *testing.T) {
+	gc := git.NewGit(t.TempDir())
+
+	out, err := gc.Run(context.Background(), "-c", "alias.prompt=!printenv GIT_TERMINAL_PROMPT", "prompt")
+	require.NoError(t, err)
+
+	assert.Equal(t, "0\n", out)
+}
+
+func TestRun_Env(t *testing.T) {
+	t.Setenv("LC_ALL", "de_DE.UTF-8")
+	t.Setenv("GIT_DIR", "/path/to/other/.git")
+	t.Setenv("SEMVER_TEST_VALUE", "leaked")
+
+	gc := git.NewGit(t.TempDir())
+
+	out, err := gc.GitCmd(context.Background(), map[string]string{"SEMVER_EXTRA": "1"},
+		"-c", "alias.env=!env", "env")
+	require.NoError(t, err)
+
+	env := strings.Split(strings.TrimSpace(out), "\n")
+
+	assert.Contains(t, env, "LC_ALL=C")
+	assert.Contains(t, env, "GIT_CONFIG_NOSYSTEM=1")
+	assert.Contains(t, env, "GIT_TERMINAL_PROMPT=0")
+	assert.Contains(t, env, "SEMVER_EXTRA=1")
+	assert.Contains(t, out, "PATH=")
+	assert.NotContains(t, out, "SEMVER_TEST_VALUE")
+	assert.NotContains(t, out, "/path/to/other/.git")
+}
+
+func TestTagsAt_IgnoresUserConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	runGit(t, "", "config", "--global", "tag.sort", "-refname")
+	runGit(t, "", "config", "--global", "column.ui", "always")
+
+	repo := t.TempDir()
+	runGit(t, repo, "init", "--quiet")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "initial commit")
+	runGit(t, repo, "tag", "v1.0.0")
+	runGit(t, repo, "tag", "v1.1.0")
+
+	tags, err := git.NewGit(repo).TagsAt(context.Background(), "HEAD")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0"}, tags)
+}
+
+// cloneRepo clones the remote into a temporary directory and adds a commit to it.
+func cloneRepo(t *testing.T, remote string) string {
+	dir := filepath.Join(t.TempDir(), "clone")
+
+	runGit(t, "", "clone", "--quiet", remote, dir)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial commit")
+
+	return dir
+}
+
+// runGit runs a git command for test setup and returns its trimmed output.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Setenv("GIT_AUTHOR_NAME", "John Doe")
+	t.Setenv("GIT_AUTHOR_EMAIL", "john@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "John Doe")
+	t.Setenv("GIT_COMMITTER_EMAIL", "john@example.com")
+
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+
+	out, err := exec.Command("git", args...).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,548 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Native reads refs, tags and commits directly from the repository files
+// instead of running git. Creating, deleting, pushing and fetching tags still
+// run git through the embedded Client and reload the repository afterwards.
+type Native struct {
+	*Client
+	repo    *repository
+	openErr error
+	safe    bool
+}
+
+// NewNative creates a new native git instance.
+func NewNative(repoDir string) *Native {
+	n := &Native{Client: NewGit(repoDir)}
+	n.reload()
+
+	return n
+}
+
+func (n *Native) reload() {
+	if n.repo != nil {
+		n.repo.close()
+	}
+
+	n.repo, n.openErr = openRepository(n.repoDir)
+}
+
+func (n *Native) open() (*repository, error) {
+	if n.openErr != nil {
+		return nil, n.openErr
+	}
+
+	return n.repo, nil
+}
+
+// ensureSafe trusts the repository before the first git command. Reads don't
+// check ownership, so IsRepo can't tell whether git would need it.
+func (n *Native) ensureSafe(ctx context.Context) error {
+	if n.safe {
+		return nil
+	}
+
+	if err := n.Client.MakeSafe(ctx); err != nil {
+		return err
+	}
+
+	n.safe = true
+
+	return nil
+}
+
+// MakeSafe does nothing, reading the repository doesn't check ownership.
+// safe.directory is passed once git needs to run.
+func (*Native) MakeSafe(context.Context) error {
+	return nil
+}
+
+// IsRepo returns true if the directory is inside the work tree of a repository.
+func (n *Native) IsRepo(_ context.Context) (bool, error) {
+	r, err := n.open()
+	if errors.Is(err, ErrUnsupportedRepository) {
+		return false, err
+	}
+
+	return err == nil && !r.bare, nil
+}
+
+// IsShallow returns true if the repository is a shallow clone.
+func (n *Native) IsShallow(_ context.Context) (bool, error) {
+	r, err := n.open()
+	if err != nil {
+		return false, fmt.Errorf("could not check for shallow repository: %s", err)
+	}
+
+	return len(r.shallow) > 0, nil
+}
+
+// CurrentBranch returns the current branch checked out or HEAD when detached.
+func (n *Native) CurrentBranch(_ context.Context) (string, error) {
+	r, err := n.open()
+	if err != nil {
+		return "", fmt.Errorf("could not get current branch: %s", err)
+	}
+
+	head, err := r.head()
+	if err != nil {
+		return "", fmt.Errorf("could not get current branch: %s", err)
+	}
+
+	if !strings.HasPrefix(head, "ref:") {
+		return "HEAD", nil
+	}
+
+	ref := strings.TrimSpace(strings.TrimPrefix(head, "ref:"))
+
+	if _, ok := r.ref(ref); !ok {
+		return "", fmt.Errorf("could not get current branch: ambiguous argument 'HEAD': unknown revision")
+	}
+
+	if !strings.HasPrefix(ref, "refs/heads/") {
+		return ref, nil
+	}
+
+	branch := strings.TrimPrefix(ref, "refs/heads/")
+
+	// git keeps the heads/ prefix when a tag has the same name
+	if _, ok := r.refs["refs/tags/"+branch]; ok {
+		return "heads/" + branch, nil
+	}
+
+	return branch, nil
+}
+
+// SourceBranch tries to get branch from commit message.
+func (n *Native) SourceBranch(_ context.Context, commitHash string) (string, error) {
+	r, err := n.open()
+	if err != nil {
+		return "", fmt.Errorf("could not get message from commit: %s", err)
+	}
+
+	h, err := r.resolve(commitHash)
+	if err != nil {
+		return "", fmt.Errorf("could not get message from commit: %s", err)
+	}
+
+	c, err := r.commit(h)
+	if err != nil {
+		return "", fmt.Errorf("could not get message from commit: %s", err)
+	}
+
+	return sourceBranchFromMessage(strings.Split(c.message, "\n")[0])
+}
+
+// LatestTag returns the latest tag if found.
+func (n *Native) LatestTag(_ context.Context) (string, error) {
+	r, err := n.open()
+	if errors.Is(err, ErrNotRepository) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("could not get latest tag: %s", err)
+	}
+
+	result, _ := latestTag(r, r.tagRefs())
+
+	return result, nil
+}
+
+// Tags returns all tags. If reachableFrom is not empty only tags reachable
+// from that revision are returned.
+func (n *Native) Tags(_ context.Context, reachableFrom string) ([]string, error) {
+	r, err := n.open()
+	if err != nil {
+		return nil, fmt.Errorf("could not list tags: %s", err)
+	}
+
+	names := r.tagNames()
+
+	if reachableFrom == "" {
+		return names, nil
+	}
+
+	h, err := r.resolve(reachableFrom)
+	if err != nil {
+		return nil, fmt.Errorf("could not list tags: %s", err)
+	}
+
+	reachable, err := ancestors(r, h)
+	if err != nil {
+		return nil, fmt.Errorf("could not list tags: %s", err)
+	}
+
+	var result []string
+
+	for _, name := range names {
+		if c, ok := r.tagCommit(name); ok && reachable[c] {
+			result = append(result, name)
+		}
+	}
+
+	return result, nil
+}
+
+// AncestorTag returns the previous tag that matches specific pattern if found.
+// The root commit is returned when no tag matches.
+func (n *Native) AncestorTag(_ context.Context, include, exclude, branch string) (string, error) {
+	r, err := n.open()
+	if errors.Is(err, ErrNotRepository) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("could not get ancestor tag: %s", err)
+	}
+
+	if h, err := r.resolve(branch); err == nil {
+		if result, err := describe(r, r.tagRefs(), h, include, exclude); err == nil {
+			return result, nil
+		}
+	}
+
+	head, err := r.resolve("HEAD")
+	if err != nil {
+		return "", nil
+	}
+
+	root, err := rootCommit(r, head)
+	if err != nil {
+		return "", nil
+	}
+
+	return root.String(), nil
+}
+
+// Commits returns the first-parent commits reachable from `to` but not from `from`.
+// If `from` is empty all commits reachable from `to` are returned.
+func (n *Native) Commits(_ context.Context, from, to string) ([]Commit, error) {
+	rev := to
+	if from != "" {
+		rev = from + ".." + to
+	}
+
+	r, err := n.open()
+	if err != nil {
+		return nil, fmt.Errorf("could not get commits for %s: %s", rev, err)
+	}
+
+	h, err := r.resolve(to)
+	if err != nil {
+		return nil, fmt.Errorf("could not get commits for %s: %s", rev, err)
+	}
+
+	excluded := map[hash]bool{}
+
+	if from != "" {
+		f, err := r.resolve(from)
+		if err != nil {
+			return nil, fmt.Errorf("could not get commits for %s: %s", rev, err)
+		}
+
+		if excluded, err = ancestors(r, f); err != nil {
+			return nil, fmt.Errorf("could not get commits for %s: %s", rev, err)
+		}
+	}
+
+	var commits []Commit
+
+	for !excluded[h] {
+		c, err := r.commit(h)
+		if err != nil {
+			return nil, fmt.Errorf("could not get commits for %s: %s", rev, err)
+		}
+
+		subject, body := splitMessage(c.message)
+
+		commits = append(commits, Commit{
+			Hash:      h.String(),
+			ShortHash: r.shortHash(h),
+			Author:    c.author,
+			Subject:   subject,
+			Body:      body,
+		})
+
+		if len(c.parents) == 0 {
+			break
+		}
+
+		h = c.parents[0]
+	}
+
+	return commits, nil
+}
+
+// TagCommit returns the commit sha the tag points at or an empty string if the
+// tag does not exist.
+func (n *Native) TagCommit(_ context.Context, name string) (string, error) {
+	r, err := n.open()
+	if errors.Is(err, ErrNotRepository) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("could not get tag commit: %s", err)
+	}
+
+	h, ok := r.tagCommit(name)
+	if !ok {
+		return "", nil
+	}
+
+	return h.String(), nil
+}
+
+// TagsAt returns the tags pointing at the revision.
+func (n *Native) TagsAt(_ context.Context, rev string) ([]string, error) {
+	r, err := n.open()
+	if err != nil {
+		return nil, fmt.Errorf("could not get tags at %s: %s", rev, err)
+	}
+
+	h, err := r.resolve(rev)
+	if err != nil {
+		return nil, fmt.Errorf("could not get tags at %s: malformed object name %s", rev, rev)
+	}
+
+	var result []string
+
+	for _, name := range r.tagNames() {
+		if c, ok := r.tagCommit(name); ok && c == h {
+			result = append(result, name)
+		}
+	}
+
+	return result, nil
+}
+
+// RemoteBranches returns the remote tracking branches of the remote containing
+// the revision, sorted by name and without the remote prefix.
+func (n *Native) RemoteBranches(_ context.Context, remote, rev string) ([]Branch, error) {
+	r, err := n.open()
+	if err != nil {
+		return nil, fmt.Errorf("could not get branches of %s containing %s: %s", remote, rev, err)
+	}
+
+	h, err := r.resolve(rev)
+	if err != nil {
+		return nil, fmt.Errorf("could not get branches of %s containing %s: malformed object name %s", remote, rev, rev)
+	}
+
+	prefix := "refs/remotes/" + remote + "/"
+
+	var names []string
+
+	for name := range r.refs {
+		if strings.HasPrefix(name, prefix) && name != prefix+"HEAD" {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	var (
+		result      []Branch
+		unreachable = map[hash]bool{}
+	)
+
+	for _, name := range names {
+		tip, ok := r.ref(name)
+		if !ok {
+			continue
+		}
+
+		tip, err = r.peelToCommit(tip)
+		if err != nil {
+			continue
+		}
+
+		found, err := reaches(r, tip, h, unreachable)
+		if err != nil {
+			return nil, fmt.Errorf("could not get branches of %s containing %s: %s", remote, rev, err)
+		}
+
+		if found {
+			result = append(result, Branch{Name: strings.TrimPrefix(name, prefix), Commit: tip.String()})
+		}
+	}
+
+	return result, nil
+}
+
+// CountCommits returns the number of commits reachable from `to` but not from
+// `from`, or all commits reachable from `to` when `from` is empty. Merge commits
+// are not counted.
+func (n *Native) CountCommits(_ context.Context, from, to string) (int, error) {
+	rev := to
+	if from != "" {
+		rev = from + ".." + to
+	}
+
+	r, err := n.open()
+	if err != nil {
+		return 0, fmt.Errorf("could not count commits for %s: %s", rev, err)
+	}
+
+	t, err := r.resolve(to)
+	if err != nil {
+		return 0, fmt.Errorf("could not count commits for %s: %s", rev, err)
+	}
+
+	excluded := map[hash]bool{}
+
+	if from != "" {
+		f, err := r.resolve(from)
+		if err != nil {
+			return 0, fmt.Errorf("could not count commits for %s: %s", rev, err)
+		}
+
+		if excluded, err = ancestors(r, f); err != nil {
+			return 0, fmt.Errorf("could not count commits for %s: %s", rev, err)
+		}
+	}
+
+	included, err := ancestors(r, t)
+	if err != nil {
+		return 0, fmt.Errorf("could not count commits for %s: %s", rev, err)
+	}
+
+	var count int
+
+	for h := range included {
+		if excluded[h] {
+			continue
+		}
+
+		c, err := r.commit(h)
+		if err != nil {
+			return 0, fmt.Errorf("could not count commits for %s: %s", rev, err)
+		}
+
+		if len(c.parents) < 2 {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// ResolveCommit returns the full commit sha of the revision.
+func (n *Native) ResolveCommit(_ context.Context, rev string) (string, error) {
+	r, err := n.open()
+	if err != nil {
+		return "", fmt.Errorf("could not resolve commit %s: %s", rev, err)
+	}
+
+	h, err := r.resolve(rev)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve commit %s: %s", rev, err)
+	}
+
+	return h.String(), nil
+}
+
+// CreateTag creates the tag with git.
+func (n *Native) CreateTag(ctx context.Context, opts TagOptions) error {
+	if err := n.ensureSafe(ctx); err != nil {
+		return err
+	}
+
+	defer n.reload()
+
+	return n.Client.CreateTag(ctx, opts)
+}
+
+// DeleteTag deletes a local tag with git.
+func (n *Native) DeleteTag(ctx context.Context, name string) error {
+	if err := n.ensureSafe(ctx); err != nil {
+		return err
+	}
+
+	defer n.reload()
+
+	return n.Client.DeleteTag(ctx, name)
+}
+
+// PushTag pushes a tag to the remote with git.
+func (n *Native) PushTag(ctx context.Context, remote, name string) error {
+	if err := n.ensureSafe(ctx); err != nil {
+		return err
+	}
+
+	return n.Client.PushTag(ctx, remote, name)
+}
+
+// FetchTags fetches all tags from the remote with git.
+func (n *Native) FetchTags(ctx context.Context, remote string) error {
+	if err := n.ensureSafe(ctx); err != nil {
+		return err
+	}
+
+	defer n.reload()
+
+	return n.Client.FetchTags(ctx, remote)
+}
+
+// RemoteTagCommit returns the commit sha the tag points at on the remote with git.
+func (n *Native) RemoteTagCommit(ctx context.Context, remote, name string) (string, error) {
+	if err := n.ensureSafe(ctx); err != nil {
+		return "", err
+	}
+
+	return n.Client.RemoteTagCommit(ctx, remote, name)
+}
+
+// Deepen fetches additional commits and the tags from the remote with git.
+func (n *Native) Deepen(ctx context.Context, remote string, depth int) error {
+	if err := n.ensureSafe(ctx); err != nil {
+		return err
+	}
+
+	defer n.reload()
+
+	return n.Client.Deepen(ctx, remote, depth)
+}
+
+// Unshallow fetches the complete history and the tags from the remote with git.
+func (n *Native) Unshallow(ctx context.Context, remote string) error {
+	if err := n.ensureSafe(ctx); err != nil {
+		return err
+	}
+
+	defer n.reload()
+
+	return n.Client.Unshallow(ctx, remote)
+}
+
+// splitMessage returns the subject and body of a commit message like the
+// %s and %b placeholders of git log.
+func splitMessage(message string) (string, string) {
+	lines := strings.Split(message, "\n")
+
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+
+	var subject []string
+
+	for ; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t\r\v\f")
+		if line == "" {
+			break
+		}
+
+		subject = append(subject, line)
+	}
+
+	return strings.Join(subject, " "), strings.TrimSpace(strings.Join(lines[i:], "\n"))
+}
@@ -0,0 +1,414 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Object types as stored in pack files.
+const (
+	objectCommit   = 1
+	objectTree     = 2
+	objectBlob     = 3
+	objectTag      = 4
+	objectOfsDelta = 6
+	objectRefDelta = 7
+)
+
+// packCacheSize is the number of inflated objects kept per pack file to
+// resolve delta chains without inflating the same base twice.
+const packCacheSize = 1024
+
+var errObjectNotFound = errors.New("object not found") // nolint
+
+var zlibReaders sync.Pool // nolint
+
+// hash is a sha1 object name.
+type hash [20]byte
+
+func (h hash) String() string {
+	return fmt.Sprintf("%x", h[:])
+}
+
+// packIndex is a version 2 pack index.
+type packIndex struct {
+	fanout  [256]uint32
+	names   []hash
+	offsets []uint32
+	large   []uint64
+}
+
+// pack is a pack file with its index.
+type pack struct {
+	path  string
+	index *packIndex
+	file  *os.File
+	cache map[int64]packObject
+}
+
+type packObject struct {
+	typ  int
+	data []byte
+}
+
+// openPack opens the pack file for the given index file.
+func openPack(idxPath string) (*pack, error) {
+	// nolint:gosec
+	data, err := os.ReadFile(idxPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack index %s: %s", idxPath, err)
+	}
+
+	index, err := parsePackIndex(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pack index %s: %s", idxPath, err)
+	}
+
+	return &pack{
+		path:  strings.TrimSuffix(idxPath, ".idx") + ".pack",
+		index: index,
+		cache: make(map[int64]packObject),
+	}, nil
+}
+
+func parsePackIndex(data []byte) (*packIndex, error) {
+	if len(data) < 8+256*4 || !bytes.Equal(data[:4], []byte{0xff, 't', 'O', 'c'}) {
+		return nil, errors.New("unsupported pack index format")
+	}
+
+	if version := binary.BigEndian.Uint32(data[4:8]); version != 2 {
+		return nil, fmt.Errorf("unsupported pack index version %d", version)
+	}
+
+	idx := &packIndex{}
+	pos := 8
+
+	for i := range idx.fanout {
+		idx.fanout[i] = binary.BigEndian.Uint32(data[pos:])
+		pos += 4
+
+		if i > 0 && idx.fanout[i] < idx.fanout[i-1] {
+			return nil, errors.New("invalid pack index fanout")
+		}
+	}
+
+	n := int(idx.fanout[255])
+	if len(data) < pos+n*(20+4+4) {
+		return nil, errors.New("truncated pack index")
+	}
+
+	idx.names = make([]hash, n)
+	for i := range idx.names {
+		copy(idx.names[i][:], data[pos:])
+		pos += 20
+	}
+
+	// skip crc32 values
+	pos += n * 4
+
+	idx.offsets = make([]uint32, n)
+	for i := range idx.offsets {
+		idx.offsets[i] = binary.BigEndian.Uint32(data[pos:])
+		pos += 4
+	}
+
+	for pos+8 <= len(data)-40 {
+		idx.large = append(idx.large, binary.BigEndian.Uint64(data[pos:]))
+		pos += 8
+	}
+
+	return idx, nil
+}
+
+// find returns the pack offset of the object or errObjectNotFound.
+func (idx *packIndex) find(h hash) (int64, error) {
+	lo := 0
+	if h[0] > 0 {
+		lo = int(idx.fanout[h[0]-1])
+	}
+
+	hi := int(idx.fanout[h[0]])
+
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(idx.names[lo+i][:], h[:]) >= 0
+	})
+
+	if i >= hi || idx.names[i] != h {
+		return 0, errObjectNotFound
+	}
+
+	offset := idx.offsets[i]
+	if offset&0x80000000 != 0 {
+		large := int(offset & 0x7fffffff)
+		if large >= len(idx.large) {
+			return 0, errors.New("invalid pack index offset")
+		}
+
+		return int64(idx.large[large]), nil
+	}
+
+	return int64(offset), nil
+}
+
+// read returns the type and content of the object, resolving deltas.
+func (p *pack) read(h hash, resolve func(hash) (int, []byte, error)) (int, []byte, error) {
+	offset, err := p.index.find(h)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return p.readAt(offset, resolve)
+}
+
+func (p *pack) readAt(offset int64, resolve func(hash) (int, []byte, error)) (int, []byte, error) {
+	if obj, ok := p.cache[offset]; ok {
+		return obj.typ, obj.data, nil
+	}
+
+	if p.file == nil {
+		f, err := os.Open(p.path)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to open pack %s: %s", p.path, err)
+		}
+
+		p.file = f
+	}
+
+	var header [32]byte
+
+	n, err := p.file.ReadAt(header[:], offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, nil, fmt.Errorf("failed to read pack %s: %s", p.path, err)
+	}
+
+	if n == 0 {
+		return 0, nil, errors.New("invalid pack object header")
+	}
+
+	c := header[0]
+	typ := int(c>>4) & 7
+	pos := 1
+
+	for c&0x80 != 0 {
+		if pos >= n {
+			return 0, nil, errors.New("invalid pack object header")
+		}
+
+		c = header[pos]
+		pos++
+	}
+
+	var (
+		baseType int
+		base     []byte
+	)
+
+	switch typ {
+	case objectOfsDelta:
+		if pos >= n {
+			return 0, nil, errors.New("truncated pack object header")
+		}
+
+		c = header[pos]
+		pos++
+		rel := int64(c & 0x7f)
+
+		for c&0x80 != 0 {
+			if pos >= n {
+				return 0, nil, errors.New("truncated pack object header")
+			}
+
+			c = header[pos]
+			pos++
+			rel = ((rel + 1) << 7) | int64(c&0x7f)
+		}
+
+		if rel <= 0 || rel > offset {
+			return 0, nil, errors.New("invalid pack delta offset")
+		}
+
+		baseType, base, err = p.readAt(offset-rel, resolve)
+		if err != nil {
+			return 0, nil, err
+		}
+	case objectRefDelta:
+		var baseHash hash
+
+		if pos+20 > n {
+			return 0, nil, errors.New("truncated pack object header")
+		}
+
+		copy(baseHash[:], header[pos:pos+20])
+		pos += 20
+
+		baseType, base, err = resolve(baseHash)
+		if err != nil {
+			return 0, nil, err
+		}
+	case objectCommit, objectTree, objectBlob, objectTag:
+	default:
+		return 0, nil, fmt.Errorf("invalid pack object type %d", typ)
+	}
+
+	data, err := inflate(io.NewSectionReader(p.file, offset+int64(pos), 1<<62))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if base != nil {
+		typ = baseType
+
+		data, err = applyDelta(base, data)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if len(p.cache) >= packCacheSize {
+		p.cache = make(map[int64]packObject)
+	}
+
+	p.cache[offset] = packObject{typ: typ, data: data}
+
+	return typ, data, nil
+}
+
+func (p *pack) close() {
+	if p.file != nil {
+		_ = p.file.Close()
+	}
+}
+
+// applyDelta applies a git delta to the base object.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	pos := 0
+
+	readSize := func() (int, error) {
+		var size, shift int
+
+		for {
+			if pos >= len(delta) {
+				return 0, errors.New("truncated delta")
+			}
+
+			c := delta[pos]
+			pos++
+			size |= int(c&0x7f) << shift
+			shift += 7
+
+			if c&0x80 == 0 {
+				return size, nil
+			}
+		}
+	}
+
+	srcSize, err := readSize()
+	if err != nil {
+		return nil, err
+	}
+
+	if srcSize != len(base) {
+		return nil, errors.New("delta base size mismatch")
+	}
+
+	dstSize, err := readSize()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 0, dstSize)
+
+	for pos < len(delta) {
+		op := delta[pos]
+		pos++
+
+		switch {
+		case op&0x80 != 0:
+			var offset, size int
+
+			for i := 0; i < 4; i++ {
+				if op&(1<<i) != 0 {
+					if pos >= len(delta) {
+						return nil, errors.New("truncated delta")
+					}
+
+					offset |= int(delta[pos]) << (8 * i)
+					pos++
+				}
+			}
+
+			for i := 0; i < 3; i++ {
+				if op&(1<<(4+i)) != 0 {
+					if pos >= len(delta) {
+						return nil, errors.New("truncated delta")
+					}
+
+					size |= int(delta[pos]) << (8 * i)
+					pos++
+				}
+			}
+
+			if size == 0 {
+				size = 0x10000
+			}
+
+			if offset+size > len(base) {
+				return nil, errors.New("delta copy out of range")
+			}
+
+			result = append(result, base[offset:offset+size]...)
+		case op != 0:
+			if pos+int(op) > len(delta) {
+				return nil, errors.New("delta insert out of range")
+			}
+
+			result = append(result, delta[pos:pos+int(op)]...)
+			pos += int(op)
+		default:
+			return nil, errors.New("invalid delta opcode")
+		}
+	}
+
+	if len(result) != dstSize {
+		return nil, errors.New("delta result size mismatch")
+	}
+
+	return result, nil
+}
+
+// inflate decompresses zlib data. Readers are reused, since each one
+// allocates a 32KB window.
+func inflate(r io.Reader) ([]byte, error) {
+	var (
+		zr  io.ReadCloser
+		err error
+	)
+
+	if pooled, ok := zlibReaders.Get().(io.ReadCloser); ok {
+		zr = pooled
+		err = pooled.(zlib.Resetter).Reset(r, nil)
+	} else {
+		zr, err = zlib.NewReader(r)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate object: %s", err)
+	}
+
+	defer zlibReaders.Put(zr)
+
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate object: %s", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,158 @@
+package git_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/sha1" // nolint:gosec
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/wakatime/semver-action/pkg/git"
+
+	"github.com/alecthomas/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNative_CorruptPack(t *testing.T) {
+	tests := map[string]struct {
+		Target      func(rel byte) []byte
+		LargeOffset bool
+		Expected    string
+	}{
+		"truncated delta copy": {
+			// ofs-delta to the base with a copy op missing its offset and size bytes
+			Target: func(rel byte) []byte {
+				return append([]byte{0x63, rel}, deflate(t, []byte{0x05, 0x05, 0x91})...)
+			},
+			Expected: "truncated delta",
+		},
+		"truncated delta header": {
+			Target: func(byte) []byte {
+				return append([]byte{0x60}, bytes.Repeat([]byte{0xff}, 40)...)
+			},
+			Expected: "truncated pack object header",
+		},
+		"self referencing delta": {
+			Target: func(byte) []byte {
+				return []byte{0x60, 0x00}
+			},
+			Expected: "invalid pack delta offset",
+		},
+		"invalid large offset": {
+			Target: func(rel byte) []byte {
+				return []byte{0x60, rel}
+			},
+			LargeOffset: true,
+			Expected:    "invalid pack index offset",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			repo := writeCorruptPack(t, test.Target, test.LargeOffset)
+
+			_, err := git.NewNative(repo).Commits(context.Background(), "", "master")
+			require.Error(t, err)
+
+			assert.Contains(t, err.Error(), test.Expected)
+		})
+	}
+}
+
+// writeCorruptPack creates a repository with a pack holding a base blob and the
+// target entry, with master pointing at the target. The target is built from its
+// relative offset to the base.
+func writeCorruptPack(t *testing.T, target func(rel byte) []byte, largeOffset bool) string {
+	repo := t.TempDir()
+	runGit(t, "", "init", "--quiet", repo)
+
+	base := append([]byte{0x35}, deflate(t, []byte("hello"))...)
+
+	var pack bytes.Buffer
+
+	pack.WriteString("PACK")
+	_ = binary.Write(&pack, binary.BigEndian, uint32(2))
+	_ = binary.Write(&pack, binary.BigEndian, uint32(2))
+
+	baseOffset := uint32(pack.Len())
+	pack.Write(base)
+
+	targetOffset := uint32(pack.Len())
+	pack.Write(target(byte(targetOffset - baseOffset)))
+
+	if largeOffset {
+		targetOffset = 0x80000003
+	}
+
+	type entry struct {
+		name   [20]byte
+		offset uint32
+	}
+
+	entries := []entry{
+		{name: sha1.Sum([]byte("base")), offset: baseOffset},     // nolint:gosec
+		{name: sha1.Sum([]byte("target")), offset: targetOffset}, // nolint:gosec
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].name[:], entries[j].name[:]) < 0
+	})
+
+	var idx bytes.Buffer
+
+	idx.Write([]byte{0xff, 't', 'O', 'c'})
+	_ = binary.Write(&idx, binary.BigEndian, uint32(2))
+
+	for b := 0; b < 256; b++ {
+		var count uint32
+
+		for _, e := range entries {
+			if int(e.name[0]) <= b {
+				count++
+			}
+		}
+
+		_ = binary.Write(&idx, binary.BigEndian, count)
+	}
+
+	for _, e := range entries {
+		idx.Write(e.name[:])
+	}
+
+	idx.Write(make([]byte, 4*len(entries)))
+
+	for _, e := range entries {
+		_ = binary.Write(&idx, binary.BigEndian, e.offset)
+	}
+
+	idx.Write(make([]byte, 40))
+
+	dir := filepath.Join(repo, ".git", "objects", "pack")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pack-test.pack"), pack.Bytes(), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pack-test.idx"), idx.Bytes(), 0600))
+
+	targetName := sha1.Sum([]byte("target")) // nolint:gosec
+
+	ref := []byte(hex.EncodeToString(targetName[:]) + "\n")
+	require.NoError(t, os.WriteFile(filepath.Join(repo, ".git", "refs", "heads", "master"), ref, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, ".git", "HEAD"), []byte("ref: refs/heads/master\n"), 0600))
+
+	return repo
+}
+
+func deflate(t *testing.T, data []byte) []byte {
+	var b bytes.Buffer
+
+	w := zlib.NewWriter(&b)
+
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return b.Bytes()
+}
@@ -0,0 +1,1003 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// repository reads refs and objects of a git repository from disk.
+type repository struct {
+	gitDir    string
+	commonDir string
+	bare      bool
+	objects   []string
+	packs     []*pack
+	refs      map[string]string
+	peeled    map[string]hash
+	loose     map[string]bool
+	// fullyPeeled is set when packed-refs has peeled values for all tags, so
+	// refs without one don't point at tag objects.
+	fullyPeeled bool
+	shallow     map[hash]bool
+	commits     map[hash]*commitObject
+	names       []hash
+	tags        []tagRef
+}
+
+// commitObject is a parsed commit.
+type commitObject struct {
+	hash          hash
+	parents       []hash
+	author        string
+	committerTime int64
+	message       string
+}
+
+// tagObject is a parsed annotated tag.
+type tagObject struct {
+	object     hash
+	objectType string
+	taggerTime int64
+}
+
+// openRepository finds the git directory of repoDir and loads its refs and
+// pack indexes.
+func openRepository(repoDir string) (*repository, error) {
+	gitDir, bare, err := findGitDir(repoDir)
+	if err != nil {
+		return nil, err
+	}
+
+	commonDir := gitDir
+
+	// nolint:gosec
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir = strings.TrimSpace(string(data))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+	}
+
+	if err := checkFormat(commonDir); err != nil {
+		return nil, err
+	}
+
+	r := &repository{
+		gitDir:    gitDir,
+		commonDir: commonDir,
+		bare:      bare,
+		refs:      make(map[string]string),
+		peeled:    make(map[string]hash),
+		loose:     make(map[string]bool),
+		shallow:   make(map[hash]bool),
+		commits:   make(map[hash]*commitObject),
+	}
+
+	if err := r.loadObjectDirs(filepath.Join(commonDir, "objects")); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadRefs(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadShallow(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// checkFormat returns an error when the config of the repository enables an
+// object format or ref storage other than sha1 objects and files refs.
+func checkFormat(commonDir string) error {
+	// nolint:gosec
+	f, err := os.Open(filepath.Join(commonDir, "config"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to read config: %s", err)
+	}
+
+	defer f.Close()
+
+	supported := map[string]string{
+		"objectformat": "sha1",
+		"refstorage":   "files",
+	}
+
+	var section string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "[") {
+			section = strings.ToLower(strings.Trim(line, "[] \t"))
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if section != "extensions" || !ok {
+			continue
+		}
+
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.ToLower(strings.TrimSpace(value))
+
+		if want, ok := supported[key]; ok && value != want {
+			return fmt.Errorf("%w %s %s, use git_backend exec", ErrUnsupportedRepository, key, value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read config: %s", err)
+	}
+
+	return nil
+}
+
+// findGitDir walks up from dir until it finds a .git directory or file. A
+// directory that is itself a git directory is reported as bare.
+func findGitDir(dir string) (string, bool, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get absolute path for: %s", dir)
+	}
+
+	if isGitDir(dir) {
+		return dir, true, nil
+	}
+
+	for {
+		dotGit := filepath.Join(dir, ".git")
+
+		info, err := os.Stat(dotGit)
+		if err == nil && info.IsDir() && isGitDir(dotGit) {
+			return dotGit, false, nil
+		}
+
+		if err == nil && !info.IsDir() {
+			gitDir, err := readGitFile(dotGit)
+			if err != nil {
+				return "", false, err
+			}
+
+			return gitDir, false, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false, ErrNotRepository
+		}
+
+		dir = parent
+	}
+}
+
+func isGitDir(dir string) bool {
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// readGitFile reads the `gitdir:` pointer of a .git file used by worktrees
+// and submodules.
+func readGitFile(fp string) (string, error) {
+	// nolint:gosec
+	data, err := os.ReadFile(fp)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %s", fp, err)
+	}
+
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", fmt.Errorf("invalid gitfile format: %s", fp)
+	}
+
+	gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(fp), gitDir)
+	}
+
+	return gitDir, nil
+}
+
+// loadObjectDirs adds the object directory, its alternates and their packs.
+func (r *repository) loadObjectDirs(dir string) error {
+	for _, existing := range r.objects {
+		if existing == dir {
+			return nil
+		}
+	}
+
+	r.objects = append(r.objects, dir)
+
+	idxFiles, err := filepath.Glob(filepath.Join(dir, "pack", "*.idx"))
+	if err != nil {
+		return err
+	}
+
+	for _, idxFile := range idxFiles {
+		p, err := openPack(idxFile)
+		if err != nil {
+			return err
+		}
+
+		r.packs = append(r.packs, p)
+	}
+
+	// nolint:gosec
+	data, err := os.ReadFile(filepath.Join(dir, "info", "alternates"))
+	if err != nil {
+		return nil
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(dir, line)
+		}
+
+		if err := r.loadObjectDirs(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadRefs reads packed-refs and then the loose refs, which take precedence.
+func (r *repository) loadRefs() error {
+	// nolint:gosec
+	f, err := os.Open(filepath.Join(r.commonDir, "packed-refs"))
+	if err == nil {
+		defer f.Close()
+
+		var last string
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := scanner.Text()
+
+			switch {
+			case strings.HasPrefix(line, "# pack-refs with:"):
+				r.fullyPeeled = strings.Contains(line, " fully-peeled")
+			case line == "" || strings.HasPrefix(line, "#"):
+			case strings.HasPrefix(line, "^"):
+				if h, err := parseHash(line[1:]); err == nil && last != "" {
+					r.peeled[last] = h
+				}
+			default:
+				fields := strings.Fields(line)
+				if len(fields) != 2 {
+					continue
+				}
+
+				last = fields[1]
+				r.refs[last] = fields[0]
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("failed to read packed-refs: %s", err)
+		}
+	}
+
+	refsDir := filepath.Join(r.commonDir, "refs")
+
+	return filepath.Walk(refsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+
+		// nolint:gosec
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+
+		rel, err := filepath.Rel(r.commonDir, path)
+		if err != nil {
+			return nil
+		}
+
+		name := filepath.ToSlash(rel)
+		r.refs[name] = strings.TrimSpace(string(data))
+		r.loose[name] = true
+
+		delete(r.peeled, name)
+
+		return nil
+	})
+}
+
+// loadShallow reads the shallow file listing commits whose parents are missing.
+func (r *repository) loadShallow() error {
+	// nolint:gosec
+	data, err := os.ReadFile(filepath.Join(r.commonDir, "shallow"))
+	if err != nil {
+		return nil
+	}
+
+	for _, line := range strings.Fields(string(data)) {
+		h, err := parseHash(line)
+		if err != nil {
+			return fmt.Errorf("invalid shallow file: %s", err)
+		}
+
+		r.shallow[h] = true
+	}
+
+	return nil
+}
+
+func (r *repository) close() {
+	for _, p := range r.packs {
+		p.close()
+	}
+}
+
+// head returns the content of HEAD, which is either a symbolic ref or a sha.
+func (r *repository) head() (string, error) {
+	// nolint:gosec
+	data, err := os.ReadFile(filepath.Join(r.gitDir, "HEAD"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read HEAD: %s", err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ref returns the object a ref points at, following symbolic refs.
+func (r *repository) ref(name string) (hash, bool) {
+	for i := 0; i < 10; i++ {
+		var value string
+
+		if name == "HEAD" {
+			head, err := r.head()
+			if err != nil {
+				return hash{}, false
+			}
+
+			value = head
+		} else {
+			v, ok := r.refs[name]
+			if !ok {
+				return hash{}, false
+			}
+
+			value = v
+		}
+
+		if !strings.HasPrefix(value, "ref:") {
+			h, err := parseHash(value)
+
+			return h, err == nil
+		}
+
+		name = strings.TrimSpace(strings.TrimPrefix(value, "ref:"))
+	}
+
+	return hash{}, false
+}
+
+// tagNames returns the names of all tags sorted like git does.
+func (r *repository) tagNames() []string {
+	var names []string
+
+	for name := range r.refs {
+		if strings.HasPrefix(name, "refs/tags/") {
+			names = append(names, strings.TrimPrefix(name, "refs/tags/"))
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// tagRefs returns the tags pointing at commits sorted by name. Peeled values
+// from packed-refs are used when available and tagger dates are only read for
+// commits with more than one annotated tag, where the newest wins.
+func (r *repository) tagRefs() []tagRef {
+	if r.tags != nil {
+		return r.tags
+	}
+
+	tags := []tagRef{}
+	annotated := map[hash]int{}
+
+	for _, name := range r.tagNames() {
+		ref := "refs/tags/" + name
+
+		h, ok := r.ref(ref)
+		if !ok {
+			continue
+		}
+
+		tag := tagRef{name: name, commit: h}
+
+		if peeled, ok := r.peeled[ref]; ok {
+			tag.commit = peeled
+			tag.annotated = true
+		} else if !r.fullyPeeled || r.loose[ref] {
+			target, typ, isAnnotated, _, err := r.peel(h)
+			if err != nil || typ != objectCommit {
+				continue
+			}
+
+			tag.commit = target
+			tag.annotated = isAnnotated
+		}
+
+		if tag.annotated {
+			annotated[tag.commit]++
+		}
+
+		tags = append(tags, tag)
+	}
+
+	for i, tag := range tags {
+		if !tag.annotated || annotated[tag.commit] < 2 {
+			continue
+		}
+
+		if h, ok := r.ref("refs/tags/" + tag.name); ok {
+			_, _, _, tags[i].taggerTime, _ = r.peel(h)
+		}
+	}
+
+	r.tags = tags
+
+	return tags
+}
+
+// readObject returns the type and content of an object.
+func (r *repository) readObject(h hash) (int, []byte, error) {
+	for _, dir := range r.objects {
+		hex := h.String()
+
+		// nolint:gosec
+		f, err := os.Open(filepath.Join(dir, hex[:2], hex[2:]))
+		if err != nil {
+			continue
+		}
+
+		data, err := inflate(f)
+		_ = f.Close()
+
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to read object %s: %s", hex, err)
+		}
+
+		return parseLooseObject(h, data)
+	}
+
+	for _, p := range r.packs {
+		typ, data, err := p.read(h, r.readObject)
+		if errors.Is(err, errObjectNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to read object %s: %s", h, err)
+		}
+
+		return typ, data, nil
+	}
+
+	return 0, nil, fmt.Errorf("object %s not found", h)
+}
+
+func parseLooseObject(h hash, data []byte) (int, []byte, error) {
+	i := bytes.IndexByte(data, 0)
+	if i < 0 {
+		return 0, nil, fmt.Errorf("invalid object %s", h)
+	}
+
+	header := strings.SplitN(string(data[:i]), " ", 2)
+
+	types := map[string]int{"commit": objectCommit, "tree": objectTree, "blob": objectBlob, "tag": objectTag}
+
+	typ, ok := types[header[0]]
+	if !ok {
+		return 0, nil, fmt.Errorf("invalid object type %q of %s", header[0], h)
+	}
+
+	return typ, data[i+1:], nil
+}
+
+// commit returns the parsed commit object. Parents of shallow commits are
+// dropped like git does.
+func (r *repository) commit(h hash) (*commitObject, error) {
+	if c, ok := r.commits[h]; ok {
+		return c, nil
+	}
+
+	typ, data, err := r.readObject(h)
+	if err != nil {
+		return nil, err
+	}
+
+	if typ != objectCommit {
+		return nil, fmt.Errorf("object %s is not a commit", h)
+	}
+
+	c := &commitObject{hash: h}
+
+	headers, message := splitObject(data)
+	c.message = message
+
+	for _, line := range headers {
+		key, value := splitHeader(line)
+
+		switch key {
+		case "parent":
+			p, err := parseHash(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid parent of commit %s", h)
+			}
+
+			c.parents = append(c.parents, p)
+		case "author":
+			if i := strings.Index(value, " <"); i >= 0 {
+				c.author = value[:i]
+			}
+		case "committer":
+			c.committerTime = signatureTime(value)
+		}
+	}
+
+	if r.shallow[h] {
+		c.parents = nil
+	}
+
+	r.commits[h] = c
+
+	return c, nil
+}
+
+// tag returns the parsed tag object.
+func (r *repository) tag(data []byte) (*tagObject, error) {
+	t := &tagObject{}
+
+	headers, _ := splitObject(data)
+
+	for _, line := range headers {
+		key, value := splitHeader(line)
+
+		switch key {
+		case "object":
+			h, err := parseHash(value)
+			if err != nil {
+				return nil, errors.New("invalid tag object")
+			}
+
+			t.object = h
+		case "type":
+			t.objectType = value
+		case "tagger":
+			t.taggerTime = signatureTime(value)
+		}
+	}
+
+	return t, nil
+}
+
+// peel follows annotated tags until it reaches a non-tag object. It returns
+// the object and whether an annotated tag was found on the way, along with
+// the tagger date of the first one.
+func (r *repository) peel(h hash) (hash, int, bool, int64, error) {
+	var (
+		annotated  bool
+		taggerTime int64
+	)
+
+	for i := 0; i < 10; i++ {
+		typ, data, err := r.readObject(h)
+		if err != nil {
+			return hash{}, 0, false, 0, err
+		}
+
+		if typ != objectTag {
+			return h, typ, annotated, taggerTime, nil
+		}
+
+		t, err := r.tag(data)
+		if err != nil {
+			return hash{}, 0, false, 0, err
+		}
+
+		if !annotated {
+			annotated = true
+			taggerTime = t.taggerTime
+		}
+
+		h = t.object
+	}
+
+	return hash{}, 0, false, 0, fmt.Errorf("tag chain too deep at %s", h)
+}
+
+// peelToCommit returns the commit an object points at.
+func (r *repository) peelToCommit(h hash) (hash, error) {
+	target, typ, _, _, err := r.peel(h)
+	if err != nil {
+		return hash{}, err
+	}
+
+	if typ != objectCommit {
+		return hash{}, fmt.Errorf("object %s does not point to a commit", h)
+	}
+
+	return target, nil
+}
+
+// tagCommit returns the commit a tag points at. Peeled values from
+// packed-refs are used when available.
+func (r *repository) tagCommit(name string) (hash, bool) {
+	ref := "refs/tags/" + name
+
+	if h, ok := r.peeled[ref]; ok {
+		return h, true
+	}
+
+	h, ok := r.ref(ref)
+	if !ok {
+		return hash{}, false
+	}
+
+	c, err := r.peelToCommit(h)
+	if err != nil {
+		return hash{}, false
+	}
+
+	return c, true
+}
+
+// resolve resolves a revision to a commit. It supports full and abbreviated
+// shas, ref names as `git rev-parse` looks them up and the `^`, `^N`, `~N`,
+// `^{}` and `^{commit}` suffixes.
+func (r *repository) resolve(rev string) (hash, error) {
+	base := rev
+	suffix := ""
+
+	if i := strings.IndexAny(rev, "^~"); i >= 0 {
+		base, suffix = rev[:i], rev[i:]
+	}
+
+	if base == "" {
+		return hash{}, fmt.Errorf("ambiguous argument '%s': unknown revision", rev)
+	}
+
+	h, err := r.resolveName(base)
+	if err != nil {
+		return hash{}, fmt.Errorf("ambiguous argument '%s': unknown revision", rev)
+	}
+
+	for suffix != "" {
+		switch {
+		case strings.HasPrefix(suffix, "^{}"), strings.HasPrefix(suffix, "^{commit}"):
+			n := strings.Index(suffix, "}") + 1
+			suffix = suffix[n:]
+
+			if h, err = r.peelToCommit(h); err != nil {
+				return hash{}, err
+			}
+		case strings.HasPrefix(suffix, "^"), strings.HasPrefix(suffix, "~"):
+			op := suffix[0]
+			suffix = suffix[1:]
+
+			n := 1
+
+			digits := len(suffix) - len(strings.TrimLeft(suffix, "0123456789"))
+			if digits > 0 {
+				n, _ = strconv.Atoi(suffix[:digits])
+				suffix = suffix[digits:]
+			}
+
+			if h, err = r.peelToCommit(h); err != nil {
+				return hash{}, err
+			}
+
+			if h, err = r.walkUp(h, op, n); err != nil {
+				return hash{}, fmt.Errorf("ambiguous argument '%s': unknown revision", rev)
+			}
+		default:
+			return hash{}, fmt.Errorf("ambiguous argument '%s': unknown revision", rev)
+		}
+	}
+
+	return r.peelToCommit(h)
+}
+
+// walkUp returns the nth parent for `^` or the nth first-parent ancestor for `~`.
+func (r *repository) walkUp(h hash, op byte, n int) (hash, error) {
+	if op == '^' {
+		if n == 0 {
+			return h, nil
+		}
+
+		c, err := r.commit(h)
+		if err != nil {
+			return hash{}, err
+		}
+
+		if n > len(c.parents) {
+			return hash{}, errors.New("no such parent")
+		}
+
+		return c.parents[n-1], nil
+	}
+
+	for i := 0; i < n; i++ {
+		c, err := r.commit(h)
+		if err != nil {
+			return hash{}, err
+		}
+
+		if len(c.parents) == 0 {
+			return hash{}, errors.New("no such ancestor")
+		}
+
+		h = c.parents[0]
+	}
+
+	return h, nil
+}
+
+// resolveName resolves a sha or a ref name in the order used by git.
+func (r *repository) resolveName(name string) (hash, error) {
+	if len(name) == 40 {
+		if h, err := parseHash(name); err == nil {
+			return h, nil
+		}
+	}
+
+	candidates := []string{
+		name,
+		"refs/" + name,
+		"refs/tags/" + name,
+		"refs/heads/" + name,
+		"refs/remotes/" + name,
+		"refs/remotes/" + name + "/HEAD",
+	}
+
+	for _, candidate := range candidates {
+		if candidate != "HEAD" && !strings.HasPrefix(candidate, "refs/") {
+			continue
+		}
+
+		if h, ok := r.ref(candidate); ok {
+			return h, nil
+		}
+	}
+
+	return r.resolveShortHash(name)
+}
+
+// resolveShortHash finds the unique object starting with the abbreviated sha.
+func (r *repository) resolveShortHash(prefix string) (hash, error) {
+	if len(prefix) < 4 || len(prefix) > 40 {
+		return hash{}, errors.New("invalid abbreviated sha")
+	}
+
+	if _, err := hex.DecodeString(prefix[:len(prefix)/2*2]); err != nil {
+		return hash{}, errors.New("invalid abbreviated sha")
+	}
+
+	prefix = strings.ToLower(prefix)
+	found := map[hash]bool{}
+
+	for _, dir := range r.objects {
+		entries, err := os.ReadDir(filepath.Join(dir, prefix[:2]))
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name := prefix[:2] + entry.Name()
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+
+			if h, err := parseHash(name); err == nil {
+				found[h] = true
+			}
+		}
+	}
+
+	for _, p := range r.packs {
+		names := p.index.names
+
+		i := sort.Search(len(names), func(i int) bool {
+			return names[i].String() >= prefix
+		})
+
+		for ; i < len(names) && strings.HasPrefix(names[i].String(), prefix); i++ {
+			found[names[i]] = true
+		}
+	}
+
+	if len(found) != 1 {
+		return hash{}, fmt.Errorf("short sha %s is ambiguous or unknown", prefix)
+	}
+
+	for h := range found {
+		return h, nil
+	}
+
+	return hash{}, nil
+}
+
+// shortHash returns the shortest unique abbreviation of the sha. Like git,
+// the minimum length grows with the number of packed objects.
+func (r *repository) shortHash(h hash) string {
+	if r.names == nil {
+		r.names = r.objectNames()
+	}
+
+	var count int
+	for _, p := range r.packs {
+		count += len(p.index.names)
+	}
+
+	minLen := (bits.Len(uint(count)) + 1) / 2
+	if minLen < 7 {
+		minLen = 7
+	}
+
+	full := h.String()
+	length := minLen
+
+	i := sort.Search(len(r.names), func(i int) bool {
+		return bytes.Compare(r.names[i][:], h[:]) >= 0
+	})
+
+	for _, j := range []int{i - 1, i + 1} {
+		if j < 0 || j >= len(r.names) {
+			continue
+		}
+
+		if n := commonHexPrefix(full, r.names[j].String()) + 1; n > length {
+			length = n
+		}
+	}
+
+	if length > len(full) {
+		length = len(full)
+	}
+
+	return full[:length]
+}
+
+// objectNames returns the sorted names of all loose and packed objects.
+func (r *repository) objectNames() []hash {
+	seen := map[hash]bool{}
+
+	for _, dir := range r.objects {
+		for i := 0; i < 256; i++ {
+			prefix := fmt.Sprintf("%02x", i)
+
+			entries, err := os.ReadDir(filepath.Join(dir, prefix))
+			if err != nil {
+				continue
+			}
+
+			for _, entry := range entries {
+				if h, err := parseHash(prefix + entry.Name()); err == nil {
+					seen[h] = true
+				}
+			}
+		}
+	}
+
+	for _, p := range r.packs {
+		for _, h := range p.index.names {
+			seen[h] = true
+		}
+	}
+
+	names := make([]hash, 0, len(seen))
+	for h := range seen {
+		names = append(names, h)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return bytes.Compare(names[i][:], names[j][:]) < 0
+	})
+
+	return names
+}
+
+func commonHexPrefix(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+
+	return n
+}
+
+func parseHash(s string) (hash, error) {
+	var h hash
+
+	if len(s) != 40 {
+		return h, fmt.Errorf("invalid sha %q", s)
+	}
+
+	if _, err := hex.Decode(h[:], []byte(s)); err != nil {
+		return h, fmt.Errorf("invalid sha %q", s)
+	}
+
+	return h, nil
+}
+
+// splitObject splits a commit or tag into its header lines and message.
+// Continuation lines of multi-line headers like gpgsig are dropped.
+func splitObject(data []byte) ([]string, string) {
+	var headers []string
+
+	content := string(data)
+
+	headerPart, message := content, ""
+	if i := strings.Index(content, "\n\n"); i >= 0 {
+		headerPart, message = content[:i], content[i+2:]
+	}
+
+	for _, line := range strings.Split(headerPart, "\n") {
+		if strings.HasPrefix(line, " ") {
+			continue
+		}
+
+		headers = append(headers, line)
+	}
+
+	return headers, message
+}
+
+func splitHeader(line string) (string, string) {
+	i := strings.IndexByte(line, ' ')
+	if i < 0 {
+		return line, ""
+	}
+
+	return line[:i], line[i+1:]
+}
+
+// signatureTime returns the unix time of an author, committer or tagger line.
+func signatureTime(value string) int64 {
+	i := strings.LastIndex(value, "> ")
+	if i < 0 {
+		return 0
+	}
+
+	fields := strings.Fields(value[i+2:])
+	if len(fields) == 0 {
+		return 0
+	}
+
+	t, _ := strconv.ParseInt(fields[0], 10, 64)
+
+	return t
+}
@@ -0,0 +1,68 @@
+package git_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wakatime/semver-action/pkg/git"
+
+	"github.com/alecthomas/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNative_UnsupportedFormat(t *testing.T) {
+	tests := map[string]struct {
+		Extension string
+		Expected  string
+	}{
+		"sha256 objects": {
+			Extension: "\tobjectFormat = sha256\n",
+			Expected:  "unsupported repository format objectformat sha256, use git_backend exec",
+		},
+		"reftable refs": {
+			Extension: "\trefStorage = reftable\n",
+			Expected:  "unsupported repository format refstorage reftable, use git_backend exec",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			repo, _ := conformanceRepo(t)
+
+			config := filepath.Join(repo, ".git", "config")
+
+			f, err := os.OpenFile(config, os.O_APPEND|os.O_WRONLY, 0600)
+			require.NoError(t, err)
+
+			_, err = f.WriteString("[extensions]\n" + test.Extension)
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+
+			gc := git.NewNative(repo)
+
+			_, err = gc.IsRepo(context.Background())
+			require.ErrorIs(t, err, git.ErrUnsupportedRepository)
+
+			_, err = gc.LatestTag(context.Background())
+			assert.EqualError(t, err, "could not get latest tag: "+test.Expected)
+
+			_, err = gc.AncestorTag(context.Background(), "v[0-9]*", "", "HEAD")
+			assert.EqualError(t, err, "could not get ancestor tag: "+test.Expected)
+		})
+	}
+}
+
+func TestNative_SupportedFormat(t *testing.T) {
+	repo, _ := conformanceRepo(t)
+	runGit(t, repo, "config", "extensions.objectFormat", "sha1")
+
+	gc := git.NewNative(repo)
+
+	isRepo, err := gc.IsRepo(context.Background())
+	require.NoError(t, err)
+
+	assert.True(t, isRepo)
+	assert.Equal(t, "v1.2.0-pre.2", latestTag(t, gc))
+}
@@ -0,0 +1,389 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/apex/log"
+)
+
+// Snapshot answers tag queries in memory. All tags and the commit graph are
+// loaded once with two git commands on the first query, instead of walking the
+// history again for every `rev-list` and `describe`. Other methods run git
+// through the embedded Client. Commands that change tags or history drop the
+// snapshot, so it is loaded again on the next query.
+type Snapshot struct {
+	*Client
+	loaded  bool
+	names   []string
+	tags    []tagRef
+	byName  map[string]hash
+	commits map[hash]*commitObject
+}
+
+// NewSnapshot creates a new snapshot git instance.
+func NewSnapshot(repoDir string) *Snapshot {
+	return &Snapshot{Client: NewGit(repoDir)}
+}
+
+func (s *Snapshot) commit(h hash) (*commitObject, error) {
+	c, ok := s.commits[h]
+	if !ok {
+		return nil, fmt.Errorf("commit %s is not in the snapshot", h)
+	}
+
+	return c, nil
+}
+
+// load reads all tags and the commits reachable from any ref.
+func (s *Snapshot) load(ctx context.Context) error {
+	if s.loaded {
+		return nil
+	}
+
+	if err := s.loadTags(ctx); err != nil {
+		return err
+	}
+
+	if err := s.loadCommits(ctx); err != nil {
+		return err
+	}
+
+	s.loaded = true
+
+	log.Debugf("loaded snapshot with %d tags and %d commits\n", len(s.names), len(s.commits))
+
+	return nil
+}
+
+// loadTags reads the tags with their peeled commits. `show-ref` takes the
+// peeled values from packed-refs, so no tag object needs to be read. Tagger
+// dates are only loaded for commits with more than one annotated tag, where
+// the newest wins.
+func (s *Snapshot) loadTags(ctx context.Context) error {
+	out, err := s.Run(ctx, "-C", s.repoDir, "show-ref", "--tags", "--dereference")
+	if isDone(err) {
+		return err
+	}
+
+	// show-ref fails without output when there are no tags
+	if err != nil && strings.TrimSpace(err.Error()) != "" {
+		return fmt.Errorf("could not list tags: %s", strings.TrimSuffix(err.Error(), "\n"))
+	}
+
+	s.names = nil
+	s.tags = nil
+	s.byName = make(map[string]hash)
+
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "refs/tags/") {
+			return fmt.Errorf("unexpected show-ref format: %q", line)
+		}
+
+		h, err := parseHash(fields[0])
+		if err != nil {
+			return fmt.Errorf("unexpected show-ref format: %q", line)
+		}
+
+		name := strings.TrimPrefix(fields[1], "refs/tags/")
+
+		// the peeled line follows the line of the annotated tag
+		if peeled := strings.TrimSuffix(name, "^{}"); peeled != name {
+			if n := len(s.tags); n > 0 && s.tags[n-1].name == peeled {
+				s.tags[n-1].commit = h
+				s.tags[n-1].annotated = true
+				s.byName[peeled] = h
+			}
+
+			continue
+		}
+
+		s.names = append(s.names, name)
+		s.tags = append(s.tags, tagRef{name: name, commit: h})
+		s.byName[name] = h
+	}
+
+	annotated := map[hash]int{}
+
+	for _, tag := range s.tags {
+		if tag.annotated {
+			annotated[tag.commit]++
+		}
+	}
+
+	var refs []string
+
+	for _, tag := range s.tags {
+		if tag.annotated && annotated[tag.commit] > 1 {
+			refs = append(refs, "refs/tags/"+tag.name)
+		}
+	}
+
+	if len(refs) == 0 {
+		return nil
+	}
+
+	args := append([]string{"-C", s.repoDir, "for-each-ref", "--format=%(refname:strip=2) %(creatordate:unix)"}, refs...)
+
+	out, err = s.Run(ctx, args...)
+	if isDone(err) {
+		return err
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not list tags: %s", strings.TrimSuffix(err.Error(), "\n"))
+	}
+
+	taggerTimes := map[string]int64{}
+
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			taggerTimes[fields[0]], _ = strconv.ParseInt(fields[1], 10, 64)
+		}
+	}
+
+	for i := range s.tags {
+		s.tags[i].taggerTime = taggerTimes[s.tags[i].name]
+	}
+
+	return nil
+}
+
+func (s *Snapshot) loadCommits(ctx context.Context) error {
+	out, err := s.Run(ctx, "-C", s.repoDir, "rev-list", "--all", "--parents", "--timestamp")
+	if isDone(err) {
+		return err
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not list commits: %s", strings.TrimSuffix(err.Error(), "\n"))
+	}
+
+	s.commits = make(map[hash]*commitObject)
+
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return fmt.Errorf("unexpected rev-list format: %q", line)
+		}
+
+		committerTime, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("unexpected rev-list format: %q", line)
+		}
+
+		h, err := parseHash(fields[1])
+		if err != nil {
+			return fmt.Errorf("unexpected rev-list format: %q", line)
+		}
+
+		c := &commitObject{hash: h, committerTime: committerTime}
+
+		for _, field := range fields[2:] {
+			p, err := parseHash(field)
+			if err != nil {
+				return fmt.Errorf("unexpected rev-list format: %q", line)
+			}
+
+			c.parents = append(c.parents, p)
+		}
+
+		s.commits[h] = c
+	}
+
+	return nil
+}
+
+// resolve returns the commit of the revision. Full shas are looked up in
+// memory, other revisions are resolved with git.
+func (s *Snapshot) resolve(ctx context.Context, rev string) (hash, bool) {
+	if h, err := parseHash(rev); err == nil {
+		_, ok := s.commits[h]
+
+		return h, ok
+	}
+
+	sha, err := s.Client.ResolveCommit(ctx, rev)
+	if err != nil {
+		return hash{}, false
+	}
+
+	h, err := parseHash(sha)
+	if err != nil {
+		return hash{}, false
+	}
+
+	_, ok := s.commits[h]
+
+	return h, ok
+}
+
+// LatestTag returns the latest tag if found. An error is only returned when
+// git timed out or was canceled.
+func (s *Snapshot) LatestTag(ctx context.Context) (string, error) {
+	if err := s.load(ctx); err != nil {
+		if isDone(err) {
+			return "", err
+		}
+
+		log.Debugf("failed to load snapshot: %s\n", err)
+
+		return s.Client.LatestTag(ctx)
+	}
+
+	result, _ := latestTag(s, s.tags)
+
+	return result, nil
+}
+
+// AncestorTag returns the previous tag that matches specific pattern if found.
+// The root commit is returned when no tag matches. An error is only returned
+// when git timed out or was canceled.
+func (s *Snapshot) AncestorTag(ctx context.Context, include, exclude, branch string) (string, error) {
+	if err := s.load(ctx); err != nil {
+		if isDone(err) {
+			return "", err
+		}
+
+		log.Debugf("failed to load snapshot: %s\n", err)
+
+		return s.Client.AncestorTag(ctx, include, exclude, branch)
+	}
+
+	if h, ok := s.resolve(ctx, branch); ok {
+		if result, err := describe(s, s.tags, h, include, exclude); err == nil {
+			return result, nil
+		}
+	}
+
+	head, ok := s.resolve(ctx, "HEAD")
+	if !ok {
+		return s.Client.AncestorTag(ctx, include, exclude, branch)
+	}
+
+	root, err := rootCommit(s, head)
+	if err != nil {
+		return "", nil
+	}
+
+	return root.String(), nil
+}
+
+// Tags returns all tags. If reachableFrom is not empty only tags reachable
+// from that revision are returned.
+func (s *Snapshot) Tags(ctx context.Context, reachableFrom string) ([]string, error) {
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+
+	if reachableFrom == "" {
+		return s.names, nil
+	}
+
+	h, ok := s.resolve(ctx, reachableFrom)
+	if !ok {
+		return s.Client.Tags(ctx, reachableFrom)
+	}
+
+	reachable, err := ancestors(s, h)
+	if err != nil {
+		return nil, fmt.Errorf("could not list tags: %s", err)
+	}
+
+	var result []string
+
+	for _, tag := range s.tags {
+		if reachable[tag.commit] {
+			result = append(result, tag.name)
+		}
+	}
+
+	return result, nil
+}
+
+// TagsAt returns the tags pointing at the revision.
+func (s *Snapshot) TagsAt(ctx context.Context, rev string) ([]string, error) {
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+
+	h, ok := s.resolve(ctx, rev)
+	if !ok {
+		return s.Client.TagsAt(ctx, rev)
+	}
+
+	var result []string
+
+	for _, tag := range s.tags {
+		if tag.commit == h {
+			result = append(result, tag.name)
+		}
+	}
+
+	return result, nil
+}
+
+// TagCommit returns the commit sha the tag points at or an empty string if the
+// tag does not exist. An error is only returned when git timed out or was canceled.
+func (s *Snapshot) TagCommit(ctx context.Context, name string) (string, error) {
+	if err := s.load(ctx); err != nil {
+		if isDone(err) {
+			return "", err
+		}
+
+		return s.Client.TagCommit(ctx, name)
+	}
+
+	h, ok := s.byName[name]
+	if _, isCommit := s.commits[h]; !ok || !isCommit {
+		return "", nil
+	}
+
+	return h.String(), nil
+}
+
+// CreateTag creates the tag and drops the snapshot.
+func (s *Snapshot) CreateTag(ctx context.Context, opts TagOptions) error {
+	s.loaded = false
+
+	return s.Client.CreateTag(ctx, opts)
+}
+
+// DeleteTag deletes a local tag and drops the snapshot.
+func (s *Snapshot) DeleteTag(ctx context.Context, name string) error {
+	s.loaded = false
+
+	return s.Client.DeleteTag(ctx, name)
+}
+
+// FetchTags fetches all tags from the remote and drops the snapshot.
+func (s *Snapshot) FetchTags(ctx context.Context, remote string) error {
+	s.loaded = false
+
+	return s.Client.FetchTags(ctx, remote)
+}
+
+// Deepen fetches additional commits and the tags from the remote and drops the snapshot.
+func (s *Snapshot) Deepen(ctx context.Context, remote string, depth int) error {
+	s.loaded = false
+
+	return s.Client.Deepen(ctx, remote, depth)
+}
+
+// Unshallow fetches the complete history and the tags from the remote and drops the snapshot.
+func (s *Snapshot) Unshallow(ctx context.Context, remote string) error {
+	s.loaded = false
+
+	return s.Client.Unshallow(ctx, remote)
+}
@@ -0,0 +1,162 @@
+package git_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wakatime/semver-action/pkg/git"
+
+	"github.com/alecthomas/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot_LoadsOnce(t *testing.T) {
+	repo, shas := conformanceRepo(t)
+
+	gc := git.NewSnapshot(repo)
+
+	var calls []string
+
+	run := gc.GitCmd
+	gc.GitCmd = func(ctx context.Context, env map[string]string, args ...string) (string, error) {
+		calls = append(calls, args[2])
+
+		return run(ctx, env, args...)
+	}
+
+	assert.Equal(t, "v1.2.0-pre.2", latestTag(t, gc))
+	assert.Equal(t, "v1.1.0", ancestorTag(t, gc, "v[0-9]*", "v[0-9]*-pre*", shas["m1"]))
+	assert.Equal(t, "v1.2.0-pre.1", ancestorTag(t, gc, "v[0-9]*-pre*", "", shas["d1"]))
+	assert.Equal(t, shas["c3"], tagCommit(t, gc, "v1.1.0"))
+
+	assert.Equal(t, []string{"show-ref", "rev-list"}, calls)
+
+	require.NoError(t, gc.CreateTag(context.Background(), git.TagOptions{Name: "v1.2.0", Ref: shas["m1"]}))
+
+	assert.Equal(t, "v1.2.0", ancestorTag(t, gc, "v[0-9]*", "v[0-9]*-pre*", shas["m1"]))
+	assert.Equal(t, []string{"show-ref", "rev-list", "tag", "show-ref", "rev-list"}, calls)
+}
+
+func TestSnapshot_UnknownCommit(t *testing.T) {
+	repo, shas := conformanceRepo(t)
+
+	gc := git.NewSnapshot(repo)
+
+	assert.Equal(t, "v1.2.0-pre.2", latestTag(t, gc))
+
+	// a commit created after the snapshot was loaded is resolved with git
+	sha := commitAt(t, repo, 100, "feat: add profile")
+
+	assert.Equal(t, "v1.2.0-pre.2", ancestorTag(t, gc, "v[0-9]*-pre*", "", sha))
+
+	tags, err := gc.TagsAt(context.Background(), shas["m1"])
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a-nightly", "v1.2.0-pre.2"}, tags)
+}
+
+// BenchmarkTagQueries runs the queries Tag makes to find the previous tags
+// with every backend. The monorepo has tags of many other components and only
+// a few lightweight release tags, so describe has to walk the whole history.
+func BenchmarkTagQueries(b *testing.B) {
+	for _, size := range []struct {
+		Name       string
+		Commits    int
+		OtherTags  int
+		ReleaseTag int
+	}{
+		{Name: "project", Commits: 1000, ReleaseTag: 10},
+		{Name: "monorepo", Commits: 20000, OtherTags: 15000, ReleaseTag: 2000},
+	} {
+		repo := benchmarkRepo(b, size.Commits, size.OtherTags, size.ReleaseTag)
+
+		for _, backend := range backends {
+			b.Run(size.Name+"/"+backend.Name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					gc := backend.New(repo)
+
+					if latestTag(b, gc) == "" {
+						b.Fatal("no latest tag found")
+					}
+
+					if _, err := gc.TagsAt(context.Background(), "HEAD"); err != nil {
+						b.Fatal(err)
+					}
+
+					if ancestorTag(b, gc, "v[0-9]*", "v[0-9]*-pre*", "master") == "" {
+						b.Fatal("no ancestor tag found")
+					}
+
+					if ancestorTag(b, gc, "v[0-9]*-pre*", "", "master~1") == "" {
+						b.Fatal("no ancestor prerelease tag found")
+					}
+				}
+			})
+		}
+	}
+}
+
+// benchmarkRepo creates a repository with a linear history using git
+// fast-import. The last commits get an annotated tag of another component
+// each, every releaseTag commits get a lightweight release tag and every
+// tenth of those a prerelease tag too.
+func benchmarkRepo(b *testing.B, commits, otherTags, releaseTag int) string {
+	repo := filepath.Join(b.TempDir(), "repo")
+
+	out, err := exec.Command("git", "init", "--quiet", "--initial-branch=master", repo).CombinedOutput()
+	require.NoError(b, err, string(out))
+
+	var stream bytes.Buffer
+
+	for i := 1; i <= commits; i++ {
+		date := 1600000000 + i*60
+		message := fmt.Sprintf("commit %d", i)
+
+		fmt.Fprintf(&stream, "commit refs/heads/master\nmark :%d\n", i)
+		fmt.Fprintf(&stream, "committer John Doe <john@example.com> %d +0000\n", date)
+		fmt.Fprintf(&stream, "data %d\n%s\n", len(message), message)
+
+		if i > 1 {
+			fmt.Fprintf(&stream, "from :%d\n", i-1)
+		}
+
+		stream.WriteString("\n")
+
+		if i > commits-otherTags {
+			message = fmt.Sprintf("release %d", i)
+
+			fmt.Fprintf(&stream, "tag component-%d/v1.0.%d\nfrom :%d\n", i%50, i, i)
+			fmt.Fprintf(&stream, "tagger John Doe <john@example.com> %d +0000\n", date)
+			fmt.Fprintf(&stream, "data %d\n%s\n", len(message), message)
+		}
+
+		if i%releaseTag == 0 {
+			n := i / releaseTag
+
+			fmt.Fprintf(&stream, "reset refs/tags/v%d.0.0\nfrom :%d\n\n", n, i)
+
+			if n%10 == 0 {
+				fmt.Fprintf(&stream, "reset refs/tags/v%d.0.0-pre.1\nfrom :%d\n\n", n+1, i)
+			}
+		}
+	}
+
+	cmd := exec.Command("git", "-C", repo, "fast-import", "--quiet")
+	cmd.Stdin = &stream
+
+	out, err = cmd.CombinedOutput()
+	require.NoError(b, err, string(out))
+
+	out, err = exec.Command("git", "-C", repo, "pack-refs", "--all").CombinedOutput()
+	require.NoError(b, err, strings.TrimSpace(string(out)))
+
+	out, err = exec.Command("git", "-C", repo, "checkout", "--quiet", "master").CombinedOutput()
+	require.NoError(b, err, strings.TrimSpace(string(out)))
+
+	return repo
+}
@@ -0,0 +1,310 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/wakatime/semver-action/pkg/actions"
+	"github.com/wakatime/semver-action/pkg/ci"
+
+	"github.com/apex/log"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats.
+const (
+	FormatGithubOutput = "github_output"
+	FormatGithubEnv    = "github_env"
+	FormatDotenv       = "dotenv"
+	FormatShell        = "shell"
+	FormatJSON         = "json"
+	FormatYAML         = "yaml"
+	FormatProperties   = "properties"
+	FormatAzure        = "azure"
+)
+
+// nolint: gochecknoglobals
+var defaultPaths = map[string]string{
+	FormatDotenv:     "semver.env",
+	FormatShell:      "semver.sh",
+	FormatJSON:       "semver.json",
+	FormatYAML:       "semver.yaml",
+	FormatProperties: "semver.properties",
+}
+
+// Value is a named output value.
+type Value struct {
+	Name  string
+	Value string
+}
+
+// Outputs contains the output values and the structured data they were built from.
+type Outputs struct {
+	Values []Value
+	// Data is encoded by the json and yaml writers. The values are used if nil.
+	Data interface{}
+}
+
+// Writer writes outputs to a sink.
+type Writer interface {
+	Write(outputs Outputs) error
+}
+
+// Format is an output format with an optional destination path.
+type Format struct {
+	Name string
+	Path string
+}
+
+// ParseFormats parses a comma or newline separated list of formats. Each entry is
+// a format name optionally followed by `=path`, e.g. `dotenv=.env,github_env`.
+func ParseFormats(s string) ([]Format, error) {
+	var formats []Format
+
+	for _, entry := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, path, _ := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		path = strings.TrimSpace(path)
+
+		switch name {
+		case FormatGithubOutput, FormatGithubEnv, FormatAzure:
+		case FormatDotenv, FormatShell, FormatJSON, FormatYAML, FormatProperties:
+			if path == "" {
+				path = defaultPaths[name]
+			}
+		default:
+			return nil, fmt.Errorf("invalid output format: %s", name)
+		}
+
+		formats = append(formats, Format{Name: name, Path: path})
+	}
+
+	return formats, nil
+}
+
+// New creates a writer for the format. GitHub formats fall back to the path
+// from GITHUB_OUTPUT and GITHUB_ENV, azure writes to stdout.
+func New(format Format, stdout io.Writer) (Writer, error) {
+	switch format.Name {
+	case FormatGithubOutput:
+		return newGithubFileWriter(format.Path, "GITHUB_OUTPUT", strings.ToUpper)
+	case FormatGithubEnv:
+		return newGithubFileWriter(format.Path, "GITHUB_ENV", EnvName)
+	case FormatDotenv:
+		return fileWriter{path: format.Path, format: formatDotenv}, nil
+	case FormatShell:
+		return fileWriter{path: format.Path, format: formatShell}, nil
+	case FormatJSON:
+		return fileWriter{path: format.Path, format: formatJSON}, nil
+	case FormatYAML:
+		return fileWriter{path: format.Path, format: formatYAML}, nil
+	case FormatProperties:
+		return fileWriter{path: format.Path, format: formatProperties}, nil
+	case FormatAzure:
+		return azureWriter{w: stdout}, nil
+	default:
+		return nil, fmt.Errorf("invalid output format: %s", format.Name)
+	}
+}
+
+// DefaultFormats returns the output formats following the convention of the CI system.
+func DefaultFormats(provider ci.Provider) []Format {
+	switch provider {
+	case ci.GitHub:
+		if os.Getenv("GITHUB_OUTPUT") == "" {
+			return nil
+		}
+
+		return []Format{{Name: FormatGithubOutput}}
+	case ci.Azure:
+		return []Format{{Name: FormatAzure}}
+	case ci.Jenkins:
+		return []Format{{Name: FormatProperties, Path: defaultPaths[FormatProperties]}}
+	case ci.GitLab, ci.CircleCI, ci.Buildkite, ci.Drone:
+		return []Format{{Name: FormatDotenv, Path: defaultPaths[FormatDotenv]}}
+	default:
+		return nil
+	}
+}
+
+// WriteAll writes the outputs with every writer.
+func WriteAll(writers []Writer, outputs Outputs) error {
+	for _, w := range writers {
+		if err := w.Write(outputs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EnvName returns the environment variable name for an output, prefixed with SEMVER_.
+func EnvName(name string) string {
+	name = strings.ToUpper(name)
+	if strings.HasPrefix(name, "SEMVER_") {
+		return name
+	}
+
+	return "SEMVER_" + name
+}
+
+// githubFileWriter appends values to a GitHub Actions file command.
+type githubFileWriter struct {
+	path string
+	key  func(name string) string
+}
+
+func newGithubFileWriter(path, envKey string, key func(name string) string) (Writer, error) {
+	if path == "" {
+		path = os.Getenv(envKey)
+	}
+
+	if path == "" {
+		return nil, fmt.Errorf("%s is not set", envKey)
+	}
+
+	return githubFileWriter{path: path, key: key}, nil
+}
+
+func (w githubFileWriter) Write(outputs Outputs) error {
+	for _, v := range outputs.Values {
+		if err := actions.AppendKeyValue(w.path, w.key(v.Name), v.Value); err != nil {
+			return fmt.Errorf("failed to write %s to output: %s", v.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// fileWriter writes the formatted outputs to a file, replacing its content.
+type fileWriter struct {
+	path   string
+	format func(outputs Outputs) (string, error)
+}
+
+func (w fileWriter) Write(outputs Outputs) error {
+	content, err := w.format(outputs)
+	if err != nil {
+		return err
+	}
+
+	// nolint:gosec
+	if err := os.WriteFile(w.path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write outputs to %s: %s", w.path, err)
+	}
+
+	return nil
+}
+
+// azureWriter sets pipeline variables with ##vso logging commands.
+type azureWriter struct {
+	w io.Writer
+}
+
+func (w azureWriter) Write(outputs Outputs) error {
+	for _, v := range outputs.Values {
+		if strings.Contains(v.Value, "\n") {
+			log.Warnf("skipping multiline output %s, azure variables can't hold newlines\n", v.Name)
+			continue
+		}
+
+		_, err := fmt.Fprintf(w.w, "##vso[task.setvariable variable=%s;isOutput=true]%s\n", EnvName(v.Name), v.Value)
+		if err != nil {
+			return fmt.Errorf("failed to write %s to output: %s", v.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// formatDotenv formats single line values as KEY=value lines.
+func formatDotenv(outputs Outputs) (string, error) {
+	var b strings.Builder
+
+	for _, v := range outputs.Values {
+		if strings.Contains(v.Value, "\n") {
+			log.Warnf("skipping multiline output %s, dotenv files can't hold newlines\n", v.Name)
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s=%s\n", EnvName(v.Name), v.Value)
+	}
+
+	return b.String(), nil
+}
+
+// formatShell formats values as sourceable export lines.
+func formatShell(outputs Outputs) (string, error) {
+	var b strings.Builder
+
+	for _, v := range outputs.Values {
+		fmt.Fprintf(&b, "export %s='%s'\n", EnvName(v.Name), strings.ReplaceAll(v.Value, "'", `'\''`))
+	}
+
+	return b.String(), nil
+}
+
+// formatProperties formats values as java properties with escaped values.
+func formatProperties(outputs Outputs) (string, error) {
+	replacer := strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`, "=", `\=`, ":", `\:`)
+
+	var b strings.Builder
+
+	for _, v := range outputs.Values {
+		fmt.Fprintf(&b, "%s=%s\n", EnvName(v.Name), replacer.Replace(v.Value))
+	}
+
+	return b.String(), nil
+}
+
+func formatJSON(outputs Outputs) (string, error) {
+	data, err := json.MarshalIndent(outputs.data(), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode json outputs: %s", err)
+	}
+
+	return string(data) + "\n", nil
+}
+
+func formatYAML(outputs Outputs) (string, error) {
+	// round trip through json so field names follow the json tags
+	data, err := json.Marshal(outputs.data())
+	if err != nil {
+		return "", fmt.Errorf("failed to encode yaml outputs: %s", err)
+	}
+
+	var decoded interface{}
+
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return "", fmt.Errorf("failed to encode yaml outputs: %s", err)
+	}
+
+	encoded, err := yaml.Marshal(decoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode yaml outputs: %s", err)
+	}
+
+	return string(encoded), nil
+}
+
+// data returns the structured data or a map of the values.
+func (o Outputs) data() interface{} {
+	if o.Data != nil {
+		return o.Data
+	}
+
+	values := make(map[string]string, len(o.Values))
+	for _, v := range o.Values {
+		values[v.Name] = v.Value
+	}
+
+	return values
+}
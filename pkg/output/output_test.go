@@ -0,0 +1,234 @@
+package output_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/wakatime/semver-action/pkg/ci"
+	"github.com/wakatime/semver-action/pkg/output"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testOutputs = output.Outputs{ // nolint:gochecknoglobals
+	Values: []output.Value{
+		{Name: "semver_tag", Value: "v1.2.0"},
+		{Name: "is_prerelease", Value: "false"},
+		{Name: "changelog", Value: "### Features\n\n- Add user's login"},
+	},
+}
+
+func TestParseFormats(t *testing.T) {
+	formats, err := output.ParseFormats("dotenv, shell=out/semver.sh\ngithub_env,json=result.json,yaml")
+	require.NoError(t, err)
+
+	assert.Equal(t, []output.Format{
+		{Name: output.FormatDotenv, Path: "semver.env"},
+		{Name: output.FormatShell, Path: "out/semver.sh"},
+		{Name: output.FormatGithubEnv},
+		{Name: output.FormatJSON, Path: "result.json"},
+		{Name: output.FormatYAML, Path: "semver.yaml"},
+	}, formats)
+}
+
+func TestParseFormats_Empty(t *testing.T) {
+	formats, err := output.ParseFormats("")
+	require.NoError(t, err)
+
+	assert.Empty(t, formats)
+}
+
+func TestParseFormats_Invalid(t *testing.T) {
+	_, err := output.ParseFormats("dotenv,xml")
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "invalid output format: xml")
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "SEMVER_TAG", output.EnvName("semver_tag"))
+	assert.Equal(t, "SEMVER_IS_PRERELEASE", output.EnvName("is_prerelease"))
+}
+
+func TestWriter_GithubOutput(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "github_output")
+	t.Setenv("GITHUB_OUTPUT", fp)
+
+	w, err := output.New(output.Format{Name: output.FormatGithubOutput}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Write(testOutputs))
+
+	content, err := os.ReadFile(fp)
+	require.NoError(t, err)
+
+	re := regexp.MustCompile(`ghadelimiter_[0-9a-f-]+`)
+
+	assert.Equal(t, "SEMVER_TAG<<EOF\nv1.2.0\nEOF\n"+
+		"IS_PRERELEASE<<EOF\nfalse\nEOF\n"+
+		"CHANGELOG<<EOF\n### Features\n\n- Add user's login\nEOF\n", re.ReplaceAllString(string(content), "EOF"))
+}
+
+func TestWriter_GithubEnv(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "github_env")
+	t.Setenv("GITHUB_ENV", fp)
+
+	w, err := output.New(output.Format{Name: output.FormatGithubEnv}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Write(testOutputs))
+
+	content, err := os.ReadFile(fp)
+	require.NoError(t, err)
+
+	re := regexp.MustCompile(`ghadelimiter_[0-9a-f-]+`)
+
+	assert.Equal(t, "SEMVER_TAG<<EOF\nv1.2.0\nEOF\n"+
+		"SEMVER_IS_PRERELEASE<<EOF\nfalse\nEOF\n"+
+		"SEMVER_CHANGELOG<<EOF\n### Features\n\n- Add user's login\nEOF\n", re.ReplaceAllString(string(content), "EOF"))
+}
+
+func TestWriter_GithubEnvUnset(t *testing.T) {
+	t.Setenv("GITHUB_ENV", "")
+
+	_, err := output.New(output.Format{Name: output.FormatGithubEnv}, nil)
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "GITHUB_ENV is not set")
+}
+
+func TestWriter_Dotenv(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "semver.env")
+
+	logs := captureLogs(t)
+
+	content := write(t, output.Format{Name: output.FormatDotenv, Path: fp})
+
+	assert.Equal(t, "SEMVER_TAG=v1.2.0\nSEMVER_IS_PRERELEASE=false\n", content)
+
+	require.Len(t, logs.Entries, 1)
+	assert.Equal(t, log.WarnLevel, logs.Entries[0].Level)
+	assert.Equal(t, "skipping multiline output changelog, dotenv files can't hold newlines\n", logs.Entries[0].Message)
+}
+
+func TestWriter_Shell(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "semver.sh")
+
+	content := write(t, output.Format{Name: output.FormatShell, Path: fp})
+
+	assert.Equal(t, "export SEMVER_TAG='v1.2.0'\n"+
+		"export SEMVER_IS_PRERELEASE='false'\n"+
+		"export SEMVER_CHANGELOG='### Features\n\n- Add user'\\''s login'\n", content)
+}
+
+func TestWriter_Properties(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "semver.properties")
+
+	content := write(t, output.Format{Name: output.FormatProperties, Path: fp})
+
+	assert.Equal(t, "SEMVER_TAG=v1.2.0\nSEMVER_IS_PRERELEASE=false\n"+
+		"SEMVER_CHANGELOG=### Features\\n\\n- Add user's login\n", content)
+}
+
+func TestWriter_JSON(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "semver.json")
+
+	content := write(t, output.Format{Name: output.FormatJSON, Path: fp})
+
+	assert.JSONEq(t, `{
+		"semver_tag": "v1.2.0",
+		"is_prerelease": "false",
+		"changelog": "### Features\n\n- Add user's login"
+	}`, content)
+}
+
+func TestWriter_YAMLData(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "semver.yaml")
+
+	w, err := output.New(output.Format{Name: output.FormatYAML, Path: fp}, nil)
+	require.NoError(t, err)
+
+	data := struct {
+		SemverTag    string `json:"semver_tag"`
+		IsPrerelease bool   `json:"is_prerelease"`
+	}{
+		SemverTag:    "v1.2.0",
+		IsPrerelease: true,
+	}
+
+	require.NoError(t, w.Write(output.Outputs{Values: testOutputs.Values, Data: data}))
+
+	content, err := os.ReadFile(fp)
+	require.NoError(t, err)
+
+	assert.Equal(t, "is_prerelease: true\nsemver_tag: v1.2.0\n", string(content))
+}
+
+func TestWriter_Azure(t *testing.T) {
+	var stdout bytes.Buffer
+
+	logs := captureLogs(t)
+
+	w, err := output.New(output.Format{Name: output.FormatAzure}, &stdout)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Write(testOutputs))
+
+	assert.Equal(t, "##vso[task.setvariable variable=SEMVER_TAG;isOutput=true]v1.2.0\n"+
+		"##vso[task.setvariable variable=SEMVER_IS_PRERELEASE;isOutput=true]false\n", stdout.String())
+
+	require.Len(t, logs.Entries, 1)
+	assert.Equal(t, log.WarnLevel, logs.Entries[0].Level)
+	assert.Equal(t, "skipping multiline output changelog, azure variables can't hold newlines\n", logs.Entries[0].Message)
+}
+
+func TestDefaultFormats(t *testing.T) {
+	t.Setenv("GITHUB_OUTPUT", "/tmp/github_output")
+
+	tests := map[ci.Provider][]output.Format{
+		ci.GitHub:   {{Name: output.FormatGithubOutput}},
+		ci.Azure:    {{Name: output.FormatAzure}},
+		ci.Jenkins:  {{Name: output.FormatProperties, Path: "semver.properties"}},
+		ci.GitLab:   {{Name: output.FormatDotenv, Path: "semver.env"}},
+		ci.CircleCI: {{Name: output.FormatDotenv, Path: "semver.env"}},
+		ci.Unknown:  nil,
+	}
+
+	for provider, expected := range tests {
+		t.Run(string(provider), func(t *testing.T) {
+			assert.Equal(t, expected, output.DefaultFormats(provider))
+		})
+	}
+}
+
+func write(t *testing.T, format output.Format) string {
+	w, err := output.New(format, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Write(testOutputs))
+
+	content, err := os.ReadFile(format.Path)
+	require.NoError(t, err)
+
+	return string(content)
+}
+
+// captureLogs records the log entries of the test.
+func captureLogs(t *testing.T) *memory.Handler {
+	logger, ok := log.Log.(*log.Logger)
+	require.True(t, ok)
+
+	previous := logger.Handler
+	handler := memory.New()
+
+	log.SetHandler(handler)
+	t.Cleanup(func() { log.SetHandler(previous) })
+
+	return handler
+}
@@ -0,0 +1,36 @@
+package semver
+
+import "regexp"
+
+// BranchType is a kind of branch recognized by its name prefix.
+type BranchType string
+
+// Branch types.
+const (
+	BranchBugfix  BranchType = "bugfix"
+	BranchDoc     BranchType = "doc"
+	BranchFeature BranchType = "feature"
+	BranchHotfix  BranchType = "hotfix"
+	BranchMajor   BranchType = "major"
+	BranchMisc    BranchType = "misc"
+	BranchResync  BranchType = "resync"
+)
+
+// nolint: gochecknoglobals
+var branchPrefixRegexes = map[BranchType]*regexp.Regexp{
+	BranchBugfix:  regexp.MustCompile(`(?i)^(.+:)?(bugfix/.+)`),
+	BranchDoc:     regexp.MustCompile(`(?i)^(.+:)?(docs?/.+)`),
+	BranchFeature: regexp.MustCompile(`(?i)^(.+:)?(feature/.+)`),
+	BranchHotfix:  regexp.MustCompile(`(?i)^(.+:)?(hotfix/.+)`),
+	BranchMajor:   regexp.MustCompile(`(?i)^(.+:)?(major/.+)`),
+	BranchMisc:    regexp.MustCompile(`(?i)^(.+:)?(misc/.+)`),
+	BranchResync:  regexp.MustCompile(`(?i)^(.+:)?(resync/.+)`),
+}
+
+// Match returns true if the branch name has the prefix of the branch type,
+// e.g. feature/login or owner:feature/login for BranchFeature.
+func (t BranchType) Match(branch string) bool {
+	re, ok := branchPrefixRegexes[t]
+
+	return ok && re.MatchString(branch)
+}
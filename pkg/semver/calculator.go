@@ -0,0 +1,307 @@
+// Package semver calculates the next semantic version of a merge from the
+// source and dest branch and the existing tags. It does not run git or read
+// the environment, the caller looks up the tags and passes them as Input.
+package semver
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/blang/semver/v4"
+)
+
+const (
+	// InitialVersion is the version bumped when there is no previous tag.
+	InitialVersion = "0.0.0"
+	// PreviewID is the first prerelease identifier of pull request previews.
+	PreviewID = "pr"
+)
+
+// Config contains the settings applied to every calculation.
+type Config struct {
+	// Bump is BumpAuto or the method to force, e.g. major.
+	Bump string
+	// Prefix is prepended to calculated tags, e.g. v.
+	Prefix string
+	// PrereleaseID is the first prerelease identifier of builds, e.g. pre.
+	PrereleaseID  string
+	MainBranch    string
+	DevelopBranch string
+	// BranchSnapshots excludes all prerelease tags, not only the develop
+	// ones, from the ancestor patterns of final versions.
+	BranchSnapshots bool
+}
+
+// Input contains the state of the repository a version is calculated from.
+type Input struct {
+	SourceBranch string
+	DestBranch   string
+	// LatestTag is the tag the version is bumped from, empty if there is none.
+	LatestTag string
+	// BaseVersion replaces the latest tag as the version to bump if set.
+	BaseVersion *semver.Version
+	// AncestorDevelopTag is the latest prerelease tag on the develop branch. It
+	// is only read when NeedsAncestorDevelopTag returns true and is looked up
+	// with DevelopTagPattern.
+	AncestorDevelopTag string
+	// Snapshot is set when calculating the snapshot of a branch, which is not
+	// merged into develop yet, so the ancestor develop tag is never used.
+	Snapshot bool
+}
+
+// Substitution records whether the ancestor develop tag replaced the latest tag
+// for doc and misc branches.
+type Substitution struct {
+	Applied bool
+	Reason  string
+}
+
+// Output is the calculated version.
+type Output struct {
+	Strategy Strategy
+	// Tag is the calculated version with the prefix.
+	Tag     string
+	Version semver.Version
+	// PreviousTag is the version bumped from with the prefix.
+	PreviousTag  string
+	IsPrerelease bool
+	Substitution Substitution
+	// AncestorInclude and AncestorExclude are the patterns of the tag on the
+	// dest branch the changelog starts from.
+	AncestorInclude string
+	AncestorExclude string
+}
+
+// Calculator calculates semantic versions.
+type Calculator struct {
+	config Config
+}
+
+// NewCalculator creates a new Calculator instance.
+func NewCalculator(config Config) *Calculator {
+	return &Calculator{config: config}
+}
+
+// Strategy determines how a merge of the source into the dest branch bumps the version.
+func (c *Calculator) Strategy(sourceBranch, destBranch string) Strategy {
+	if c.config.Bump != BumpAuto {
+		return Strategy{Method: Method(c.config.Bump), Rule: RuleForced}
+	}
+
+	develop, main := c.config.DevelopBranch, c.config.MainBranch
+
+	switch {
+	// bugfix into develop branch
+	case BranchBugfix.Match(sourceBranch) && destBranch == develop:
+		return Strategy{Method: MethodBuild, Component: ComponentPatch, Rule: RuleBugfixIntoDevelop}
+	// doc into develop branch
+	case BranchDoc.Match(sourceBranch) && destBranch == develop:
+		return Strategy{Method: MethodBuild, Rule: RuleDocIntoDevelop}
+	// feature into develop
+	case BranchFeature.Match(sourceBranch) && destBranch == develop:
+		return Strategy{Method: MethodBuild, Component: ComponentMinor, Rule: RuleFeatureIntoDevelop}
+	// major into develop
+	case BranchMajor.Match(sourceBranch) && destBranch == develop:
+		return Strategy{Method: MethodBuild, Component: ComponentMajor, Rule: RuleMajorIntoDevelop}
+	// misc into develop branch
+	case BranchMisc.Match(sourceBranch) && destBranch == develop:
+		return Strategy{Method: MethodBuild, Rule: RuleMiscIntoDevelop}
+	// hotfix into main branch
+	case BranchHotfix.Match(sourceBranch) && destBranch == main:
+		return Strategy{Method: MethodHotfix, Rule: RuleHotfixIntoMain}
+	// resync into develop
+	case BranchResync.Match(sourceBranch) && destBranch == develop:
+		return Strategy{Method: MethodBuild, Component: ComponentPatch, Rule: RuleResyncIntoDevelop}
+	// develop branch into main branch
+	case sourceBranch == develop && destBranch == main:
+		return Strategy{Method: MethodFinal, Rule: RuleDevelopIntoMain}
+	default:
+		return Strategy{Method: MethodBuild, Rule: RuleFallback}
+	}
+}
+
+// DevelopTagPattern returns the git pattern matching prerelease tags.
+func (c *Calculator) DevelopTagPattern() string {
+	return fmt.Sprintf("%s[0-9]*-%s*", c.config.Prefix, c.config.PrereleaseID)
+}
+
+// finalExcludePattern returns the git pattern of the tags excluded from the
+// ancestor of final versions.
+func (c *Calculator) finalExcludePattern() string {
+	if c.config.BranchSnapshots {
+		return fmt.Sprintf("%s[0-9]*-*", c.config.Prefix)
+	}
+
+	return c.DevelopTagPattern()
+}
+
+// NeedsAncestorDevelopTag returns true if Input.AncestorDevelopTag is used for
+// the input.
+func (c *Calculator) NeedsAncestorDevelopTag(in Input) bool {
+	_, ok := c.substitutionSkipped(in)
+
+	return !ok
+}
+
+// substitutionSkipped returns the reason the ancestor develop tag can't replace
+// the latest tag and true, or false if it may.
+func (c *Calculator) substitutionSkipped(in Input) (string, bool) {
+	switch {
+	case in.Snapshot:
+		return "snapshot of a branch not merged yet", true
+	case !BranchDoc.Match(in.SourceBranch) && !BranchMisc.Match(in.SourceBranch):
+		return "source branch is not prefixed with doc or misc", true
+	case in.DestBranch != c.config.DevelopBranch:
+		return fmt.Sprintf("dest branch is not %s", c.config.DevelopBranch), true
+	default:
+		return "", false
+	}
+}
+
+// Calculate returns the next version for the input. An InvalidTagError is
+// returned if the latest or ancestor develop tag isn't a semantic version.
+func (c *Calculator) Calculate(in Input) (Output, error) {
+	strategy := c.Strategy(in.SourceBranch, in.DestBranch)
+
+	tag, err := semver.New(InitialVersion)
+	if err != nil {
+		return Output{}, err
+	}
+
+	if in.LatestTag != "" {
+		parsed, err := parseTag(in.LatestTag)
+		if err != nil {
+			return Output{}, err
+		}
+
+		tag = &parsed
+	}
+
+	out := Output{
+		Strategy:    strategy,
+		PreviousTag: c.config.Prefix + tag.String(),
+	}
+
+	if in.BaseVersion != nil {
+		// copy base version as it gets incremented below
+		baseVersion := *in.BaseVersion
+		tag = &baseVersion
+	}
+
+	if strategy.Increments(ComponentMajor) {
+		if err := tag.IncrementMajor(); err != nil {
+			return Output{}, fmt.Errorf("failed to increment major version: %s", err)
+		}
+	}
+
+	if strategy.Increments(ComponentMinor) {
+		if err := tag.IncrementMinor(); err != nil {
+			return Output{}, fmt.Errorf("failed to increment minor version: %s", err)
+		}
+	}
+
+	if strategy.Increments(ComponentPatch) {
+		if err := tag.IncrementPatch(); err != nil {
+			return Output{}, fmt.Errorf("failed to increment patch version: %s", err)
+		}
+	}
+
+	// If branch is prefixed with doc or misc and the latest tag is equal to the
+	// ancestor develop tag excluding prerelease part, then it will use ancestor one instead.
+	if reason, skipped := c.substitutionSkipped(in); skipped {
+		out.Substitution.Reason = reason
+	} else {
+		parsed, err := parseTag(in.AncestorDevelopTag)
+		if err != nil {
+			return Output{}, err
+		}
+
+		if tag.String() == parsed.FinalizeVersion() {
+			out.Substitution.Applied = true
+			out.Substitution.Reason = fmt.Sprintf(
+				"latest tag %s equals ancestor develop tag %s excluding prerelease part",
+				tag.String(), in.AncestorDevelopTag)
+			tag = &parsed
+		} else {
+			out.Substitution.Reason = fmt.Sprintf(
+				"latest tag %s differs from ancestor develop tag %s excluding prerelease part",
+				tag.String(), in.AncestorDevelopTag)
+		}
+	}
+
+	finalPattern := fmt.Sprintf("%s[0-9]*", c.config.Prefix)
+
+	switch strategy.Method {
+	case MethodBuild:
+		out.IsPrerelease = true
+		out.AncestorInclude = c.DevelopTagPattern()
+
+		buildNumber, _ := semver.NewPRVersion("0")
+
+		if len(tag.Pre) > 1 && strategy.Component == ComponentNone {
+			buildNumber = tag.Pre[1]
+		}
+
+		preVersion, err := semver.NewPRVersion(c.config.PrereleaseID)
+		if err != nil {
+			return Output{}, fmt.Errorf("failed to create new prerelease version: %s", err)
+		}
+
+		buildVersion, err := semver.NewPRVersion(strconv.Itoa(int(buildNumber.VersionNum + 1)))
+		if err != nil {
+			return Output{}, fmt.Errorf("failed to create new build version: %s", err)
+		}
+
+		tag.Pre = []semver.PRVersion{preVersion, buildVersion}
+	case MethodMajor, MethodMinor, MethodPatch:
+		if len(tag.Pre) > 0 {
+			out.IsPrerelease = true
+			out.AncestorInclude = c.DevelopTagPattern()
+		} else {
+			out.AncestorInclude = finalPattern
+			out.AncestorExclude = c.finalExcludePattern()
+		}
+	default:
+		out.AncestorInclude = finalPattern
+		out.AncestorExclude = c.finalExcludePattern()
+		tag.Pre = nil
+		tag.Build = nil
+	}
+
+	out.Version = *tag
+	out.Tag = c.config.Prefix + tag.String()
+
+	return out, nil
+}
+
+// Preview turns the calculated version of a merge into the preview version of
+// the pull request, e.g. 1.6.0-pr.482.3. The prerelease is replaced with the
+// pull request number and the counter and the build metadata is dropped.
+func (c *Calculator) Preview(out Output, pullRequest, counter uint64) Output {
+	version := out.Version
+	version.Pre = []semver.PRVersion{
+		{VersionStr: PreviewID},
+		{VersionNum: pullRequest, IsNum: true},
+		{VersionNum: counter, IsNum: true},
+	}
+	version.Build = nil
+
+	out.Version = version
+	out.Tag = c.config.Prefix + version.String()
+	out.IsPrerelease = true
+
+	return out
+}
+
+// parseTag parses the tag leniently, e.g. with a v prefix.
+func parseTag(tag string) (semver.Version, error) {
+	version, err := semver.ParseTolerant(tag)
+	if err != nil {
+		return semver.Version{}, &InvalidTagError{
+			Tag: tag,
+			Err: fmt.Errorf("failed to parse tag %q or not valid semantic version: %s", tag, err),
+		}
+	}
+
+	return version, nil
+}
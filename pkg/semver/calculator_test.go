@@ -0,0 +1,288 @@
+package semver_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wakatime/semver-action/pkg/semver"
+
+	"github.com/alecthomas/assert"
+	blang "github.com/blang/semver/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculator_Calculate(t *testing.T) {
+	tests := map[string]struct {
+		Bump                 string
+		Input                semver.Input
+		ExpectedTag          string
+		ExpectedPreviousTag  string
+		ExpectedIsPrerelease bool
+		ExpectedInclude      string
+		ExpectedExclude      string
+		ExpectedSubstitution bool
+	}{
+		"feature into develop": {
+			Input: semver.Input{
+				SourceBranch: "feature/login",
+				DestBranch:   "develop",
+				LatestTag:    "v1.0.0",
+			},
+			ExpectedTag:          "v1.1.0-pre.1",
+			ExpectedPreviousTag:  "v1.0.0",
+			ExpectedIsPrerelease: true,
+			ExpectedInclude:      "v[0-9]*-pre*",
+		},
+		"fallback bumps build number": {
+			Input: semver.Input{
+				SourceBranch: "some-branch",
+				DestBranch:   "develop",
+				LatestTag:    "v1.1.0-pre.1",
+			},
+			ExpectedTag:          "v1.1.0-pre.2",
+			ExpectedPreviousTag:  "v1.1.0-pre.1",
+			ExpectedIsPrerelease: true,
+			ExpectedInclude:      "v[0-9]*-pre*",
+		},
+		"develop into main": {
+			Input: semver.Input{
+				SourceBranch: "develop",
+				DestBranch:   "master",
+				LatestTag:    "v1.1.0-pre.2",
+			},
+			ExpectedTag:         "v1.1.0",
+			ExpectedPreviousTag: "v1.1.0-pre.2",
+			ExpectedInclude:     "v[0-9]*",
+			ExpectedExclude:     "v[0-9]*-pre*",
+		},
+		"hotfix into main": {
+			Input: semver.Input{
+				SourceBranch: "hotfix/crash",
+				DestBranch:   "master",
+				LatestTag:    "v1.1.0",
+			},
+			ExpectedTag:         "v1.1.1",
+			ExpectedPreviousTag: "v1.1.0",
+			ExpectedInclude:     "v[0-9]*",
+			ExpectedExclude:     "v[0-9]*-pre*",
+		},
+		"no latest tag": {
+			Input: semver.Input{
+				SourceBranch: "feature/login",
+				DestBranch:   "develop",
+			},
+			ExpectedTag:          "v0.1.0-pre.1",
+			ExpectedPreviousTag:  "v0.0.0",
+			ExpectedIsPrerelease: true,
+			ExpectedInclude:      "v[0-9]*-pre*",
+		},
+		"base version": {
+			Input: semver.Input{
+				SourceBranch: "feature/login",
+				DestBranch:   "develop",
+				LatestTag:    "v1.0.0",
+				BaseVersion:  &blang.Version{Major: 3},
+			},
+			ExpectedTag:          "v3.1.0-pre.1",
+			ExpectedPreviousTag:  "v1.0.0",
+			ExpectedIsPrerelease: true,
+			ExpectedInclude:      "v[0-9]*-pre*",
+		},
+		"forced major": {
+			Bump: "major",
+			Input: semver.Input{
+				SourceBranch: "feature/login",
+				DestBranch:   "master",
+				LatestTag:    "v1.2.3",
+			},
+			ExpectedTag:         "v2.0.0",
+			ExpectedPreviousTag: "v1.2.3",
+			ExpectedInclude:     "v[0-9]*",
+			ExpectedExclude:     "v[0-9]*-pre*",
+		},
+		"doc into develop with ancestor develop tag": {
+			Input: semver.Input{
+				SourceBranch:       "doc/readme",
+				DestBranch:         "develop",
+				LatestTag:          "v1.1.0",
+				AncestorDevelopTag: "v1.1.0-pre.3",
+			},
+			ExpectedTag:          "v1.1.0-pre.4",
+			ExpectedPreviousTag:  "v1.1.0",
+			ExpectedIsPrerelease: true,
+			ExpectedInclude:      "v[0-9]*-pre*",
+			ExpectedSubstitution: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			bump := test.Bump
+			if bump == "" {
+				bump = semver.BumpAuto
+			}
+
+			calculator := semver.NewCalculator(semver.Config{
+				Bump:          bump,
+				Prefix:        "v",
+				PrereleaseID:  "pre",
+				MainBranch:    "master",
+				DevelopBranch: "develop",
+			})
+
+			out, err := calculator.Calculate(test.Input)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.ExpectedTag, out.Tag)
+			assert.Equal(t, test.ExpectedTag, "v"+out.Version.String())
+			assert.Equal(t, test.ExpectedPreviousTag, out.PreviousTag)
+			assert.Equal(t, test.ExpectedIsPrerelease, out.IsPrerelease)
+			assert.Equal(t, test.ExpectedInclude, out.AncestorInclude)
+			assert.Equal(t, test.ExpectedExclude, out.AncestorExclude)
+			assert.Equal(t, test.ExpectedSubstitution, out.Substitution.Applied)
+		})
+	}
+}
+
+func TestCalculator_CalculateInvalidTag(t *testing.T) {
+	calculator := semver.NewCalculator(semver.Config{
+		Bump:          semver.BumpAuto,
+		Prefix:        "v",
+		PrereleaseID:  "pre",
+		MainBranch:    "master",
+		DevelopBranch: "develop",
+	})
+
+	_, err := calculator.Calculate(semver.Input{
+		SourceBranch: "feature/login",
+		DestBranch:   "develop",
+		LatestTag:    "nightly",
+	})
+	require.Error(t, err)
+
+	var invalid *semver.InvalidTagError
+
+	require.True(t, errors.As(err, &invalid))
+	assert.Equal(t, "nightly", invalid.Tag)
+	assert.True(t, errors.Is(err, semver.ErrInvalidTag))
+}
+
+func TestCalculator_NeedsAncestorDevelopTag(t *testing.T) {
+	calculator := semver.NewCalculator(semver.Config{
+		Bump:          semver.BumpAuto,
+		Prefix:        "v",
+		PrereleaseID:  "pre",
+		MainBranch:    "master",
+		DevelopBranch: "develop",
+	})
+
+	assert.True(t, calculator.NeedsAncestorDevelopTag(semver.Input{SourceBranch: "doc/readme", DestBranch: "develop"}))
+	assert.True(t, calculator.NeedsAncestorDevelopTag(semver.Input{SourceBranch: "misc/ci", DestBranch: "develop"}))
+	assert.False(t, calculator.NeedsAncestorDevelopTag(semver.Input{SourceBranch: "feature/login", DestBranch: "develop"}))
+	assert.False(t, calculator.NeedsAncestorDevelopTag(semver.Input{SourceBranch: "doc/readme", DestBranch: "master"}))
+	assert.False(t, calculator.NeedsAncestorDevelopTag(semver.Input{
+		SourceBranch: "doc/readme",
+		DestBranch:   "develop",
+		Snapshot:     true,
+	}))
+	assert.Equal(t, "v[0-9]*-pre*", calculator.DevelopTagPattern())
+}
+
+func TestCalculator_Preview(t *testing.T) {
+	calculator := semver.NewCalculator(semver.Config{
+		Bump:          semver.BumpAuto,
+		Prefix:        "v",
+		PrereleaseID:  "pre",
+		MainBranch:    "master",
+		DevelopBranch: "develop",
+	})
+
+	tests := map[string]struct {
+		Input    semver.Input
+		Expected string
+	}{
+		"feature into develop": {
+			Input: semver.Input{
+				SourceBranch: "feature/login",
+				DestBranch:   "develop",
+				LatestTag:    "v1.5.3",
+			},
+			Expected: "v1.6.0-pr.482.3",
+		},
+		"develop into main": {
+			Input: semver.Input{
+				SourceBranch: "develop",
+				DestBranch:   "master",
+				LatestTag:    "v1.6.0-pre.2",
+			},
+			Expected: "v1.6.0-pr.482.3",
+		},
+		"build metadata is dropped": {
+			Input: semver.Input{
+				SourceBranch: "hotfix/crash",
+				DestBranch:   "master",
+				LatestTag:    "v1.5.3+linux",
+			},
+			Expected: "v1.5.4-pr.482.3",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			out, err := calculator.Calculate(test.Input)
+			require.NoError(t, err)
+
+			preview := calculator.Preview(out, 482, 3)
+
+			assert.Equal(t, test.Expected, preview.Tag)
+			assert.Equal(t, test.Expected[1:], preview.Version.String())
+			assert.True(t, preview.IsPrerelease)
+			assert.Equal(t, out.PreviousTag, preview.PreviousTag)
+		})
+	}
+}
+
+func TestCalculator_CalculateBranchSnapshots(t *testing.T) {
+	calculator := semver.NewCalculator(semver.Config{
+		Bump:            semver.BumpAuto,
+		Prefix:          "v",
+		PrereleaseID:    "pre",
+		MainBranch:      "master",
+		DevelopBranch:   "develop",
+		BranchSnapshots: true,
+	})
+
+	out, err := calculator.Calculate(semver.Input{
+		SourceBranch: "develop",
+		DestBranch:   "master",
+		LatestTag:    "v1.1.0-pre.2",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.1.0", out.Tag)
+	assert.Equal(t, "v[0-9]*", out.AncestorInclude)
+	assert.Equal(t, "v[0-9]*-*", out.AncestorExclude)
+}
+
+func TestCalculator_CalculateSnapshotSkipsSubstitution(t *testing.T) {
+	calculator := semver.NewCalculator(semver.Config{
+		Bump:            semver.BumpAuto,
+		Prefix:          "v",
+		PrereleaseID:    "pre",
+		MainBranch:      "master",
+		DevelopBranch:   "develop",
+		BranchSnapshots: true,
+	})
+
+	out, err := calculator.Calculate(semver.Input{
+		SourceBranch:       "doc/readme",
+		DestBranch:         "develop",
+		LatestTag:          "v1.1.0-pre.2",
+		AncestorDevelopTag: "766d04f1d2b8c1b4b6f0e1e5f5a3c2d1e0f9a8b7",
+		Snapshot:           true,
+	})
+	require.NoError(t, err)
+
+	assert.False(t, out.Substitution.Applied)
+	assert.Equal(t, "snapshot of a branch not merged yet", out.Substitution.Reason)
+}
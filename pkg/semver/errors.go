@@ -0,0 +1,28 @@
+package semver
+
+import "errors"
+
+// ErrInvalidTag is returned when a tag or version is not a valid semantic
+// version. The error is an InvalidTagError holding the tag.
+var ErrInvalidTag = errors.New("invalid tag") // nolint
+
+// InvalidTagError is returned when a tag or version is not a valid semantic version.
+type InvalidTagError struct {
+	Tag string
+	Err error
+}
+
+// Error returns the reason the tag is invalid.
+func (e *InvalidTagError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the reason the tag is invalid.
+func (e *InvalidTagError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is match ErrInvalidTag.
+func (*InvalidTagError) Is(target error) bool {
+	return target == ErrInvalidTag
+}
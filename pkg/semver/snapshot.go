@@ -0,0 +1,98 @@
+package semver
+
+import (
+	"crypto/sha1" // nolint:gosec
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// MaxBranchIdentifierLength is the maximum length of the prerelease identifier
+// created from a branch name, including the hash appended when it's truncated.
+const MaxBranchIdentifierLength = 32
+
+// nolint: gochecknoglobals
+var (
+	invalidIdentifierRegex = regexp.MustCompile(`[^a-z0-9-]+`)
+	numericIdentifierRegex = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// BranchIdentifier turns a branch name into a valid prerelease identifier, e.g.
+// feature/Login_OAuth into feature-login-oauth. It is lowercased, characters
+// other than letters, digits and hyphens are replaced with a hyphen and it is
+// capped at MaxBranchIdentifierLength. A truncated identifier ends with the
+// first 7 characters of the SHA-1 of the branch name, so long branches with the
+// same beginning don't collide.
+func BranchIdentifier(branch string) string {
+	id := invalidIdentifierRegex.ReplaceAllString(strings.ToLower(branch), "-")
+	id = strings.Trim(id, "-")
+
+	// numeric identifiers would compare as numbers and don't allow leading zeros
+	if id == "" || numericIdentifierRegex.MatchString(id) {
+		id = strings.TrimSuffix("branch-"+id, "-")
+	}
+
+	if len(id) <= MaxBranchIdentifierLength {
+		return id
+	}
+
+	sum := sha1.Sum([]byte(branch)) // nolint:gosec
+	hash := hex.EncodeToString(sum[:])[:7]
+
+	return strings.TrimRight(id[:MaxBranchIdentifierLength-len(hash)-1], "-") + "-" + hash
+}
+
+// SnapshotID returns the prerelease identifier of the snapshots of the branch.
+// Identifiers used by releases and previews get a branch- prefix, so snapshots
+// never continue their counters.
+func (c *Calculator) SnapshotID(branch string) string {
+	id := BranchIdentifier(branch)
+
+	if id == c.config.PrereleaseID || id == PreviewID {
+		return "branch-" + id
+	}
+
+	return id
+}
+
+// Snapshot turns the calculated version of a merge into the snapshot version of
+// the branch, e.g. 1.6.0-feature-login-oauth.4. The counter continues from the
+// highest of the tags with the same version and branch identifier, so every
+// branch has its own.
+func (c *Calculator) Snapshot(out Output, branch string, tags []string) Output {
+	id := c.SnapshotID(branch)
+
+	version := out.Version
+	version.Pre = nil
+	version.Build = nil
+
+	var counter uint64
+
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, c.config.Prefix) {
+			continue
+		}
+
+		existing, err := semver.Parse(strings.TrimPrefix(tag, c.config.Prefix))
+		if err != nil || len(existing.Pre) != 2 || existing.Pre[0].VersionStr != id || !existing.Pre[1].IsNum {
+			continue
+		}
+
+		if existing.FinalizeVersion() == version.String() && existing.Pre[1].VersionNum > counter {
+			counter = existing.Pre[1].VersionNum
+		}
+	}
+
+	version.Pre = []semver.PRVersion{
+		{VersionStr: id},
+		{VersionNum: counter + 1, IsNum: true},
+	}
+
+	out.Version = version
+	out.Tag = c.config.Prefix + version.String()
+	out.IsPrerelease = true
+
+	return out
+}
@@ -0,0 +1,105 @@
+package semver_test
+
+import (
+	"testing"
+
+	"github.com/wakatime/semver-action/pkg/semver"
+
+	"github.com/alecthomas/assert"
+	blang "github.com/blang/semver/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBranchIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"feature/login-oauth":    "feature-login-oauth",
+		"Feature/Login_OAuth":    "feature-login-oauth",
+		"bugfix/#123 crash!":     "bugfix-123-crash",
+		"feature//login--":       "feature-login",
+		"123":                    "branch-123",
+		"/_/":                    "branch",
+		"dependabot/npm/lodash":  "dependabot-npm-lodash",
+		"feature/ünicode-branch": "feature-nicode-branch",
+	}
+
+	for branch, expected := range tests {
+		t.Run(branch, func(t *testing.T) {
+			id := semver.BranchIdentifier(branch)
+
+			assert.Equal(t, expected, id)
+
+			_, err := blang.NewPRVersion(id)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestBranchIdentifier_Truncated(t *testing.T) {
+	first := semver.BranchIdentifier("feature/a-very-long-branch-name-for-the-login-with-oauth")
+	second := semver.BranchIdentifier("feature/a-very-long-branch-name-for-the-login-with-saml")
+
+	assert.Equal(t, semver.MaxBranchIdentifierLength, len(first))
+	assert.Equal(t, "feature-a-very-long-bran-", first[:25])
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, first, semver.BranchIdentifier("feature/a-very-long-branch-name-for-the-login-with-oauth"))
+
+	_, err := blang.NewPRVersion(first)
+	require.NoError(t, err)
+}
+
+func TestCalculator_SnapshotID(t *testing.T) {
+	calculator := semver.NewCalculator(semver.Config{Prefix: "v", PrereleaseID: "pre"})
+
+	assert.Equal(t, "feature-login", calculator.SnapshotID("feature/login"))
+	assert.Equal(t, "branch-pre", calculator.SnapshotID("pre"))
+	assert.Equal(t, "branch-pr", calculator.SnapshotID("PR"))
+}
+
+func TestCalculator_Snapshot(t *testing.T) {
+	calculator := semver.NewCalculator(semver.Config{
+		Bump:          semver.BumpAuto,
+		Prefix:        "v",
+		PrereleaseID:  "pre",
+		MainBranch:    "master",
+		DevelopBranch: "develop",
+	})
+
+	out, err := calculator.Calculate(semver.Input{
+		SourceBranch: "feature/login-oauth",
+		DestBranch:   "develop",
+		LatestTag:    "v1.5.3",
+	})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		Tags     []string
+		Expected string
+	}{
+		"first snapshot": {
+			Expected: "v1.6.0-feature-login-oauth.1",
+		},
+		"counter of the branch": {
+			Tags: []string{
+				"v1.5.3",
+				"v1.6.0-pre.7",
+				"v1.6.0-feature-login-oauth.1",
+				"v1.6.0-feature-login-oauth.3",
+				"v1.6.0-feature-signup.9",
+				"v1.5.0-feature-login-oauth.12",
+				"1.6.0-feature-login-oauth.8",
+			},
+			Expected: "v1.6.0-feature-login-oauth.4",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			snapshot := calculator.Snapshot(out, "feature/login-oauth", test.Tags)
+
+			assert.Equal(t, test.Expected, snapshot.Tag)
+			assert.Equal(t, test.Expected[1:], snapshot.Version.String())
+			assert.True(t, snapshot.IsPrerelease)
+			assert.Equal(t, "v1.5.3", snapshot.PreviousTag)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package semver
+
+// Method is how a version is bumped.
+type Method string
+
+// Bump methods.
+const (
+	// MethodBuild bumps the prerelease build number, optionally after bumping a component.
+	MethodBuild Method = "build"
+	// MethodMajor bumps the major version.
+	MethodMajor Method = "major"
+	// MethodMinor bumps the minor version.
+	MethodMinor Method = "minor"
+	// MethodPatch bumps the patch version.
+	MethodPatch Method = "patch"
+	// MethodHotfix bumps the patch version of a final release.
+	MethodHotfix Method = "hotfix"
+	// MethodFinal removes the prerelease part.
+	MethodFinal Method = "final"
+)
+
+// Component is the version component bumped before a build.
+type Component string
+
+// Version components.
+const (
+	ComponentNone  Component = ""
+	ComponentMajor Component = "major"
+	ComponentMinor Component = "minor"
+	ComponentPatch Component = "patch"
+)
+
+// BumpAuto determines the strategy from the source and dest branch. Any other
+// bump value forces the method of the same name.
+const BumpAuto = "auto"
+
+// Names of the rules matched by Calculator.Strategy.
+const (
+	RuleForced             = "forced"
+	RuleBugfixIntoDevelop  = "bugfix-into-develop"
+	RuleDocIntoDevelop     = "doc-into-develop"
+	RuleFeatureIntoDevelop = "feature-into-develop"
+	RuleMajorIntoDevelop   = "major-into-develop"
+	RuleMiscIntoDevelop    = "misc-into-develop"
+	RuleHotfixIntoMain     = "hotfix-into-main"
+	RuleResyncIntoDevelop  = "resync-into-develop"
+	RuleDevelopIntoMain    = "develop-into-main"
+	RuleFallback           = "fallback"
+)
+
+// Strategy is how a merge bumps the version.
+type Strategy struct {
+	Method    Method
+	Component Component
+	// Rule is the name of the matched rule.
+	Rule string
+}
+
+// Increments returns true if the strategy bumps the component.
+func (s Strategy) Increments(component Component) bool {
+	if s.Method == MethodBuild {
+		return s.Component == component
+	}
+
+	if s.Method == MethodHotfix {
+		return component == ComponentPatch
+	}
+
+	return string(s.Method) == string(component)
+}
+
+// BumpType returns the version component bumped by the strategy, prerelease
+// for builds without a component or the method otherwise.
+func (s Strategy) BumpType() string {
+	switch s.Method {
+	case MethodHotfix:
+		return string(ComponentPatch)
+	case MethodBuild:
+		if s.Component != ComponentNone {
+			return string(s.Component)
+		}
+
+		return "prerelease"
+	default:
+		return string(s.Method)
+	}
+}
@@ -0,0 +1,154 @@
+package semver_test
+
+import (
+	"testing"
+
+	"github.com/wakatime/semver-action/pkg/semver"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestCalculator_Strategy(t *testing.T) {
+	tests := map[string]struct {
+		SourceBranch      string
+		DestBranch        string
+		Bump              string
+		ExpectedMethod    semver.Method
+		ExpectedComponent semver.Component
+		ExpectedRule      string
+	}{
+		"source branch bugfix, dest branch develop and auto bump": {
+			SourceBranch:      "bugfix/some",
+			DestBranch:        "develop",
+			Bump:              "auto",
+			ExpectedMethod:    "build",
+			ExpectedComponent: "patch",
+			ExpectedRule:      "bugfix-into-develop",
+		},
+		"source branch doc, dest branch develop and auto bump": {
+			SourceBranch:      "doc/some",
+			DestBranch:        "develop",
+			Bump:              "auto",
+			ExpectedMethod:    "build",
+			ExpectedComponent: "",
+			ExpectedRule:      "doc-into-develop",
+		},
+		"source branch feature, dest branch develop and auto bump": {
+			SourceBranch:      "feature/some",
+			DestBranch:        "develop",
+			Bump:              "auto",
+			ExpectedMethod:    "build",
+			ExpectedComponent: "minor",
+			ExpectedRule:      "feature-into-develop",
+		},
+		"source branch major, dest branch develop and auto bump": {
+			SourceBranch:      "major/some",
+			DestBranch:        "develop",
+			Bump:              "auto",
+			ExpectedMethod:    "build",
+			ExpectedComponent: "major",
+			ExpectedRule:      "major-into-develop",
+		},
+		"source branch misc, dest branch develop and auto bump": {
+			SourceBranch:      "misc/some",
+			DestBranch:        "develop",
+			Bump:              "auto",
+			ExpectedMethod:    "build",
+			ExpectedComponent: "",
+			ExpectedRule:      "misc-into-develop",
+		},
+		"source branch hotfix, dest branch master and auto bump": {
+			SourceBranch:   "hotfix/some",
+			DestBranch:     "master",
+			Bump:           "auto",
+			ExpectedMethod: "hotfix",
+			ExpectedRule:   "hotfix-into-main",
+		},
+		"source branch resync, dest branch develop and auto bump": {
+			SourceBranch:      "resync/some",
+			DestBranch:        "develop",
+			Bump:              "auto",
+			ExpectedMethod:    "build",
+			ExpectedComponent: "patch",
+			ExpectedRule:      "resync-into-develop",
+		},
+		"source branch develop, dest branch master and auto bump": {
+			SourceBranch:   "develop",
+			DestBranch:     "master",
+			Bump:           "auto",
+			ExpectedMethod: "final",
+			ExpectedRule:   "develop-into-main",
+		},
+		"not a valid source branch prefix and auto bump": {
+			SourceBranch:   "some-branch",
+			Bump:           "auto",
+			ExpectedMethod: "build",
+			ExpectedRule:   "fallback",
+		},
+		"patch bump": {
+			Bump:           "patch",
+			ExpectedMethod: "patch",
+			ExpectedRule:   "forced",
+		},
+		"minor bump": {
+			Bump:           "minor",
+			ExpectedMethod: "minor",
+			ExpectedRule:   "forced",
+		},
+		"major bump": {
+			Bump:           "major",
+			ExpectedMethod: "major",
+			ExpectedRule:   "forced",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			calculator := semver.NewCalculator(semver.Config{
+				Bump:          test.Bump,
+				MainBranch:    "master",
+				DevelopBranch: "develop",
+			})
+
+			strategy := calculator.Strategy(test.SourceBranch, test.DestBranch)
+
+			assert.Equal(t, test.ExpectedMethod, strategy.Method)
+			assert.Equal(t, test.ExpectedComponent, strategy.Component)
+			assert.Equal(t, test.ExpectedRule, strategy.Rule)
+		})
+	}
+}
+
+func TestStrategy_BumpType(t *testing.T) {
+	tests := map[string]struct {
+		Strategy semver.Strategy
+		Expected string
+	}{
+		"build with minor": {
+			Strategy: semver.Strategy{Method: semver.MethodBuild, Component: semver.ComponentMinor},
+			Expected: "minor",
+		},
+		"build": {
+			Strategy: semver.Strategy{Method: semver.MethodBuild},
+			Expected: "prerelease",
+		},
+		"hotfix": {
+			Strategy: semver.Strategy{Method: semver.MethodHotfix},
+			Expected: "patch",
+		},
+		"final": {
+			Strategy: semver.Strategy{Method: semver.MethodFinal},
+			Expected: "final",
+		},
+		"forced major": {
+			Strategy: semver.Strategy{Method: semver.MethodMajor},
+			Expected: "major",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.Expected, test.Strategy.BumpType())
+		})
+	}
+}